@@ -1,6 +1,7 @@
 package main
 
 import (
+	stderrors "errors"
 	"os"
 
 	"github.com/brevdev/brev-cli/pkg/cmd"
@@ -14,6 +15,11 @@ func main() {
 	command := cmd.NewDefaultBrevCommand()
 
 	if err := command.Execute(); err != nil {
+		var exitCodeErr errors.ExitCodeError
+		if stderrors.As(err, &exitCodeErr) {
+			done()
+			os.Exit(exitCodeErr.Code)
+		}
 		cmderrors.DisplayAndHandleError(err)
 		done()
 		os.Exit(1)
@@ -0,0 +1,151 @@
+package autoforward
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1 0000000000000000 100 0 0 10 0
+   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:0BB8 0100007F:D6F2 01 00000000:00000000 00:00000000 00000000  1000        0 3 1 0000000000000000 20 4 30 10 -1
+`
+
+const procNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4 1 0000000000000000 100 0 0 10 0
+`
+
+func TestParseProcNetTCP(t *testing.T) {
+	ports, err := ParseProcNetTCP(procNetTCP)
+	assert.Nil(t, err)
+	assert.Equal(t, []ListeningPort{{Port: 3000, Address: "127.0.0.1"}, {Port: 22, Address: "0.0.0.0"}}, ports)
+
+	ports, err = ParseProcNetTCP(procNetTCP6)
+	assert.Nil(t, err)
+	assert.Equal(t, []ListeningPort{{Port: 8080, Address: "::1"}}, ports)
+}
+
+func TestParsePortRanges(t *testing.T) {
+	ranges, err := ParsePortRanges([]string{"3000-3999,8080", "9000"})
+	assert.Nil(t, err)
+	assert.Equal(t, []PortRange{{From: 3000, To: 3999}, {From: 8080, To: 8080}, {From: 9000, To: 9000}}, ranges)
+
+	for _, spec := range []string{"", "a", "10-5", "0", "1-70000"} {
+		_, err = ParsePortRanges([]string{spec})
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestPortFilter(t *testing.T) {
+	filter := PortFilter{Deny: DefaultDeny}
+	assert.True(t, filter.Allows(3000))
+	assert.False(t, filter.Allows(22))
+
+	filter = PortFilter{Allow: []PortRange{{From: 3000, To: 3999}}, Deny: []PortRange{{From: 3306, To: 3306}}}
+	assert.True(t, filter.Allows(3000))
+	assert.False(t, filter.Allows(3306))
+	assert.False(t, filter.Allows(8080))
+}
+
+type fakeForward struct {
+	stopped bool
+	done    chan error
+}
+
+type fakeForwarder struct {
+	mu       sync.Mutex
+	forwards map[int]*fakeForward
+}
+
+func (f *fakeForwarder) Forward(port int) (func(), <-chan error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fwd := &fakeForward{done: make(chan error, 1)}
+	f.forwards[port] = fwd
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		fwd.stopped = true
+		fwd.done <- nil
+	}, fwd.done
+}
+
+func ports(forwards []Forward) []int {
+	res := []int{}
+	for _, f := range forwards {
+		res = append(res, f.Port)
+	}
+	return res
+}
+
+func TestAutoForwarderUpdate(t *testing.T) {
+	forwarder := &fakeForwarder{forwards: map[int]*fakeForward{}}
+	af := NewAutoForwarder(forwarder, PortFilter{Deny: DefaultDeny})
+
+	changed := af.Update([]ListeningPort{{Port: 22}, {Port: 3000}, {Port: 8080}})
+	assert.True(t, changed)
+	assert.Equal(t, []int{3000, 8080}, ports(af.Forwards()))
+
+	changed = af.Update([]ListeningPort{{Port: 22}, {Port: 3000}, {Port: 8080}})
+	assert.False(t, changed)
+
+	changed = af.Update([]ListeningPort{{Port: 3000}, {Port: 5000}})
+	assert.True(t, changed)
+	assert.Equal(t, []int{3000, 5000}, ports(af.Forwards()))
+	assert.True(t, forwarder.forwards[8080].stopped)
+
+	// a forward that gives up is shown as failed
+	forwarder.forwards[5000].done <- errors.New("address already in use")
+	select {
+	case <-af.Changes():
+	case <-time.After(time.Second):
+		t.Fatal("no change")
+	}
+	forwards := af.Forwards()
+	assert.Nil(t, forwards[0].Err)
+	assert.EqualError(t, forwards[1].Err, "address already in use")
+
+	af.StopAll()
+	assert.Empty(t, af.Forwards())
+	assert.True(t, forwarder.forwards[3000].stopped)
+}
+
+func TestWatchListeningPorts(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "/ports.json", []byte(`{"ports":[{"port":3000,"address":"127.0.0.1"}]}`), 0o644)
+	assert.Nil(t, err)
+	out := &bytes.Buffer{}
+	stop := make(chan struct{})
+	close(stop)
+	err = WatchListeningPorts(fs, "/ports.json", time.Millisecond, out, stop)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"ports\":[{\"port\":3000,\"address\":\"127.0.0.1\"}]}\n", out.String())
+}
+
+func TestListeningPortsTask(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	task := ListeningPortsTask{
+		Store: brevHomeStore("/home/brev/.brev"),
+		fs:    fs,
+		getPorts: func() ([]ListeningPort, error) {
+			return []ListeningPort{{Port: 3000, Address: "127.0.0.1"}}, nil
+		},
+	}
+	err := task.Run()
+	assert.Nil(t, err)
+	data, err := afero.ReadFile(fs, "/home/brev/.brev/"+ReportFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ports":[{"port":3000,"address":"127.0.0.1"}]}`, string(data))
+}
+
+type brevHomeStore string
+
+func (b brevHomeStore) GetBrevHomePath() (string, error) {
+	return string(b), nil
+}
@@ -0,0 +1,261 @@
+package autoforward
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/portforward"
+)
+
+type PortRange struct {
+	From int
+	To   int
+}
+
+func (r PortRange) Contains(port int) bool {
+	return port >= r.From && port <= r.To
+}
+
+// ParsePortRanges parses ports and ranges like 8080 and 3000-3999
+func ParsePortRanges(specs []string) ([]PortRange, error) {
+	ranges := []PortRange{}
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			bounds := strings.SplitN(part, "-", 2)
+			if len(bounds) == 1 {
+				bounds = append(bounds, bounds[0])
+			}
+			from, fromErr := strconv.Atoi(bounds[0])
+			to, toErr := strconv.Atoi(bounds[1])
+			if fromErr != nil || toErr != nil || from < 1 || to > 65535 || from > to {
+				return nil, breverrors.NewValidationError(fmt.Sprintf("invalid port range %q, use a port like 8080 or a range like 3000-3999", part))
+			}
+			ranges = append(ranges, PortRange{From: from, To: to})
+		}
+	}
+	return ranges, nil
+}
+
+// DefaultDeny is never forwarded, sshd of the workspace
+var DefaultDeny = []PortRange{{From: 22, To: 22}}
+
+// PortFilter forwards the ports in Allow, or every port when Allow is empty,
+// except the ports in Deny
+type PortFilter struct {
+	Allow []PortRange
+	Deny  []PortRange
+}
+
+func (f PortFilter) Allows(port int) bool {
+	for _, r := range f.Deny {
+		if r.Contains(port) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, r := range f.Allow {
+		if r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+type Forwarder interface {
+	// Forward forwards port on the workspace to the same port on this
+	// machine until stop is called, done gets the error the forward ended with
+	Forward(port int) (stop func(), done <-chan error)
+}
+
+// Forward is a port being forwarded, Err is set once the forward gave up
+type Forward struct {
+	Port  int
+	Since time.Time
+	Err   error
+}
+
+type activeForward struct {
+	Forward
+	stop func()
+}
+
+// AutoForwarder opens a forward for each allowed port the workspace reports
+// and closes it when the server goes away
+type AutoForwarder struct {
+	Filter    PortFilter
+	Forwarder Forwarder
+
+	mu      sync.Mutex
+	active  map[int]*activeForward
+	changes chan struct{}
+	now     func() time.Time
+}
+
+func NewAutoForwarder(forwarder Forwarder, filter PortFilter) *AutoForwarder {
+	return &AutoForwarder{
+		Filter:    filter,
+		Forwarder: forwarder,
+		active:    map[int]*activeForward{},
+		changes:   make(chan struct{}, 1),
+		now:       time.Now,
+	}
+}
+
+// Changes gets a value when a forward fails on its own
+func (a *AutoForwarder) Changes() <-chan struct{} {
+	return a.changes
+}
+
+// Update opens and closes forwards to match the listening ports, it is
+// whether anything changed
+func (a *AutoForwarder) Update(ports []ListeningPort) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	wanted := map[int]bool{}
+	for _, p := range ports {
+		if a.Filter.Allows(p.Port) {
+			wanted[p.Port] = true
+		}
+	}
+	changed := false
+	for port, f := range a.active {
+		if !wanted[port] {
+			f.stop()
+			delete(a.active, port)
+			changed = true
+		}
+	}
+	for port := range wanted {
+		if _, ok := a.active[port]; ok {
+			continue
+		}
+		stop, done := a.Forwarder.Forward(port)
+		f := &activeForward{Forward: Forward{Port: port, Since: a.now()}, stop: stop}
+		a.active[port] = f
+		go a.watch(f, done)
+		changed = true
+	}
+	return changed
+}
+
+// watch records why a forward ended unless it was closed on purpose
+func (a *AutoForwarder) watch(f *activeForward, done <-chan error) {
+	err := <-done
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.active[f.Port] != f {
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("stopped")
+	}
+	f.Err = err
+	select {
+	case a.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Forwards is every forward sorted by port
+func (a *AutoForwarder) Forwards() []Forward {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	forwards := []Forward{}
+	for _, f := range a.active {
+		forwards = append(forwards, f.Forward)
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].Port < forwards[j].Port })
+	return forwards
+}
+
+func (a *AutoForwarder) StopAll() {
+	a.Update(nil)
+}
+
+// PortForwardForwarder forwards with brev port-forward
+type PortForwardForwarder struct {
+	// NewOptions is the port forward options of the workspace
+	NewOptions func() (*portforward.PortForwardOptions, error)
+}
+
+var _ Forwarder = PortForwardForwarder{}
+
+func (p PortForwardForwarder) Forward(port int) (func(), <-chan error) {
+	done := make(chan error, 1)
+	opts, err := p.NewOptions()
+	if err != nil {
+		done <- breverrors.WrapAndTrace(err)
+		return func() {}, done
+	}
+	opts.WithPorts([]portforward.PortMapping{{Local: strconv.Itoa(port), Remote: strconv.Itoa(port)}})
+	go func() {
+		done <- opts.RunPortforward()
+	}()
+	return opts.Stop, done
+}
+
+type PortSubscriber interface {
+	// Subscribe sends the listening ports each time they change until stop
+	// is closed or the connection drops
+	Subscribe(reports chan<- []ListeningPort, stop <-chan struct{}) error
+}
+
+// SSHPortSubscriber runs brev listening-ports --watch in the workspace
+type SSHPortSubscriber struct {
+	SSHName string
+}
+
+var _ PortSubscriber = SSHPortSubscriber{}
+
+func (s SSHPortSubscriber) Subscribe(reports chan<- []ListeningPort, stop <-chan struct{}) error {
+	cmd := exec.Command("ssh", "-T", "-o", "ServerAliveInterval=15", s.SSHName, "brev", "listening-ports", "--watch") //nolint:gosec // the host is from the brev ssh config
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-stop:
+			_ = cmd.Process.Kill()
+		case <-exited:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var report Report
+		err = json.Unmarshal(scanner.Bytes(), &report)
+		if err != nil {
+			continue
+		}
+		select {
+		case reports <- report.Ports:
+		case <-stop:
+		}
+	}
+	err = cmd.Wait()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package autoforward forwards the ports servers listen on in a workspace as
+// they come and go, like the port forwarding of VS Code
+package autoforward
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+// ReportFileName lives in brev home, ex: /home/brev/.brev/listening_ports.json
+const ReportFileName = "listening_ports.json"
+
+const tcpListenState = "0A"
+
+type ListeningPort struct {
+	Port    int    `json:"port"`
+	Address string `json:"address"`
+}
+
+// Report is what the workspace tells brev forward --auto
+type Report struct {
+	Ports []ListeningPort `json:"ports"`
+}
+
+// ParseProcNetTCP returns the listening sockets in /proc/net/tcp or
+// /proc/net/tcp6
+func ParseProcNetTCP(data string) ([]ListeningPort, error) {
+	ports := []ListeningPort{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	first := true
+	for scanner.Scan() {
+		if first {
+			// header
+			first = false
+			continue
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != tcpListenState {
+			continue
+		}
+		port, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		ports = append(ports, port)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return ports, nil
+}
+
+// parseProcNetAddress parses ip:port in hex, the ip is in 32 bit words of
+// host byte order
+func parseProcNetAddress(address string) (ListeningPort, error) {
+	parts := strings.Split(address, ":")
+	if len(parts) != 2 {
+		return ListeningPort{}, fmt.Errorf("invalid address %q", address)
+	}
+	ipBytes, err := hex.DecodeString(parts[0])
+	if err != nil || (len(ipBytes) != net.IPv4len && len(ipBytes) != net.IPv6len) {
+		return ListeningPort{}, fmt.Errorf("invalid address %q", address)
+	}
+	for i := 0; i < len(ipBytes); i += 4 {
+		ipBytes[i], ipBytes[i+1], ipBytes[i+2], ipBytes[i+3] = ipBytes[i+3], ipBytes[i+2], ipBytes[i+1], ipBytes[i]
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return ListeningPort{}, fmt.Errorf("invalid address %q", address)
+	}
+	return ListeningPort{Port: int(port), Address: net.IP(ipBytes).String()}, nil
+}
+
+// GetListeningPorts is every port a server listens on, once per port
+func GetListeningPorts() ([]ListeningPort, error) {
+	byPort := map[int]ListeningPort{}
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := ioutil.ReadFile(path) //nolint:gosec // fixed path
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		ports, err := ParseProcNetTCP(string(data))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		for _, p := range ports {
+			if _, ok := byPort[p.Port]; !ok {
+				byPort[p.Port] = p
+			}
+		}
+	}
+	ports := []ListeningPort{}
+	for _, p := range byPort {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Port < ports[j].Port })
+	return ports, nil
+}
+
+type ListeningPortsStore interface {
+	GetBrevHomePath() (string, error)
+}
+
+// ListeningPortsTask runs in the workspace and keeps ReportFileName up to
+// date with the ports servers listen on
+type ListeningPortsTask struct {
+	Store ListeningPortsStore
+
+	fs       afero.Fs
+	getPorts func() ([]ListeningPort, error)
+}
+
+var _ tasks.Task = ListeningPortsTask{}
+
+func NewListeningPortsTask(store ListeningPortsStore) ListeningPortsTask {
+	return ListeningPortsTask{
+		Store:    store,
+		fs:       afero.NewOsFs(),
+		getPorts: GetListeningPorts,
+	}
+}
+
+func (l ListeningPortsTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 2s"}
+}
+
+func (l ListeningPortsTask) Configure() error {
+	return nil
+}
+
+func (l ListeningPortsTask) Run() error {
+	ports, err := l.getPorts()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	data, err := json.Marshal(Report{Ports: ports})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	path, err := GetReportPath(l.Store)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// unchanged reports are not written so watchers only wake up on changes
+	_, err = files.WriteFileAtomic(l.fs, path, data, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func GetReportPath(store ListeningPortsStore) (string, error) {
+	brevHome, err := store.GetBrevHomePath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(brevHome, ReportFileName), nil
+}
+
+// WatchListeningPorts writes a line of json to out each time the report
+// changes, when the task is not running the ports are read directly
+func WatchListeningPorts(fs afero.Fs, reportPath string, interval time.Duration, out io.Writer, stop <-chan struct{}) error {
+	var last []byte
+	for {
+		data, err := afero.ReadFile(fs, reportPath)
+		if os.IsNotExist(err) {
+			ports, portsErr := GetListeningPorts()
+			if portsErr != nil {
+				return breverrors.WrapAndTrace(portsErr)
+			}
+			data, err = json.Marshal(Report{Ports: ports})
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		data = bytes.TrimSpace(data)
+		if !bytes.Equal(data, last) {
+			_, err = fmt.Fprintf(out, "%s\n", data)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			last = data
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
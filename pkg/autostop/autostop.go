@@ -0,0 +1,324 @@
+// Package autostop stops a workspace from the inside once nobody has used it for a while
+package autostop
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/analytics"
+	"github.com/brevdev/brev-cli/pkg/cmd/stop"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+const (
+	// OverrideFileName lives in brev home, ex: /home/brev/.brev/autostop.yaml
+	OverrideFileName = "autostop.yaml"
+
+	DefaultIdleTimeout      = 2 * time.Hour
+	DefaultWarnBefore       = 10 * time.Minute
+	DefaultCPULoadThreshold = 0.5
+	// DefaultIdleThreshold is the idle score above which the workspace counts as idle
+	DefaultIdleThreshold = 0.8
+	// CodeServerActiveWindow is how recent the code-server heartbeat must be
+	// to count as someone having the editor open
+	CodeServerActiveWindow = 5 * time.Minute
+
+	sshWeight        = 0.5
+	codeServerWeight = 0.3
+	cpuWeight        = 0.2
+)
+
+// Policy is how long a workspace may sit idle. Auto stop is off until it is
+// turned on per workspace by writing OverrideFileName, ex:
+//
+//	enabled: true
+//	idleTimeout: 4h
+//	warnBefore: 15m
+//	cpuLoadThreshold: 0.8
+type Policy struct {
+	Enabled          bool
+	IdleTimeout      time.Duration
+	WarnBefore       time.Duration
+	CPULoadThreshold float64
+	IdleThreshold    float64
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		IdleTimeout:      DefaultIdleTimeout,
+		WarnBefore:       DefaultWarnBefore,
+		CPULoadThreshold: DefaultCPULoadThreshold,
+		IdleThreshold:    DefaultIdleThreshold,
+	}
+}
+
+type overrideFile struct {
+	Enabled          bool    `json:"enabled"`
+	IdleTimeout      string  `json:"idleTimeout"`
+	WarnBefore       string  `json:"warnBefore"`
+	CPULoadThreshold float64 `json:"cpuLoadThreshold"`
+	IdleThreshold    float64 `json:"idleThreshold"`
+}
+
+// ParsePolicy applies an override file on top of the default policy
+func ParsePolicy(data []byte) (Policy, error) {
+	policy := DefaultPolicy()
+	override := overrideFile{}
+	err := yaml.UnmarshalStrict(data, &override)
+	if err != nil {
+		return policy, breverrors.WrapAndTrace(err)
+	}
+	policy.Enabled = override.Enabled
+	if override.IdleTimeout != "" {
+		policy.IdleTimeout, err = time.ParseDuration(override.IdleTimeout)
+		if err != nil {
+			return policy, breverrors.WrapAndTrace(err)
+		}
+	}
+	if override.WarnBefore != "" {
+		policy.WarnBefore, err = time.ParseDuration(override.WarnBefore)
+		if err != nil {
+			return policy, breverrors.WrapAndTrace(err)
+		}
+	}
+	if override.CPULoadThreshold > 0 {
+		policy.CPULoadThreshold = override.CPULoadThreshold
+	}
+	if override.IdleThreshold > 0 {
+		policy.IdleThreshold = override.IdleThreshold
+	}
+	if policy.WarnBefore > policy.IdleTimeout {
+		policy.WarnBefore = policy.IdleTimeout
+	}
+	return policy, nil
+}
+
+// Activity is a snapshot of the signals that mean someone is using the workspace
+type Activity struct {
+	SSHConnections int
+	// CPULoad is the 1 minute load average divided by the number of cpus
+	CPULoad              float64
+	CodeServerLastActive time.Time
+}
+
+// IdleScore is 1 when there is no activity at all and goes down as ssh
+// sessions, an open editor and cpu load are seen
+func (p Policy) IdleScore(a Activity, now time.Time) float64 {
+	active := 0.0
+	if a.SSHConnections > 0 {
+		active += sshWeight
+	}
+	if !a.CodeServerLastActive.IsZero() && now.Sub(a.CodeServerLastActive) < CodeServerActiveWindow {
+		active += codeServerWeight
+	}
+	if p.CPULoadThreshold > 0 {
+		active += cpuWeight * minFloat(a.CPULoad/p.CPULoadThreshold, 1)
+	}
+	return 1 - active
+}
+
+// IsIdle is false whenever the cpu load reaches CPULoadThreshold, a training
+// run or build left going without a session is not idle even though the
+// weighted score alone could not tell
+func (p Policy) IsIdle(a Activity, now time.Time) bool {
+	if p.CPULoadThreshold > 0 && a.CPULoad >= p.CPULoadThreshold {
+		return false
+	}
+	return p.IdleScore(a, now) > p.IdleThreshold
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type AutoStopStore interface {
+	stop.StopStore
+	GetBrevHomePath() (string, error)
+}
+
+// AutoStopTask checks for activity every minute, broadcasts a warning to
+// attached terminals WarnBefore the idle timeout and then stops the workspace
+type AutoStopTask struct {
+	Store AutoStopStore
+
+	getActivity func() (Activity, error)
+	broadcast   func(message string) error
+	stop        func() error
+	now         func() time.Time
+
+	lastActive time.Time
+	warned     bool
+}
+
+var _ tasks.Task = &AutoStopTask{}
+
+func NewAutoStopTask(store AutoStopStore) *AutoStopTask {
+	sshMonitor := analytics.NewSSHMonitor()
+	return &AutoStopTask{
+		Store: store,
+		getActivity: func() (Activity, error) {
+			return getActivity(sshMonitor)
+		},
+		broadcast: wall,
+		stop: func() error {
+			return stop.StopThisWorkspace(store, terminal.New())
+		},
+		now:        time.Now,
+		lastActive: time.Now(),
+	}
+}
+
+func (a AutoStopTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+func (a AutoStopTask) Configure() error {
+	return nil
+}
+
+func (a *AutoStopTask) Run() error {
+	policy, err := a.getPolicy()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	now := a.now()
+	if !policy.Enabled {
+		a.markActive(now)
+		return nil
+	}
+
+	activity, err := a.getActivity()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !policy.IsIdle(activity, now) {
+		a.markActive(now)
+		return nil
+	}
+
+	idleFor := now.Sub(a.lastActive)
+	if idleFor >= policy.IdleTimeout {
+		log.Printf("idle for %s, stopping workspace", idleFor.Round(time.Second))
+		err = a.stop()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		a.markActive(now)
+		return nil
+	}
+	if !a.warned && idleFor >= policy.IdleTimeout-policy.WarnBefore {
+		err = a.broadcast(warningMessage(policy.IdleTimeout - idleFor))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		a.warned = true
+	}
+	return nil
+}
+
+func (a *AutoStopTask) markActive(now time.Time) {
+	a.lastActive = now
+	a.warned = false
+}
+
+func (a AutoStopTask) getPolicy() (Policy, error) {
+	brevHome, err := a.Store.GetBrevHomePath()
+	if err != nil {
+		return Policy{}, breverrors.WrapAndTrace(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(brevHome, OverrideFileName)) //nolint:gosec // path is built from brev home
+	if os.IsNotExist(err) {
+		return DefaultPolicy(), nil
+	}
+	if err != nil {
+		return Policy{}, breverrors.WrapAndTrace(err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return Policy{}, breverrors.WrapAndTrace(err)
+	}
+	return policy, nil
+}
+
+func warningMessage(remaining time.Duration) string {
+	return fmt.Sprintf("brev: this workspace has been idle and will stop in about %s. "+
+		"Any ssh session, editor or cpu activity keeps it running, set 'enabled: false' in ~/.brev/%s to turn auto stop off.",
+		remaining.Round(time.Minute), OverrideFileName)
+}
+
+func getActivity(sshMonitor *analytics.SSHMonitor) (Activity, error) {
+	connections, err := sshMonitor.GetSSHConnections()
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	load, err := getCPULoad()
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	return Activity{
+		SSHConnections:       len(connections),
+		CPULoad:              load,
+		CodeServerLastActive: getCodeServerLastActive(),
+	}, nil
+}
+
+func getCPULoad() (float64, error) {
+	data, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	return ParseLoadAvg(string(data), runtime.NumCPU())
+}
+
+// ParseLoadAvg returns the 1 minute load average per cpu from /proc/loadavg
+func ParseLoadAvg(loadavg string, cpus int) (float64, error) {
+	fields := strings.Fields(loadavg)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected loadavg %q", loadavg)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	if cpus < 1 {
+		cpus = 1
+	}
+	return load / float64(cpus), nil
+}
+
+// getCodeServerLastActive uses the heartbeat file code-server touches while
+// a browser has it open, zero if code-server isn't installed
+func getCodeServerLastActive() time.Time {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(filepath.Join(home, ".local", "share", "code-server", "heartbeat"))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func wall(message string) error {
+	out, err := exec.Command("wall", message).CombinedOutput() //nolint:gosec // message is built by us
+	if err != nil {
+		return breverrors.WrapAndTrace(err, string(out))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package autostop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte("idleTimeout: 4h\nwarnBefore: 15m\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4*time.Hour, p.IdleTimeout)
+	assert.Equal(t, 15*time.Minute, p.WarnBefore)
+	assert.Equal(t, DefaultCPULoadThreshold, p.CPULoadThreshold)
+	assert.False(t, p.Enabled)
+
+	p, err = ParsePolicy([]byte("enabled: true\n"))
+	assert.Nil(t, err)
+	assert.True(t, p.Enabled)
+
+	p, err = ParsePolicy([]byte("idleTimeout: 5m\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, p.WarnBefore)
+
+	_, err = ParsePolicy([]byte("idleTimeout: soon\n"))
+	assert.NotNil(t, err)
+
+	_, err = ParsePolicy([]byte("idle: 1h\n"))
+	assert.NotNil(t, err)
+}
+
+func TestIdleScore(t *testing.T) {
+	p := DefaultPolicy()
+	now := time.Now()
+
+	assert.Equal(t, 1.0, p.IdleScore(Activity{}, now))
+	assert.True(t, p.IsIdle(Activity{}, now))
+	assert.True(t, p.IsIdle(Activity{CPULoad: 0.1}, now))
+	assert.False(t, p.IsIdle(Activity{CPULoad: 2}, now))
+	assert.False(t, p.IsIdle(Activity{SSHConnections: 1}, now))
+	assert.False(t, p.IsIdle(Activity{CodeServerLastActive: now.Add(-time.Minute)}, now))
+	assert.True(t, p.IsIdle(Activity{CodeServerLastActive: now.Add(-time.Hour)}, now))
+
+	// cpu load alone keeps the workspace running whatever the threshold
+	p.IdleThreshold = 0.5
+	assert.False(t, p.IsIdle(Activity{CPULoad: DefaultCPULoadThreshold}, now))
+	assert.True(t, p.IsIdle(Activity{CPULoad: 0.1}, now))
+}
+
+func TestParseLoadAvg(t *testing.T) {
+	load, err := ParseLoadAvg("2.00 1.50 1.00 2/345 6789\n", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, load)
+
+	_, err = ParseLoadAvg("", 4)
+	assert.NotNil(t, err)
+}
+
+type mockAutoStopStore struct {
+	AutoStopStore
+	brevHome string
+}
+
+func (m mockAutoStopStore) GetBrevHomePath() (string, error) {
+	return m.brevHome, nil
+}
+
+func TestAutoStopTaskRun(t *testing.T) {
+	start := time.Now()
+	now := start
+	activity := Activity{}
+	broadcasts := []string{}
+	stops := 0
+	brevHome := t.TempDir()
+	task := &AutoStopTask{
+		Store:       mockAutoStopStore{brevHome: brevHome},
+		getActivity: func() (Activity, error) { return activity, nil },
+		broadcast: func(message string) error {
+			broadcasts = append(broadcasts, message)
+			return nil
+		},
+		stop: func() error {
+			stops++
+			return nil
+		},
+		now:        func() time.Time { return now },
+		lastActive: start,
+	}
+
+	// off until the workspace opts in
+	now = start.Add(2 * DefaultIdleTimeout)
+	assert.Nil(t, task.Run())
+	assert.Empty(t, broadcasts)
+	assert.Equal(t, 0, stops)
+	assert.Nil(t, os.WriteFile(filepath.Join(brevHome, OverrideFileName), []byte("enabled: true\n"), 0o644))
+	start = now
+	task.lastActive = start
+
+	now = start.Add(DefaultIdleTimeout - DefaultWarnBefore - time.Minute)
+	assert.Nil(t, task.Run())
+	assert.Empty(t, broadcasts)
+
+	now = start.Add(DefaultIdleTimeout - DefaultWarnBefore)
+	assert.Nil(t, task.Run())
+	now = now.Add(time.Minute)
+	assert.Nil(t, task.Run())
+	assert.Len(t, broadcasts, 1)
+	assert.Equal(t, 0, stops)
+
+	// activity resets the idle window
+	activity = Activity{SSHConnections: 1}
+	assert.Nil(t, task.Run())
+	activity = Activity{}
+	now = now.Add(DefaultWarnBefore)
+	assert.Nil(t, task.Run())
+	assert.Equal(t, 0, stops)
+
+	now = now.Add(DefaultIdleTimeout)
+	assert.Nil(t, task.Run())
+	assert.Equal(t, 1, stops)
+}
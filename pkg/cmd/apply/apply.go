@@ -0,0 +1,375 @@
+// Package apply creates workspaces from a checked in brev.yaml manifest
+package apply
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/start"
+	"github.com/brevdev/brev-cli/pkg/cmd/templates"
+	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyLong    = "Create a workspace from a manifest, or report how an existing workspace has drifted from it"
+	applyExample = `
+  brev apply -f brev.yaml
+  brev apply -f brev.yaml --dry-run
+  brev apply -f brev.yaml --recreate
+  brev apply -f brev.yaml --recreate --yes
+	`
+)
+
+type ApplyStore interface {
+	templates.TemplatesStore
+	GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error)
+	GetCurrentUser() (*entity.User, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
+	DeleteWorkspace(workspaceID string) (*entity.Workspace, error)
+	CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error)
+	GetSecrets(hierarchyType store.HierarchyType, hierarchyID string) ([]store.Secret, error)
+	UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error)
+	GetSetupScriptContentsByURL(url string) (string, error)
+	GetFileAsString(path string) (string, error)
+	GetApplyState() (string, error)
+	SaveApplyState(state string) error
+	GetApplyStateKey() ([]byte, error)
+}
+
+func NewCmdApply(t *terminal.Terminal, applyStore ApplyStore) *cobra.Command {
+	var file string
+	var dryRun bool
+	var recreate bool
+	var skipConfirm bool
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "apply",
+		DisableFlagsInUseLine: true,
+		Short:                 "Create or check a workspace from a brev.yaml manifest",
+		Long:                  applyLong,
+		Example:               applyExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunApply(t, applyStore, file, dryRun, recreate, skipConfirm)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "brev.yaml", "path to the workspace manifest")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would change without changing anything")
+	cmd.Flags().BoolVar(&recreate, "recreate", false, "delete and recreate a workspace that has drifted from the manifest")
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation before deleting a workspace with --recreate")
+
+	return cmd
+}
+
+type Action string
+
+const (
+	CreateAction    Action = "create"
+	RecreateAction  Action = "recreate"
+	UnchangedAction Action = "unchanged"
+	DriftAction     Action = "drift"
+)
+
+type Plan struct {
+	Action    Action
+	Workspace *entity.Workspace
+	Drift     []Drift
+}
+
+// MakePlan compares the manifest with the user's workspaces of the same name
+func MakePlan(target Target, workspaces []entity.Workspace, state *ApplyState, recreate bool) (*Plan, error) {
+	switch len(workspaces) {
+	case 0:
+		return &Plan{Action: CreateAction}, nil
+	case 1:
+		var applied *AppliedWorkspace
+		if a, ok := state.Workspaces[workspaces[0].ID]; ok {
+			applied = &a
+		}
+		drift := target.GetDrift(workspaces[0], applied)
+		if len(drift) == 0 {
+			return &Plan{Action: UnchangedAction, Workspace: &workspaces[0]}, nil
+		}
+		action := DriftAction
+		if recreate {
+			action = RecreateAction
+		}
+		return &Plan{Action: action, Workspace: &workspaces[0], Drift: drift}, nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces named %s, rename one of them so the manifest can be applied", target.Manifest.Metadata.Name))
+	}
+}
+
+func RunApply(t *terminal.Terminal, applyStore ApplyStore, file string, dryRun bool, recreate bool, skipConfirm bool) error {
+	contents, err := applyStore.GetFileAsString(file)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	manifest, err := ParseManifest([]byte(contents))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	org, err := getOrg(applyStore, manifest.Metadata.Org)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	user, err := applyStore.GetCurrentUser()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspaces, err := applyStore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: manifest.Metadata.Name, UserID: user.ID})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	target, err := getTarget(applyStore, *manifest, org.ID, filepath.Dir(file))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	stateData, err := applyStore.GetApplyState()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	state, err := ParseApplyState(stateData)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	plan, err := MakePlan(*target, workspaces, state, recreate)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	displayPlan(t, *manifest, *plan)
+	if dryRun {
+		return nil
+	}
+
+	switch plan.Action {
+	case DriftAction:
+		return breverrors.NewValidationError(fmt.Sprintf("workspace %s has drifted from %s, rerun with --recreate to replace it", manifest.Metadata.Name, file))
+	case RecreateAction:
+		if !skipConfirm {
+			confirm := terminal.PromptSelectInput(terminal.PromptSelectContent{
+				Label:    fmt.Sprintf("Delete workspace %s (%s) and create it again from %s?", plan.Workspace.Name, plan.Workspace.ID, file),
+				ErrorMsg: "error",
+				Items:    []string{"no", "yes"},
+			})
+			if confirm != "yes" {
+				return nil
+			}
+		}
+		_, err = applyStore.DeleteWorkspace(plan.Workspace.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		delete(state.Workspaces, plan.Workspace.ID)
+		t.Vprintf("deleted workspace %s (%s)\n", plan.Workspace.Name, plan.Workspace.ID)
+	}
+
+	err = applySecrets(t, applyStore, *manifest, org, user, state)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if plan.Action != UnchangedAction {
+		err = createFromManifest(t, applyStore, *target, org, user, state)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+// getTarget looks up the template and setup script the manifest names
+func getTarget(applyStore ApplyStore, manifest Manifest, orgID string, manifestDir string) (*Target, error) {
+	target := Target{Manifest: manifest}
+	if manifest.Spec.Template != "" {
+		templateID, err := templates.GetTemplateID(applyStore, orgID, manifest.Spec.Template)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		target.TemplateID = templateID
+	}
+	setupScript, err := getSetupScript(applyStore, manifest.Spec.SetupScript, manifestDir)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	target.SetupScript = setupScript
+	return &target, nil
+}
+
+// applySecrets creates the manifest secrets, or updates the ones that exist,
+// skipping those last applied from this machine with the same value
+func applySecrets(t *terminal.Terminal, applyStore ApplyStore, manifest Manifest, org *entity.Organization, user *entity.User, state *ApplyState) error {
+	if len(manifest.Spec.Secrets) == 0 {
+		return nil
+	}
+	key, err := applyStore.GetApplyStateKey()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	existing := map[store.HierarchyType][]store.Secret{}
+	for _, s := range manifest.Spec.Secrets {
+		req, err := s.ToCreateSecretRequest(user.ID, org.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		secrets, ok := existing[req.HierarchyType]
+		if !ok {
+			secrets, err = applyStore.GetSecrets(req.HierarchyType, req.HierarchyID)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			existing[req.HierarchyType] = secrets
+		}
+		requestHash, err := hashSecretRequest(key, *req)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+
+		secretID := ""
+		for _, e := range secrets {
+			if e.Name == req.Name {
+				secretID = e.ID
+			}
+		}
+		switch {
+		case secretID != "" && state.Secrets[secretKey(*req)] == requestHash:
+			t.Vprintf("secret %s unchanged\n", s.Name)
+			continue
+		case secretID != "":
+			_, err = applyStore.UpdateSecret(secretID, *req)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			t.Vprintf("secret %s updated\n", s.Name)
+		default:
+			_, err = applyStore.CreateSecret(*req)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			t.Vprintf("secret %s saved\n", s.Name)
+		}
+		state.Secrets[secretKey(*req)] = requestHash
+		err = saveApplyState(applyStore, state)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+func createFromManifest(t *terminal.Terminal, applyStore ApplyStore, target Target, org *entity.Organization, user *entity.User, state *ApplyState) error {
+	clusterID := config.GlobalConfig.GetDefaultClusterID()
+	options := target.Manifest.ToCreateWorkspacesOptions(clusterID, target.TemplateID, target.SetupScript)
+	options = start.ResolveWorkspaceUserOptions(options, user)
+
+	w, err := applyStore.CreateWorkspace(org.ID, options)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	applied := AppliedWorkspace{Applications: applicationIDs(target.Manifest.Spec.Applications)}
+	if target.SetupScript != "" {
+		applied.SetupScript = hash(target.SetupScript)
+	}
+	state.Workspaces[w.ID] = applied
+	err = saveApplyState(applyStore, state)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("\nWorkspace %s is starting.\n", w.Name) + t.Yellow("Run 'brev ls' to check status\n"))
+	return nil
+}
+
+func saveApplyState(applyStore ApplyStore, state *ApplyState) error {
+	data, err := state.String()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = applyStore.SaveApplyState(data)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func getSetupScript(applyStore ApplyStore, setupScript string, manifestDir string) (string, error) {
+	if setupScript == "" {
+		return "", nil
+	}
+	if start.IsUrl(setupScript) {
+		contents, err := applyStore.GetSetupScriptContentsByURL(setupScript)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return contents, nil
+	}
+	path := setupScript
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(manifestDir, path)
+	}
+	contents, err := applyStore.GetFileAsString(path)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return contents, nil
+}
+
+func getOrg(applyStore ApplyStore, orgName string) (*entity.Organization, error) {
+	if orgName == "" {
+		org, err := applyStore.GetActiveOrganizationOrDefault()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if org == nil {
+			return nil, breverrors.NewValidationError("no orgs exist")
+		}
+		return org, nil
+	}
+	orgs, err := applyStore.GetOrganizations(&store.GetOrganizationsOptions{Name: orgName})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(orgs) == 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no org with name %s", orgName))
+	} else if len(orgs) > 1 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("more than one org with name %s", orgName))
+	}
+	return &orgs[0], nil
+}
+
+func displayPlan(t *terminal.Terminal, manifest Manifest, plan Plan) {
+	switch plan.Action {
+	case CreateAction:
+		t.Vprint(t.Green("+ workspace %s will be created", manifest.Metadata.Name))
+	case UnchangedAction:
+		t.Vprintf("workspace %s is up to date (%s)\n", manifest.Metadata.Name, plan.Workspace.Status)
+	case DriftAction, RecreateAction:
+		verb := "has drifted"
+		if plan.Action == RecreateAction {
+			verb = "will be recreated"
+		}
+		t.Vprint(t.Yellow("~ workspace %s %s", manifest.Metadata.Name, verb))
+		for _, d := range plan.Drift {
+			t.Vprintf("\t%s: %s -> %s\n", d.Field, t.Red(d.Actual), t.Green(d.Manifest))
+		}
+	}
+}
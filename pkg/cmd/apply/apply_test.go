@@ -0,0 +1,201 @@
+package apply
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `
+apiVersion: brev.dev/v1
+kind: Workspace
+metadata:
+  name: hello-react
+spec:
+  class: 4x16
+  gitRepo: https://github.com/brevdev/hello-react
+  secrets:
+    - name: DATABASE_URL
+      scope: org
+`
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "hello-react", m.Metadata.Name)
+	assert.Equal(t, "github.com:brevdev/hello-react.git", m.GetGitRepo())
+
+	options := m.ToCreateWorkspacesOptions("cluster", "t1", "")
+	assert.Equal(t, "4x16", options.WorkspaceClassID)
+	assert.Equal(t, "t1", options.WorkspaceTemplateID)
+	assert.Equal(t, "github.com:brevdev/hello-react.git", options.GitRepo)
+}
+
+func TestParseManifestInvalid(t *testing.T) {
+	_, err := ParseManifest([]byte("apiVersion: brev.dev/v1\nkind: Workspace\nmetadata: {}\n"))
+	assert.NotNil(t, err)
+
+	_, err = ParseManifest([]byte("apiVersion: brev.dev/v1\nkind: Workspace\nmetadata:\n  name: a\nspec:\n  clas: 2x8\n"))
+	assert.NotNil(t, err)
+
+	// the api has no branch field
+	_, err = ParseManifest([]byte("apiVersion: brev.dev/v1\nkind: Workspace\nmetadata:\n  name: a\nspec:\n  branch: main\n"))
+	assert.NotNil(t, err)
+}
+
+func TestSecretToCreateSecretRequest(t *testing.T) {
+	t.Setenv("APPLY_TEST_SECRET", "shh")
+	req, err := SecretSpec{Name: "DB", FromEnv: "APPLY_TEST_SECRET", Scope: "org"}.ToCreateSecretRequest("u1", "o1")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "o1", req.HierarchyID)
+	assert.Equal(t, "shh", req.Src.Config.Value)
+	assert.Equal(t, "DB", req.Dest.Config.Name)
+
+	_, err = SecretSpec{Name: "APPLY_TEST_MISSING"}.ToCreateSecretRequest("u1", "o1")
+	assert.NotNil(t, err)
+}
+
+func TestMakePlan(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+	target := Target{Manifest: *m}
+	state, err := ParseApplyState("")
+	assert.Nil(t, err)
+
+	plan, err := MakePlan(target, nil, state, false)
+	assert.Nil(t, err)
+	assert.Equal(t, CreateAction, plan.Action)
+
+	ws := entity.Workspace{ID: "1", WorkspaceClassID: "4x16", GitRepo: "github.com:brevdev/hello-react.git"}
+	plan, err = MakePlan(target, []entity.Workspace{ws}, state, false)
+	assert.Nil(t, err)
+	assert.Equal(t, UnchangedAction, plan.Action)
+
+	ws.WorkspaceClassID = "2x8"
+	plan, err = MakePlan(target, []entity.Workspace{ws}, state, false)
+	assert.Nil(t, err)
+	assert.Equal(t, DriftAction, plan.Action)
+	assert.Equal(t, []Drift{{Field: "class", Manifest: "4x16", Actual: "2x8"}}, plan.Drift)
+
+	plan, err = MakePlan(target, []entity.Workspace{ws}, state, true)
+	assert.Nil(t, err)
+	assert.Equal(t, RecreateAction, plan.Action)
+
+	_, err = MakePlan(target, []entity.Workspace{ws, ws}, state, false)
+	assert.NotNil(t, err)
+}
+
+func TestGetDriftCoversEveryField(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+	m.Spec.Template = "ubuntu"
+	m.Spec.SetupScript = "setup.sh"
+	m.Spec.Applications = []entity.Application{{ID: "a1"}}
+	target := Target{Manifest: *m, TemplateID: "t1", SetupScript: "echo hi"}
+	ws := entity.Workspace{
+		ID:                "1",
+		WorkspaceClassID:  "4x16",
+		GitRepo:           "github.com:brevdev/hello-react.git",
+		WorkspaceTemplate: entity.WorkspaceTemplate{ID: "t1", Name: "ubuntu"},
+	}
+
+	drift := target.GetDrift(ws, nil)
+	assert.Equal(t, []Drift{
+		{Field: "setupScript", Manifest: "setup.sh", Actual: notApplied},
+		{Field: "applications", Manifest: "a1", Actual: notApplied},
+	}, drift)
+
+	applied := &AppliedWorkspace{SetupScript: hash("echo hi"), Applications: []string{"a1"}}
+	assert.Empty(t, target.GetDrift(ws, applied))
+
+	ws.WorkspaceTemplate = entity.WorkspaceTemplate{ID: "t2", Name: "cuda"}
+	applied.SetupScript = hash("echo bye")
+	drift = target.GetDrift(ws, applied)
+	assert.Equal(t, []string{"template", "setupScript"}, []string{drift[0].Field, drift[1].Field})
+}
+
+type mockSecretsStore struct {
+	ApplyStore
+	secrets []store.Secret
+	created []string
+	updated []string
+	state   string
+}
+
+func (m *mockSecretsStore) GetSecrets(_ store.HierarchyType, _ string) ([]store.Secret, error) {
+	return m.secrets, nil
+}
+
+func (m *mockSecretsStore) CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error) {
+	m.created = append(m.created, req.Name)
+	m.secrets = append(m.secrets, store.Secret{ID: "s" + req.Name, CreateSecretRequest: req})
+	return &req, nil
+}
+
+func (m *mockSecretsStore) UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error) {
+	m.updated = append(m.updated, req.Name)
+	return &store.Secret{ID: secretID, CreateSecretRequest: req}, nil
+}
+
+func (m *mockSecretsStore) SaveApplyState(state string) error {
+	m.state = state
+	return nil
+}
+
+func (m *mockSecretsStore) GetApplyStateKey() ([]byte, error) {
+	return []byte("machine key"), nil
+}
+
+func TestHashSecretRequest(t *testing.T) {
+	req := store.CreateSecretRequest{Name: "TOKEN", Src: store.SecretReqSrc{Config: store.SrcConfig{Value: "1234"}}}
+	data, err := json.Marshal(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	mine, err := hashSecretRequest([]byte("mine"), req)
+	assert.Nil(t, err)
+	theirs, err := hashSecretRequest([]byte("theirs"), req)
+	assert.Nil(t, err)
+	// without the key the value can not be checked against guesses
+	assert.NotEqual(t, mine, theirs)
+	assert.NotEqual(t, hash(string(data)), mine)
+}
+
+func TestApplySecretsSkipsUnchanged(t *testing.T) {
+	t.Setenv("APPLY_TEST_A", "1")
+	t.Setenv("APPLY_TEST_B", "2")
+	m := Manifest{Spec: WorkspaceSpec{Secrets: []SecretSpec{{Name: "APPLY_TEST_A"}, {Name: "APPLY_TEST_B"}}}}
+	s := &mockSecretsStore{secrets: []store.Secret{{ID: "sb", CreateSecretRequest: store.CreateSecretRequest{Name: "APPLY_TEST_B"}}}}
+	state, err := ParseApplyState("")
+	assert.Nil(t, err)
+	org, user := &entity.Organization{ID: "o1"}, &entity.User{ID: "u1"}
+
+	err = applySecrets(terminal.New(), s, m, org, user, state)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"APPLY_TEST_A"}, s.created)
+	assert.Equal(t, []string{"APPLY_TEST_B"}, s.updated)
+
+	state, err = ParseApplyState(s.state)
+	assert.Nil(t, err)
+	err = applySecrets(terminal.New(), s, m, org, user, state)
+	assert.Nil(t, err)
+	assert.Len(t, s.created, 1)
+	assert.Len(t, s.updated, 1)
+
+	t.Setenv("APPLY_TEST_B", "3")
+	err = applySecrets(terminal.New(), s, m, org, user, state)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"APPLY_TEST_B", "APPLY_TEST_B"}, s.updated)
+}
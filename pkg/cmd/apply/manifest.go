@@ -0,0 +1,236 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/start"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+const (
+	ManifestAPIVersion = "brev.dev/v1"
+	ManifestKind       = "Workspace"
+)
+
+// Manifest is the checked in definition of a workspace, ex brev.yaml
+//
+//	apiVersion: brev.dev/v1
+//	kind: Workspace
+//	metadata:
+//	  name: my-project
+//	spec:
+//	  class: 4x16
+//	  template: ubuntu
+//	  gitRepo: https://github.com/brevdev/hello-react
+//	  setupScript: .brev/setup.sh
+//	  secrets:
+//	    - name: DATABASE_URL
+type Manifest struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Metadata   ManifestMetadata `json:"metadata"`
+	Spec       WorkspaceSpec    `json:"spec"`
+}
+
+type ManifestMetadata struct {
+	Name string `json:"name"`
+	// Org is the org name, defaults to the active org
+	Org string `json:"org,omitempty"`
+}
+
+type WorkspaceSpec struct {
+	Class string `json:"class,omitempty"`
+	// Template is a template name or id, see 'brev templates ls'
+	Template     string               `json:"template,omitempty"`
+	GitRepo      string               `json:"gitRepo,omitempty"`
+	SetupScript  string               `json:"setupScript,omitempty"`
+	Applications []entity.Application `json:"applications,omitempty"`
+	Secrets      []SecretSpec         `json:"secrets,omitempty"`
+}
+
+// SecretSpec never holds a value so manifests are safe to commit, the value
+// is read from the local environment at apply time
+type SecretSpec struct {
+	Name string `json:"name"`
+	// FromEnv is the local env var holding the value, defaults to Name
+	FromEnv string `json:"fromEnv,omitempty"`
+	// Scope is user or org, defaults to user
+	Scope string `json:"scope,omitempty"`
+	// Path makes this a file secret instead of an env var
+	Path string `json:"path,omitempty"`
+}
+
+func ParseManifest(b []byte) (*Manifest, error) {
+	var m Manifest
+	err := yaml.UnmarshalStrict(b, &m)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid manifest: %v", err))
+	}
+	err = m.Validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &m, nil
+}
+
+func (m Manifest) Validate() error {
+	if m.APIVersion != ManifestAPIVersion {
+		return breverrors.NewValidationError(fmt.Sprintf("unsupported apiVersion %q, expected %s", m.APIVersion, ManifestAPIVersion))
+	}
+	if m.Kind != ManifestKind {
+		return breverrors.NewValidationError(fmt.Sprintf("unsupported kind %q, expected %s", m.Kind, ManifestKind))
+	}
+	if m.Metadata.Name == "" {
+		return breverrors.NewValidationError("metadata.name is required")
+	}
+	for _, s := range m.Spec.Secrets {
+		if s.Name == "" {
+			return breverrors.NewValidationError("spec.secrets[].name is required")
+		}
+		if s.Scope != "" && s.Scope != string(store.User) && s.Scope != string(store.Org) {
+			return breverrors.NewValidationError(fmt.Sprintf("secret %s has invalid scope %q, must be user or org", s.Name, s.Scope))
+		}
+	}
+	return nil
+}
+
+// GetGitRepo normalizes urls the same way brev start does so that the repo
+// can be compared against existing workspaces
+func (m Manifest) GetGitRepo() string {
+	repo := m.Spec.GitRepo
+	if strings.Contains(repo, "https://") || strings.Contains(repo, "http://") || strings.Contains(repo, "git@") {
+		return start.MakeNewWorkspaceFromURL(repo).GitRepo
+	}
+	return repo
+}
+
+// ToCreateWorkspacesOptions maps the manifest onto the create request, the
+// template must already be resolved to its id and setupScript to its contents
+func (m Manifest) ToCreateWorkspacesOptions(clusterID string, templateID string, setupScript string) *store.CreateWorkspacesOptions {
+	options := store.NewCreateWorkspacesOptions(clusterID, m.Metadata.Name)
+	if m.Spec.GitRepo != "" {
+		options = options.WithGitRepo(m.GetGitRepo())
+	}
+	if m.Spec.Class != "" {
+		options = options.WithWorkspaceClassID(m.Spec.Class)
+	}
+	if templateID != "" {
+		options = options.WithWorkspaceTemplateID(templateID)
+	}
+	if len(m.Spec.Applications) > 0 {
+		options.Applications = m.Spec.Applications
+		options.PrimaryApplicationID = m.Spec.Applications[0].ID
+	}
+	if setupScript != "" {
+		options = options.WithStartupScript(setupScript)
+	}
+	return options
+}
+
+// ToCreateSecretRequest reads the secret value from the environment
+func (s SecretSpec) ToCreateSecretRequest(userID string, orgID string) (*store.CreateSecretRequest, error) {
+	envName := s.FromEnv
+	if envName == "" {
+		envName = s.Name
+	}
+	value, ok := os.LookupEnv(envName)
+	if !ok {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("secret %s: env var %s is not set", s.Name, envName))
+	}
+
+	hierarchyType := store.User
+	hierarchyID := userID
+	if s.Scope == string(store.Org) {
+		hierarchyType = store.Org
+		hierarchyID = orgID
+	}
+
+	dest := store.SecretReqDest{
+		Type:   store.EnvVariable,
+		Config: store.DestConfig{Name: s.Name},
+	}
+	if s.Path != "" {
+		dest = store.SecretReqDest{
+			Type:   store.File,
+			Config: store.DestConfig{Path: s.Path},
+		}
+	}
+
+	return &store.CreateSecretRequest{
+		Name:          s.Name,
+		HierarchyType: hierarchyType,
+		HierarchyID:   hierarchyID,
+		Src: store.SecretReqSrc{
+			Type:   store.KeyValue,
+			Config: store.SrcConfig{Value: value},
+		},
+		Dest: dest,
+	}, nil
+}
+
+type Drift struct {
+	Field    string
+	Manifest string
+	Actual   string
+}
+
+// notApplied is the actual value of the fields the api does not return for a
+// workspace brev apply did not create from this machine
+const notApplied = "unknown, not applied from this machine"
+
+// Target is the manifest with the template and setup script it names looked up
+type Target struct {
+	Manifest    Manifest
+	TemplateID  string
+	SetupScript string
+}
+
+// GetDrift returns the manifest fields which do not match the workspace,
+// fields left empty in the manifest are not compared. The setup script and
+// applications are not returned by the api, they are compared with what was
+// last applied from this machine.
+func (target Target) GetDrift(workspace entity.Workspace, applied *AppliedWorkspace) []Drift {
+	m := target.Manifest
+	drift := []Drift{}
+	if m.Spec.Class != "" && m.Spec.Class != workspace.WorkspaceClassID {
+		drift = append(drift, Drift{Field: "class", Manifest: m.Spec.Class, Actual: workspace.WorkspaceClassID})
+	}
+	if target.TemplateID != "" && target.TemplateID != workspace.WorkspaceTemplate.ID {
+		drift = append(drift, Drift{Field: "template", Manifest: m.Spec.Template, Actual: workspace.WorkspaceTemplate.Name})
+	}
+	if m.Spec.GitRepo != "" && m.GetGitRepo() != workspace.GitRepo {
+		drift = append(drift, Drift{Field: "gitRepo", Manifest: m.GetGitRepo(), Actual: workspace.GitRepo})
+	}
+	if m.Spec.SetupScript != "" {
+		switch {
+		case applied == nil:
+			drift = append(drift, Drift{Field: "setupScript", Manifest: m.Spec.SetupScript, Actual: notApplied})
+		case applied.SetupScript != hash(target.SetupScript):
+			drift = append(drift, Drift{Field: "setupScript", Manifest: m.Spec.SetupScript, Actual: "a different script"})
+		}
+	}
+	if len(m.Spec.Applications) > 0 {
+		ids := applicationIDs(m.Spec.Applications)
+		switch {
+		case applied == nil:
+			drift = append(drift, Drift{Field: "applications", Manifest: strings.Join(ids, ","), Actual: notApplied})
+		case strings.Join(ids, ",") != strings.Join(applied.Applications, ","):
+			drift = append(drift, Drift{Field: "applications", Manifest: strings.Join(ids, ","), Actual: strings.Join(applied.Applications, ",")})
+		}
+	}
+	return drift
+}
+
+func applicationIDs(applications []entity.Application) []string {
+	ids := []string{}
+	for _, a := range applications {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,73 @@
+package apply
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+// ApplyState is what brev apply last applied from this machine, kept in
+// ~/.brev/apply_state.json for the parts the api does not return
+type ApplyState struct {
+	// Workspaces is keyed by workspace id
+	Workspaces map[string]AppliedWorkspace `json:"workspaces"`
+	// Secrets is keyed by secretKey, the values are hmacs of the requests
+	// with the key of this machine so unchanged secrets are not sent again
+	// and the file does not give away their values
+	Secrets map[string]string `json:"secrets"`
+}
+
+type AppliedWorkspace struct {
+	// SetupScript is a hash of the script contents
+	SetupScript  string   `json:"setupScript,omitempty"`
+	Applications []string `json:"applications,omitempty"`
+}
+
+func ParseApplyState(data string) (*ApplyState, error) {
+	state := ApplyState{}
+	if data != "" {
+		err := json.Unmarshal([]byte(data), &state)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err, "~/.brev/apply_state.json")
+		}
+	}
+	if state.Workspaces == nil {
+		state.Workspaces = map[string]AppliedWorkspace{}
+	}
+	if state.Secrets == nil {
+		state.Secrets = map[string]string{}
+	}
+	return &state, nil
+}
+
+func (s ApplyState) String() (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+func secretKey(req store.CreateSecretRequest) string {
+	return fmt.Sprintf("%s/%s/%s", req.HierarchyType, req.HierarchyID, req.Name)
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashSecretRequest(key []byte, req store.CreateSecretRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
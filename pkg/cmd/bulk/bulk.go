@@ -0,0 +1,245 @@
+// Package bulk runs lifecycle commands against many workspaces at once
+package bulk
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+const DefaultParallelism = 4
+
+type Options struct {
+	All         bool
+	Selector    string
+	Parallelism int
+	// ConfirmFuzzy is set by destructive commands, see
+	// resolver.WithConfirmFuzzy
+	ConfirmFuzzy bool
+}
+
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().BoolVar(&opts.All, "all", false, "act on all of your workspaces in the active org")
+	cmd.Flags().StringVarP(&opts.Selector, "selector", "l", "", "only act on workspaces matching key=value pairs, ex: status=RUNNING,class=4x16 (keys: status, class, creator, repo)")
+	cmd.Flags().IntVar(&opts.Parallelism, "parallel", DefaultParallelism, "number of workspaces to act on at the same time")
+}
+
+// Confirm lists the workspaces a pattern matched and asks before acting on
+// them, action is the prompt verb and result the listing one, ex: Delete and
+// deleted
+func Confirm(action string, result string, t *terminal.Terminal, workspaces []entity.Workspace) bool {
+	t.Vprint(t.Yellow(fmt.Sprintf("The following workspaces will be %s:", result)))
+	for _, w := range workspaces {
+		t.Vprintf("\t%s (%s)\n", w.Name, w.ID)
+	}
+	confirm := terminal.PromptSelectInput(terminal.PromptSelectContent{
+		Label:    fmt.Sprintf("%s %d workspaces?", action, len(workspaces)),
+		ErrorMsg: "error",
+		Items:    []string{"no", "yes"},
+	})
+	return confirm == "yes"
+}
+
+// IsBulk is true when the command may act on more than one workspace
+func (o Options) IsBulk(args []string) bool {
+	return len(args) > 1 || o.IsPattern(args)
+}
+
+// IsPattern is true when workspaces are picked by a pattern rather than by name
+func (o Options) IsPattern(args []string) bool {
+	if o.All || o.Selector != "" {
+		return true
+	}
+	for _, a := range args {
+		if IsGlob(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Options) Validate(args []string) error {
+	if len(args) == 0 && !o.All && o.Selector == "" {
+		return breverrors.NewValidationError("provide a workspace name, --all or --selector")
+	}
+	if o.All && len(args) > 0 {
+		return breverrors.NewValidationError("--all can not be used with workspace names")
+	}
+	if o.Parallelism < 1 {
+		return breverrors.NewValidationError("--parallel must be at least 1")
+	}
+	return nil
+}
+
+func IsGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// GetWorkspaces turns args, --all and --selector into a list of workspaces.
+// Names go through the shared resolver, globs match names and ssh aliases of
+// the user's workspaces, and the selector filters whatever is left.
+func GetWorkspaces(t *terminal.Terminal, bulkStore resolver.ResolverStore, args []string, opts Options) ([]entity.Workspace, error) {
+	selector, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	org, err := bulkStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return nil, breverrors.NewValidationError("no orgs exist")
+	}
+	user, err := bulkStore.GetCurrentUser()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	// only look past the user's own workspaces when explicitly selecting by creator
+	listOptions := &store.GetWorkspacesOptions{UserID: user.ID}
+	if selector.HasKey(CreatorKey) {
+		listOptions = nil
+	}
+	candidates, err := bulkStore.GetWorkspaces(org.ID, listOptions)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	var workspaces []entity.Workspace
+	if len(args) == 0 {
+		workspaces = candidates
+	} else {
+		workspaceResolver := resolver.NewWorkspaceResolver(t, bulkStore).WithConfirmFuzzy(opts.ConfirmFuzzy)
+		for _, a := range args {
+			if IsGlob(a) {
+				matches, err := MatchGlob(a, candidates)
+				if err != nil {
+					return nil, breverrors.WrapAndTrace(err)
+				}
+				workspaces = append(workspaces, matches...)
+				continue
+			}
+			w, err := workspaceResolver.GetWorkspace(a)
+			if err != nil {
+				return nil, breverrors.WrapAndTrace(err)
+			}
+			workspaces = append(workspaces, *w)
+		}
+	}
+
+	workspaces = selector.Filter(dedupe(workspaces), user.ID)
+	if len(workspaces) == 0 {
+		return nil, breverrors.NewValidationError("no workspaces matched")
+	}
+	return workspaces, nil
+}
+
+func MatchGlob(pattern string, workspaces []entity.Workspace) ([]entity.Workspace, error) {
+	matches := []entity.Workspace{}
+	for _, w := range workspaces {
+		nameMatch, err := path.Match(pattern, w.Name)
+		if err != nil {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid pattern %s: %v", pattern, err))
+		}
+		aliasMatch, _ := path.Match(pattern, string(w.GetLocalIdentifier()))
+		if nameMatch || aliasMatch {
+			matches = append(matches, w)
+		}
+	}
+	return matches, nil
+}
+
+func dedupe(workspaces []entity.Workspace) []entity.Workspace {
+	seen := map[string]bool{}
+	out := []entity.Workspace{}
+	for _, w := range workspaces {
+		if seen[w.ID] {
+			continue
+		}
+		seen[w.ID] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// Operation acts on one workspace and returns a short description of what it did
+type Operation func(workspace entity.Workspace) (string, error)
+
+type Result struct {
+	Workspace entity.Workspace
+	Message   string
+	Err       error
+}
+
+// Run calls op for every workspace with at most parallelism in flight,
+// results are returned in the same order as workspaces
+func Run(workspaces []entity.Workspace, parallelism int, op Operation) []Result {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make([]Result, len(workspaces))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, w := range workspaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w entity.Workspace) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			message, err := op(w)
+			results[i] = Result{Workspace: w, Message: message, Err: err}
+		}(i, w)
+	}
+	wg.Wait()
+	return results
+}
+
+// RunAndSummarize runs op, prints a result per workspace and returns an error
+// if any of the operations failed
+func RunAndSummarize(t *terminal.Terminal, workspaces []entity.Workspace, parallelism int, op Operation) error {
+	results := Run(workspaces, parallelism, op)
+	DisplayResults(t, results)
+
+	var allErr error
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			allErr = multierror.Append(allErr, fmt.Errorf("%s: %w", r.Workspace.Name, r.Err))
+		}
+	}
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr, fmt.Sprintf("%d of %d workspaces failed", failed, len(results)))
+	}
+	return nil
+}
+
+func DisplayResults(t *terminal.Terminal, results []Result) {
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"NAME", "ID", "RESULT"})
+	for _, r := range results {
+		result := t.Green("%s", r.Message)
+		if r.Err != nil {
+			result = t.Red("failed: %s", errors.Cause(r.Err).Error())
+		}
+		ta.AppendRow(table.Row{r.Workspace.Name, r.Workspace.ID, result})
+	}
+	fmt.Print("\n")
+	ta.Render()
+}
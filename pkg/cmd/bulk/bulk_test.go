@@ -0,0 +1,121 @@
+package bulk
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWorkspaces = []entity.Workspace{
+	{ID: "abcd12345", Name: "api-1", Status: "RUNNING", WorkspaceClassID: "4x16", CreatedByUserID: "u1", GitRepo: "github.com:brevdev/api.git"},
+	{ID: "efgh67890", Name: "api-2", Status: "STOPPED", WorkspaceClassID: "2x8", CreatedByUserID: "u1", GitRepo: "github.com:brevdev/api.git"},
+	{ID: "ijkl11111", Name: "web", Status: "RUNNING", WorkspaceClassID: "4x16", CreatedByUserID: "u2", GitRepo: "github.com:brevdev/web.git"},
+}
+
+func names(workspaces []entity.Workspace) []string {
+	out := []string{}
+	for _, w := range workspaces {
+		out = append(out, w.Name)
+	}
+	return out
+}
+
+func TestParseSelector(t *testing.T) {
+	s, err := ParseSelector("status=RUNNING, class!=2x8")
+	assert.Nil(t, err)
+	assert.Equal(t, Selector{
+		{Key: StatusKey, Value: "RUNNING"},
+		{Key: ClassKey, Value: "2x8", Negate: true},
+	}, s)
+
+	s, err = ParseSelector("")
+	assert.Nil(t, err)
+	assert.Empty(t, s)
+
+	_, err = ParseSelector("status")
+	assert.NotNil(t, err)
+
+	_, err = ParseSelector("color=blue")
+	assert.NotNil(t, err)
+}
+
+func TestSelectorFilter(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{"status=running", []string{"api-1", "web"}},
+		{"class=4x16,creator=me", []string{"api-1"}},
+		{"creator!=me", []string{"web"}},
+		{"repo=brevdev/api", []string{"api-1", "api-2"}},
+		{"class=*x16", []string{"api-1", "web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := ParseSelector(tt.selector)
+			if !assert.Nil(t, err) {
+				return
+			}
+			assert.Equal(t, tt.expected, names(s.Filter(testWorkspaces, "u1")))
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	matches, err := MatchGlob("api-*", testWorkspaces)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api-1", "api-2"}, names(matches))
+
+	matches, err = MatchGlob("web-1111", testWorkspaces)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"web"}, names(matches))
+
+	_, err = MatchGlob("[", testWorkspaces)
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NotNil(t, Options{Parallelism: 1}.Validate(nil))
+	assert.NotNil(t, Options{All: true, Parallelism: 1}.Validate([]string{"a"}))
+	assert.NotNil(t, Options{All: true}.Validate(nil))
+	assert.Nil(t, Options{Selector: "status=RUNNING", Parallelism: 1}.Validate([]string{"api-*"}))
+
+	assert.False(t, Options{}.IsBulk([]string{"a"}))
+	assert.True(t, Options{}.IsBulk([]string{"a", "b"}))
+	assert.True(t, Options{}.IsBulk([]string{"a*"}))
+	assert.False(t, Options{}.IsPattern([]string{"a", "b"}))
+}
+
+func TestRun(t *testing.T) {
+	var inFlight, maxInFlight int32
+	results := Run(testWorkspaces, 2, func(w entity.Workspace) (string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		if w.Status != "RUNNING" {
+			return "", fmt.Errorf("not running")
+		}
+		return "ok", nil
+	})
+
+	assert.LessOrEqual(t, maxInFlight, int32(2))
+	if !assert.Len(t, results, 3) {
+		return
+	}
+	for i, r := range results {
+		assert.Equal(t, testWorkspaces[i].ID, r.Workspace.ID)
+	}
+	assert.Nil(t, results[0].Err)
+	assert.NotNil(t, results[1].Err)
+	assert.Equal(t, "ok", results[2].Message)
+}
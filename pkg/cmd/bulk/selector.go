@@ -0,0 +1,117 @@
+package bulk
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	StatusKey  = "status"
+	ClassKey   = "class"
+	CreatorKey = "creator"
+	RepoKey    = "repo"
+)
+
+var selectorKeys = []string{StatusKey, ClassKey, CreatorKey, RepoKey}
+
+type Requirement struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// Selector is a comma separated list of key=value or key!=value requirements,
+// values may be glob patterns and creator=me matches the current user
+type Selector []Requirement
+
+func ParseSelector(s string) (Selector, error) {
+	selector := Selector{}
+	if strings.TrimSpace(s) == "" {
+		return selector, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		negate := false
+		kv := strings.SplitN(part, "!=", 2)
+		if len(kv) == 2 {
+			negate = true
+		} else {
+			kv = strings.SplitN(part, "=", 2)
+		}
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid selector %q, expected key=value", part))
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if !isSelectorKey(key) {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid selector key %q, must be one of %s", key, strings.Join(selectorKeys, ", ")))
+		}
+		selector = append(selector, Requirement{Key: key, Value: strings.TrimSpace(kv[1]), Negate: negate})
+	}
+	return selector, nil
+}
+
+func isSelectorKey(key string) bool {
+	for _, k := range selectorKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Selector) HasKey(key string) bool {
+	for _, r := range s {
+		if r.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Selector) Matches(w entity.Workspace, currentUserID string) bool {
+	for _, r := range s {
+		if r.matches(w, currentUserID) == r.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) Filter(workspaces []entity.Workspace, currentUserID string) []entity.Workspace {
+	out := []entity.Workspace{}
+	for _, w := range workspaces {
+		if s.Matches(w, currentUserID) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func (r Requirement) matches(w entity.Workspace, currentUserID string) bool {
+	switch r.Key {
+	case StatusKey:
+		return matchValue(strings.ToUpper(r.Value), w.Status)
+	case ClassKey:
+		return matchValue(r.Value, w.WorkspaceClassID)
+	case CreatorKey:
+		if r.Value == "me" {
+			return w.CreatedByUserID == currentUserID
+		}
+		return matchValue(r.Value, w.CreatedByUserID)
+	case RepoKey:
+		return matchValue(r.Value, w.GitRepo) || strings.Contains(w.GitRepo, r.Value)
+	}
+	return false
+}
+
+func matchValue(pattern string, value string) bool {
+	if IsGlob(pattern) {
+		match, _ := path.Match(pattern, value)
+		return match
+	}
+	return pattern == value
+}
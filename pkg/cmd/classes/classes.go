@@ -0,0 +1,168 @@
+// Package classes lists the workspace resource classes available to an org
+package classes
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	classesLong = `Workspace classes are the cpu and memory a workspace runs with. Pass a class
+to 'brev start --class' when creating a workspace or change it later with 'brev resize'.`
+	classesExample = `
+  brev classes
+  brev classes --output json
+  brev start <git url> --class 4x16
+  brev resize <ws_name> --class 8x32
+	`
+)
+
+type ClassesStore interface {
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetWorkspaceClasses(organizationID string) ([]entity.WorkspaceClass, error)
+}
+
+func NewCmdClasses(t *terminal.Terminal, classesStore ClassesStore) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "classes",
+		DisableFlagsInUseLine: true,
+		Short:                 "List available workspace classes",
+		Long:                  classesLong,
+		Example:               classesExample,
+		Args:                  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunList(t, classesStore, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmdoutput.AddOutputFlag(cmd, &output)
+
+	return cmd
+}
+
+// GetClasses returns the classes of the active org, smallest first
+func GetClasses(classesStore ClassesStore) ([]entity.WorkspaceClass, error) {
+	org, err := classesStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return nil, breverrors.NewValidationError("no orgs exist")
+	}
+	classes, err := getOrgClasses(classesStore, org.ID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return classes, nil
+}
+
+func getOrgClasses(classesStore ClassesStore, organizationID string) ([]entity.WorkspaceClass, error) {
+	classes, err := classesStore.GetWorkspaceClasses(organizationID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	sort.SliceStable(classes, func(i, j int) bool {
+		if classes[i].CPU != classes[j].CPU {
+			return classes[i].CPU < classes[j].CPU
+		}
+		return classes[i].MemoryGB < classes[j].MemoryGB
+	})
+	return classes, nil
+}
+
+func RunList(t *terminal.Terminal, classesStore ClassesStore, format cmdoutput.Format) error {
+	classes, err := GetClasses(classesStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, format, "WorkspaceClassList", classes)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(classes) == 0 {
+		t.Vprint(t.Yellow("No workspace classes available"))
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"CLASS", "CPU", "MEMORY", "GPU", "PRICE"})
+	for _, c := range classes {
+		gpu := c.GPU
+		if gpu == "" {
+			gpu = "-"
+		}
+		ta.AppendRow(table.Row{c.ID, c.CPU, fmt.Sprintf("%dGB", c.MemoryGB), gpu, fmt.Sprintf("$%.2f/hr", c.PricePerHour)})
+	}
+	ta.Render()
+	return nil
+}
+
+// ValidateClass returns a validation error listing the available classes
+// when classID is not one of them
+func ValidateClass(classes []entity.WorkspaceClass, classID string) error {
+	ids := []string{}
+	for _, c := range classes {
+		if c.ID == classID {
+			return nil
+		}
+		ids = append(ids, c.ID)
+	}
+	return breverrors.NewValidationError(fmt.Sprintf("invalid workspace class %s, available classes: %s", classID, strings.Join(ids, ", ")))
+}
+
+// CheckClass validates classID against the classes of an org, an empty
+// classID means the default and is always valid
+func CheckClass(classesStore ClassesStore, organizationID string, classID string) error {
+	if classID == "" {
+		return nil
+	}
+	classes, err := getOrgClasses(classesStore, organizationID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = ValidateClass(classes, classID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func GetClassCompletionHandler(classesStore ClassesStore) completions.CompletionHandler {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		classes, err := GetClasses(classesStore)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ids := []string{}
+		for _, c := range classes {
+			ids = append(ids, c.ID)
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,45 @@
+package classes
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClassesStore struct{}
+
+func (m mockClassesStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o1"}, nil
+}
+
+func (m mockClassesStore) GetWorkspaceClasses(_ string) ([]entity.WorkspaceClass, error) {
+	return []entity.WorkspaceClass{
+		{ID: "16x32", CPU: 16, MemoryGB: 32},
+		{ID: "4x16", CPU: 4, MemoryGB: 16},
+		{ID: "2x8", CPU: 2, MemoryGB: 8},
+		{ID: "4x8", CPU: 4, MemoryGB: 8},
+	}, nil
+}
+
+func TestGetClassesSorted(t *testing.T) {
+	classes, err := GetClasses(mockClassesStore{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	ids := []string{}
+	for _, c := range classes {
+		ids = append(ids, c.ID)
+	}
+	assert.Equal(t, []string{"2x8", "4x8", "4x16", "16x32"}, ids)
+}
+
+func TestCheckClass(t *testing.T) {
+	assert.Nil(t, CheckClass(mockClassesStore{}, "o1", ""))
+	assert.Nil(t, CheckClass(mockClassesStore{}, "o1", "4x16"))
+
+	err := CheckClass(mockClassesStore{}, "o1", "8x32")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "available classes: 2x8, 4x8, 4x16, 16x32")
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 
 	"github.com/brevdev/brev-cli/pkg/auth"
+	"github.com/brevdev/brev-cli/pkg/cmd/apply"
 	"github.com/brevdev/brev-cli/pkg/cmd/approve"
+	"github.com/brevdev/brev-cli/pkg/cmd/classes"
 	"github.com/brevdev/brev-cli/pkg/cmd/clipboard"
 	"github.com/brevdev/brev-cli/pkg/cmd/delete"
+	"github.com/brevdev/brev-cli/pkg/cmd/forward"
 	"github.com/brevdev/brev-cli/pkg/cmd/healthcheck"
 	"github.com/brevdev/brev-cli/pkg/cmd/initfile"
 	"github.com/brevdev/brev-cli/pkg/cmd/invite"
@@ -22,18 +25,25 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/proxy"
 	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
 	"github.com/brevdev/brev-cli/pkg/cmd/reset"
+	"github.com/brevdev/brev-cli/pkg/cmd/resize"
 	"github.com/brevdev/brev-cli/pkg/cmd/runtasks"
+	"github.com/brevdev/brev-cli/pkg/cmd/schedule"
 	"github.com/brevdev/brev-cli/pkg/cmd/secret"
 	"github.com/brevdev/brev-cli/pkg/cmd/set"
 	"github.com/brevdev/brev-cli/pkg/cmd/setupworkspace"
 	"github.com/brevdev/brev-cli/pkg/cmd/shell"
+	"github.com/brevdev/brev-cli/pkg/cmd/snapshot"
+	"github.com/brevdev/brev-cli/pkg/cmd/sshall"
+	"github.com/brevdev/brev-cli/pkg/cmd/sshconfig"
 	"github.com/brevdev/brev-cli/pkg/cmd/sshkeys"
 	"github.com/brevdev/brev-cli/pkg/cmd/sshmon"
 	"github.com/brevdev/brev-cli/pkg/cmd/start"
 	"github.com/brevdev/brev-cli/pkg/cmd/stop"
 	"github.com/brevdev/brev-cli/pkg/cmd/tasks"
+	"github.com/brevdev/brev-cli/pkg/cmd/templates"
 	"github.com/brevdev/brev-cli/pkg/cmd/test"
 	"github.com/brevdev/brev-cli/pkg/cmd/up"
+	"github.com/brevdev/brev-cli/pkg/cmd/wait"
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/files"
@@ -123,7 +133,7 @@ func NewBrevCommand() *cobra.Command { //nolint:funlen // define brev command
 					t.Errprint(err, "Failed to determine version")
 					return breverrors.WrapAndTrace(err)
 				}
-				t.Vprint(v)
+				t.Eprint(v)
 			}
 			if user != "" {
 				_, err := noLoginCmdStore.WithUserID(user)
@@ -200,6 +210,7 @@ func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *stor
 	cmd.AddCommand(org.NewCmdOrg(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(invite.NewCmdInvite(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(portforward.NewCmdPortForward(loginCmdStore, t))
+	cmd.AddCommand(forward.NewCmdForward(t, loginCmdStore))
 	cmd.AddCommand(login.NewCmdLogin(t, noLoginCmdStore, loginAuth))
 	cmd.AddCommand(logout.NewCmdLogout(loginAuth, noLoginCmdStore))
 	cmd.AddCommand(meshd.NewCmdMeshD(t, noLoginCmdStore))
@@ -223,18 +234,28 @@ func createCmdTree(cmd *cobra.Command, t *terminal.Terminal, loginCmdStore *stor
 	cmd.AddCommand(secret.NewCmdSecret(loginCmdStore, t))
 	cmd.AddCommand(sshkeys.NewCmdSSHKeys(t, loginCmdStore))
 	cmd.AddCommand(start.NewCmdStart(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(apply.NewCmdApply(t, loginCmdStore))
 	cmd.AddCommand(stop.NewCmdStop(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(delete.NewCmdDelete(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(reset.NewCmdReset(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(snapshot.NewCmdSnapshot(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(wait.NewCmdWait(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(schedule.NewCmdSchedule(t, loginCmdStore, noLoginCmdStore))
+	cmd.AddCommand(templates.NewCmdTemplates(t, loginCmdStore))
+	cmd.AddCommand(classes.NewCmdClasses(t, loginCmdStore))
+	cmd.AddCommand(resize.NewCmdResize(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(profile.NewCmdProfile(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(up.NewCmdJetbrains(loginCmdStore, t, true))
+	cmd.AddCommand(sshall.NewCmdSSHAll(t, noLoginCmdStore))
 	cmd.AddCommand(refresh.NewCmdRefresh(t, loginCmdStore))
+	cmd.AddCommand(sshconfig.NewCmdSSHConfig(t, loginCmdStore, noLoginCmdStore))
 	cmd.AddCommand(runtasks.NewCmdRunTasks(t, noLoginCmdStore))
 	cmd.AddCommand(proxy.NewCmdProxy(t, noLoginCmdStore))
 	cmd.AddCommand(healthcheck.NewCmdHealthcheck(t, noLoginCmdStore))
 
 	cmd.AddCommand(setupworkspace.NewCmdSetupWorkspace(noLoginCmdStore))
 	cmd.AddCommand(sshmon.NewCmdSSHMon(noLoginCmdStore, config.GlobalConfig.GetSegmentKey()))
+	cmd.AddCommand(forward.NewCmdListeningPorts(noLoginCmdStore))
 }
 
 func hasHousekeepingCommands(cmd *cobra.Command) bool {
@@ -18,8 +18,9 @@ func DisplayAndHandleCmdError(name string, cmdFunc func() error) error {
 	er.AddTag("command", name)
 	err := cmdFunc()
 	if err != nil {
-		er.ReportMessage(err.Error())
-		er.ReportError(err)
+		redactedErr := terminal.New().RedactError(err)
+		er.ReportMessage(redactedErr.Error())
+		er.ReportError(redactedErr)
 		if featureflag.Debug() || featureflag.IsDev() {
 			return err
 		} else {
@@ -36,15 +37,16 @@ func DisplayAndHandleError(err error) {
 		switch err.(type) {
 		case breverrors.ValidationError:
 			// do not report error
-			prettyErr = (t.Yellow(errors.Cause(err).Error()))
+			prettyErr = (t.Yellow(t.Redact(errors.Cause(err).Error())))
 		default:
+			redactedErr := t.RedactError(err)
 			er := breverrors.GetDefaultErrorReporter()
-			er.ReportMessage(err.Error())
-			er.ReportError(err)
-			prettyErr = (t.Red(errors.Cause(err).Error()))
+			er.ReportMessage(redactedErr.Error())
+			er.ReportError(redactedErr)
+			prettyErr = (t.Red(t.Redact(errors.Cause(err).Error())))
 		}
 		if featureflag.Debug() || featureflag.IsDev() {
-			fmt.Println(err)
+			fmt.Println(t.Redact(fmt.Sprint(err)))
 		} else {
 			fmt.Println(prettyErr)
 		}
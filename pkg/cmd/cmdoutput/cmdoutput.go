@@ -0,0 +1,133 @@
+// Package cmdoutput renders listing command results as tables, json or yaml
+package cmdoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+type Format string
+
+const (
+	TableFormat Format = "table"
+	WideFormat  Format = "wide"
+	JSONFormat  Format = "json"
+	YAMLFormat  Format = "yaml"
+)
+
+var Formats = []Format{TableFormat, WideFormat, JSONFormat, YAMLFormat}
+
+// SchemaVersion is bumped whenever a field is removed or renamed in the
+// machine readable output so scripts can detect breaking changes
+const SchemaVersion = "brev.dev/v1"
+
+const FlagName = "output"
+
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return TableFormat, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", breverrors.NewValidationError(fmt.Sprintf("invalid output format %q, must be one of %s", s, strings.Join(formatStrings(), "|")))
+}
+
+func (f Format) IsMachineReadable() bool {
+	return f == JSONFormat || f == YAMLFormat
+}
+
+func (f Format) IsWide() bool {
+	return f == WideFormat
+}
+
+func formatStrings() []string {
+	strs := []string{}
+	for _, f := range Formats {
+		strs = append(strs, string(f))
+	}
+	return strs
+}
+
+// TableOptions is the borderless style every brev table is drawn with
+func TableOptions() table.Options {
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	return options
+}
+
+// AddOutputFlag registers the shared --output flag on a listing command
+func AddOutputFlag(cmd *cobra.Command, output *string) {
+	cmd.Flags().StringVar(output, FlagName, string(TableFormat), fmt.Sprintf("output format [%s]", strings.Join(formatStrings(), "|")))
+	_ = cmd.RegisterFlagCompletionFunc(FlagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formatStrings(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+type List struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Items      interface{} `json:"items"`
+}
+
+// NewList wraps items in a versioned envelope, items must be a slice
+func NewList(kind string, items interface{}) List {
+	v := reflect.ValueOf(items)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return List{
+		APIVersion: SchemaVersion,
+		Kind:       kind,
+		Items:      items,
+	}
+}
+
+func Write(w io.Writer, format Format, v interface{}) error {
+	switch format {
+	case JSONFormat:
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	case YAMLFormat:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = fmt.Fprint(w, string(out))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	default:
+		return fmt.Errorf("format %s is not machine readable", format)
+	}
+	return nil
+}
+
+// WriteList writes items wrapped in a List of the given kind
+func WriteList(w io.Writer, format Format, kind string, items interface{}) error {
+	err := Write(w, format, NewList(kind, items))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
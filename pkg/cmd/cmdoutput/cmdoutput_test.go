@@ -0,0 +1,68 @@
+package cmdoutput
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.Nil(t, err)
+	assert.Equal(t, TableFormat, f)
+
+	f, err = ParseFormat("JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, JSONFormat, f)
+	assert.True(t, f.IsMachineReadable())
+
+	f, err = ParseFormat("wide")
+	assert.Nil(t, err)
+	assert.True(t, f.IsWide())
+	assert.False(t, f.IsMachineReadable())
+
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err)
+}
+
+func TestWriteListJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := WriteList(buf, JSONFormat, "OrganizationList", []entity.Organization{{ID: "o1", Name: "org"}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	expected := `{
+  "apiVersion": "brev.dev/v1",
+  "kind": "OrganizationList",
+  "items": [
+    {
+      "id": "o1",
+      "name": "org",
+      "userNetworkId": ""
+    }
+  ]
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteListYAMLEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var workspaces []entity.Workspace
+	err := WriteList(buf, YAMLFormat, "WorkspaceList", workspaces)
+	if !assert.Nil(t, err) {
+		return
+	}
+	expected := `apiVersion: brev.dev/v1
+items: []
+kind: WorkspaceList
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteTableFormatFails(t *testing.T) {
+	err := Write(&bytes.Buffer{}, TableFormat, nil)
+	assert.NotNil(t, err)
+}
@@ -1,24 +1,29 @@
 package delete
 
 import (
-	"fmt"
-
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
-	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 )
 
 var (
 	deleteLong    = "Delete a Brev workspace that you no longer need. If you have a .brev setup script, you can get a new one without setting up."
-	deleteExample = "brev delete <ws_name>"
+	deleteExample = `
+  brev delete <ws_name>
+  brev delete <ws_name> <ws_name>
+  brev delete "tmp-*" --yes
+  brev delete --selector status=STOPPED,repo=github.com:brevdev/hello-react.git
+	`
 )
 
 type DeleteStore interface {
 	completions.CompletionStore
+	resolver.ResolverStore
 	GetAllWorkspaces(options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetWorkspace(id string) (*entity.Workspace, error)
 	DeleteWorkspace(workspaceID string) (*entity.Workspace, error)
@@ -27,6 +32,9 @@ type DeleteStore interface {
 }
 
 func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDeleteStore DeleteStore) *cobra.Command {
+	bulkOptions := bulk.Options{ConfirmFuzzy: true}
+	var skipConfirm bool
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "delete",
@@ -36,93 +44,60 @@ func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDel
 		Example:               deleteExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginDeleteStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var allError error
-			for _, workspace := range args {
-				err := deleteWorkspace(workspace, t, loginDeleteStore)
-				if err != nil {
-					allError = multierror.Append(allError, err)
-				}
+			err := bulkOptions.Validate(args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
 			}
-			if allError != nil {
-				return breverrors.WrapAndTrace(allError)
+			if bulkOptions.IsBulk(args) {
+				err = deleteWorkspaces(args, bulkOptions, skipConfirm, t, loginDeleteStore)
+			} else {
+				err = deleteWorkspace(args[0], t, loginDeleteStore)
+			}
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	bulk.AddFlags(cmd, &bulkOptions)
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation when deleting by --all, --selector or pattern")
 
 	return cmd
 }
 
-func deleteWorkspace(workspaceName string, t *terminal.Terminal, deleteStore DeleteStore) error {
-	workspace, err := getWorkspaceFromNameOrID(workspaceName, deleteStore)
+func deleteWorkspaces(args []string, bulkOptions bulk.Options, skipConfirm bool, t *terminal.Terminal, deleteStore DeleteStore) error {
+	workspaces, err := bulk.GetWorkspaces(t, deleteStore, args, bulkOptions)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-
-	deletedWorkspace, err := deleteStore.DeleteWorkspace(workspace.ID)
+	if bulkOptions.IsPattern(args) && !skipConfirm && !bulk.Confirm("Delete", "deleted", t, workspaces) {
+		return nil
+	}
+	err = bulk.RunAndSummarize(t, workspaces, bulkOptions.Parallelism, func(workspace entity.Workspace) (string, error) {
+		_, err := deleteStore.DeleteWorkspace(workspace.ID)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return "deleting", nil
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-
-	t.Vprintf("Deleting workspace %s. This can take a few minutes. Run 'brev ls' to check status\n", deletedWorkspace.Name)
-
 	return nil
 }
 
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-func getWorkspaceFromNameOrID(nameOrID string, sstore DeleteStore) (*entity.WorkspaceWithMeta, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-	if org == nil {
-		return nil, breverrors.NewValidationError("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
+func deleteWorkspace(workspaceName string, t *terminal.Terminal, deleteStore DeleteStore) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, deleteStore).WithConfirmFuzzy(true).GetWorkspaceFromNameOrID(workspaceName)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return breverrors.WrapAndTrace(err)
 	}
 
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
+	deletedWorkspace, err := deleteStore.DeleteWorkspace(workspace.ID)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID))
-	}
-
-	if workspace == nil {
-		return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+		return breverrors.WrapAndTrace(err)
 	}
 
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
+	t.Vprintf("Deleting workspace %s. This can take a few minutes. Run 'brev ls' to check status\n", deletedWorkspace.Name)
 
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+	return nil
 }
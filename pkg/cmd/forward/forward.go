@@ -0,0 +1,217 @@
+// Package forward forwards the ports servers listen on in a workspace as they
+// come and go
+package forward
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/autoforward"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	cmdportforward "github.com/brevdev/brev-cli/pkg/cmd/portforward"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/k8s"
+	"github.com/brevdev/brev-cli/pkg/portforward"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	forwardLong = `Forward the ports servers listen on in your workspace to the same ports on this
+machine, opening and closing forwards as the servers start and stop. Port 22 is
+never forwarded.`
+	forwardExample = `  brev forward --auto <ws_name>
+  brev forward --auto <ws_name> --allow 3000-9000 --deny 5432`
+)
+
+const resubscribeDelay = 2 * time.Second
+
+func NewCmdForward(t *terminal.Terminal, store cmdportforward.PortforwardStore) *cobra.Command {
+	var auto bool
+	var allow []string
+	var deny []string
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "forward",
+		DisableFlagsInUseLine: true,
+		Short:                 "Forward the ports your workspace listens on",
+		Long:                  forwardLong,
+		Example:               forwardExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(store, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !auto {
+				return breverrors.NewValidationError("use --auto, or brev port-forward to forward fixed ports")
+			}
+			allowRanges, err := autoforward.ParsePortRanges(allow)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			denyRanges, err := autoforward.ParsePortRanges(deny)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			filter := autoforward.PortFilter{
+				Allow: allowRanges,
+				Deny:  append(append([]autoforward.PortRange{}, autoforward.DefaultDeny...), denyRanges...),
+			}
+			err = runAutoForward(t, store, args[0], filter)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&auto, "auto", false, "forward ports as servers in the workspace start listening on them")
+	cmd.Flags().StringArrayVar(&allow, "allow", []string{}, "only forward these ports or ranges, ex: 3000-3999, can be repeated")
+	cmd.Flags().StringArrayVar(&deny, "deny", []string{}, "never forward these ports or ranges, ex: 5432, can be repeated")
+
+	return cmd
+}
+
+func runAutoForward(t *terminal.Terminal, store cmdportforward.PortforwardStore, workspaceName string, filter autoforward.PortFilter) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, store).GetWorkspaceFromNameOrID(workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	k8sClientMapper, err := k8s.NewDefaultWorkspaceGroupClientMapper(store)
+	if err != nil {
+		switch err.(type) {
+		case *url.Error:
+			return breverrors.WrapAndTrace(err, "check your internet connection")
+		default:
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	forwarder := autoforward.PortForwardForwarder{
+		NewOptions: func() (*portforward.PortForwardOptions, error) {
+			opts, err := portforward.NewPortForwardOptions(k8sClientMapper, portforward.NewQuietPortForwarder()).WithWorkspace(*workspace)
+			if err != nil {
+				return nil, breverrors.WrapAndTrace(err)
+			}
+			return opts, nil
+		},
+	}
+	af := autoforward.NewAutoForwarder(forwarder, filter)
+	subscriber := autoforward.SSHPortSubscriber{SSHName: string(workspace.GetLocalIdentifier())}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	reports := make(chan []autoforward.ListeningPort)
+	status := make(chan string, 1)
+	go subscribe(subscriber, reports, status, stop)
+
+	message := "waiting for the ports of " + workspace.Name
+	displayForwards(t, workspace.Name, af.Forwards(), message)
+	for {
+		select {
+		case <-stop:
+			af.StopAll()
+			return nil
+		case ports := <-reports:
+			message = ""
+			af.Update(ports)
+		case <-af.Changes():
+		case message = <-status:
+		}
+		displayForwards(t, workspace.Name, af.Forwards(), message)
+	}
+}
+
+// subscribe keeps a subscription to the ports of the workspace until stop is
+// closed
+func subscribe(subscriber autoforward.PortSubscriber, reports chan<- []autoforward.ListeningPort, status chan<- string, stop <-chan struct{}) {
+	for {
+		err := subscriber.Subscribe(reports, stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		message := "lost connection to the workspace, reconnecting..."
+		if err != nil {
+			message = fmt.Sprintf("lost connection to the workspace (%v), reconnecting...", err)
+		}
+		select {
+		case status <- message:
+		default:
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+func displayForwards(t *terminal.Terminal, workspaceName string, forwards []autoforward.Forward, message string) {
+	// redraw in place
+	fmt.Print("\033[H\033[2J")
+	t.Vprintf("Forwarding the ports %s listens on, press ctrl-c to stop\n\n", workspaceName)
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"LOCAL", "WORKSPACE PORT", "STATUS", "SINCE"})
+	for _, f := range forwards {
+		status := t.Green("forwarding")
+		if f.Err != nil {
+			status = t.Red("failed: %v", f.Err)
+		}
+		ta.AppendRow(table.Row{fmt.Sprintf("localhost:%d", f.Port), f.Port, status, f.Since.Format("15:04:05")})
+	}
+	ta.Render()
+	if message != "" {
+		t.Vprintf("\n%s\n", t.Yellow(message))
+	}
+}
+
+type ListeningPortsStore interface {
+	autoforward.ListeningPortsStore
+}
+
+// NewCmdListeningPorts runs in the workspace, brev forward --auto reads the
+// ports from it over ssh
+func NewCmdListeningPorts(store ListeningPortsStore) *cobra.Command {
+	var watch bool
+
+	cmd := &cobra.Command{
+		Annotations: map[string]string{"hidden": ""},
+		Use:         "listening-ports",
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reportPath, err := autoforward.GetReportPath(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			stop := make(chan struct{})
+			if !watch {
+				close(stop)
+			}
+			err = autoforward.WatchListeningPorts(afero.NewOsFs(), reportPath, time.Second, os.Stdout, stop)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&watch, "watch", false, "print the ports again each time they change")
+
+	return cmd
+}
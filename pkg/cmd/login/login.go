@@ -24,6 +24,15 @@ import (
 	"golang.org/x/text/encoding/charmap"
 )
 
+const loginLong = `Log into brev
+
+The credentials are kept in the Secret Service keyring when your desktop
+session runs one, else in ~/.brev/credentials.json, and the store is recorded
+in ~/.brev/credential_store. Set BREV_CREDENTIAL_STORE to keyring,
+encrypted-file or file when logging in to move them.
+encrypted-file asks for its passphrase on the terminal every run, so it is for
+interactive use only, daemons and scripts can not read it.`
+
 type LoginOptions struct {
 	Auth       Auth
 	LoginStore LoginStore
@@ -60,7 +69,7 @@ func NewCmdLogin(t *terminal.Terminal, loginStore LoginStore, auth Auth) *cobra.
 		Use:                   "login",
 		DisableFlagsInUseLine: true,
 		Short:                 "Log into brev",
-		Long:                  "Log into brev",
+		Long:                  loginLong,
 		Example:               "brev login",
 		Args:                  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -30,6 +31,7 @@ type LsStore interface {
 func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore) *cobra.Command {
 	var showAll bool
 	var org string
+	var output string
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
@@ -40,6 +42,9 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
   brev ls
   brev ls orgs
   brev ls --org <orgid>
+  brev ls --output wide
+  brev ls --output json
+  brev ls orgs --output yaml
 		`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
@@ -52,7 +57,11 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 		Args:      cobra.MinimumNArgs(0),
 		ValidArgs: []string{"orgs", "workspaces"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunLs(t, loginLsStore, args, org, showAll)
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunLs(t, loginLsStore, args, org, showAll, format)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -67,6 +76,7 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 	}
 
 	cmd.Flags().BoolVar(&showAll, "all", false, "show all workspaces in org")
+	cmdoutput.AddOutputFlag(cmd, &output)
 
 	return cmd
 }
@@ -100,8 +110,8 @@ func getOrgForRunLs(lsStore LsStore, orgflag string) (*entity.Organization, erro
 	return org, nil
 }
 
-func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool) error {
-	ls := NewLs(lsStore, t)
+func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool, format cmdoutput.Format) error {
+	ls := NewLs(lsStore, t).WithFormat(format)
 	user, err := lsStore.GetCurrentUser()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -165,20 +175,34 @@ func handleLsArg(ls *Ls, arg string, user *entity.User, org *entity.Organization
 type Ls struct {
 	lsStore  LsStore
 	terminal *terminal.Terminal
+	format   cmdoutput.Format
 }
 
 func NewLs(lsStore LsStore, terminal *terminal.Terminal) *Ls {
 	return &Ls{
 		lsStore:  lsStore,
 		terminal: terminal,
+		format:   cmdoutput.TableFormat,
 	}
 }
 
+func (ls *Ls) WithFormat(format cmdoutput.Format) *Ls {
+	ls.format = format
+	return ls
+}
+
 func (ls Ls) RunOrgs() error {
 	orgs, err := ls.lsStore.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, ls.format, "OrganizationList", orgs)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	if len(orgs) == 0 {
 		ls.terminal.Vprint(ls.terminal.Yellow("You don't have any orgs. Create one! https://console.brev.dev"))
 		return nil
@@ -217,6 +241,13 @@ func (ls Ls) RunUser(_ bool) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, ls.format, "UserList", users)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	for _, user := range users {
 		fmt.Printf("%s	%s	%s\n", user.ID, user.Name, user.Email)
 	}
@@ -269,7 +300,7 @@ func (ls Ls) displayWorkspacesAndHelp(org *entity.Organization, otherOrgs []enti
 		}
 	} else {
 		ls.terminal.Vprintf("You have %d workspaces in Org "+ls.terminal.Yellow(org.Name)+"\n", len(userWorkspaces))
-		displayWorkspacesTable(ls.terminal, userWorkspaces)
+		displayWorkspacesTable(ls.terminal, userWorkspaces, ls.format.IsWide())
 
 		fmt.Print("\n")
 
@@ -311,6 +342,17 @@ func (ls Ls) RunWorkspaces(org *entity.Organization, user *entity.User, showAll
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.format.IsMachineReadable() {
+		workspaces := allWorkspaces
+		if !showAll {
+			workspaces = store.FilterForUserWorkspaces(allWorkspaces, user.ID)
+		}
+		err = cmdoutput.WriteList(os.Stdout, ls.format, "WorkspaceList", workspaces)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	orgs, err := ls.lsStore.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -334,8 +376,19 @@ func (ls Ls) RunHosts(org *entity.Organization) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	hosts := []string{}
 	for _, workspace := range workspaces {
-		fmt.Println(workspace.GetNodeIdentifierForVPN())
+		hosts = append(hosts, workspace.GetNodeIdentifierForVPN())
+	}
+	if ls.format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, ls.format, "HostList", hosts)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	for _, host := range hosts {
+		fmt.Println(host)
 	}
 	return nil
 }
@@ -359,38 +412,43 @@ func displayProjects(t *terminal.Terminal, orgName string, projects []entity.Vir
 
 const enableSSHCol = false
 
-func getBrevTableOptions() table.Options {
-	options := table.OptionsDefault
-	options.DrawBorder = false
-	options.SeparateColumns = false
-	options.SeparateRows = false
-	options.SeparateHeader = false
-	return options
-}
-
-func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace) {
+func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace, wide bool) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
-	ta.Style().Options = getBrevTableOptions()
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(getWorkspacesTableHeader(wide))
+	for _, w := range workspaces {
+		ta.AppendRow(getWorkspacesTableRow(t, w, wide))
+	}
+	ta.Render()
+}
+
+func getWorkspacesTableHeader(wide bool) table.Row {
 	header := table.Row{"NAME", "STATUS", "URL", "ID"}
 	if enableSSHCol {
 		header = table.Row{"NAME", "STATUS", "URL", "SSH", "ID"}
 	}
-	ta.AppendHeader(header)
-	for _, w := range workspaces {
-		workspaceRow := []table.Row{{w.Name, getStatusColoredText(t, w.Status), w.DNS, w.ID}}
-		if enableSSHCol {
-			workspaceRow = []table.Row{{w.Name, getStatusColoredText(t, w.Status), w.DNS, w.GetLocalIdentifier(), w.ID}}
-		}
-		ta.AppendRows(workspaceRow)
+	if wide {
+		header = append(header, "CLASS", "TEMPLATE", "GIT REPO", "CREATED BY")
 	}
-	ta.Render()
+	return header
+}
+
+func getWorkspacesTableRow(t *terminal.Terminal, w entity.Workspace, wide bool) table.Row {
+	row := table.Row{w.Name, getStatusColoredText(t, w.Status), w.DNS, w.ID}
+	if enableSSHCol {
+		row = table.Row{w.Name, getStatusColoredText(t, w.Status), w.DNS, w.GetLocalIdentifier(), w.ID}
+	}
+	if wide {
+		row = append(row, w.WorkspaceClassID, w.WorkspaceTemplate.Name, w.GitRepo, w.CreatedByUserID)
+	}
+	return row
 }
 
 func displayOrgTable(t *terminal.Terminal, orgs []entity.Organization, currentOrg *entity.Organization) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
-	ta.Style().Options = getBrevTableOptions()
+	ta.Style().Options = cmdoutput.TableOptions()
 	header := table.Row{"NAME", "ID"}
 	ta.AppendHeader(header)
 	for _, o := range orgs {
@@ -406,7 +464,7 @@ func displayOrgTable(t *terminal.Terminal, orgs []entity.Organization, currentOr
 func displayProjectsTable(projects []entity.VirtualProject) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
-	ta.Style().Options = getBrevTableOptions()
+	ta.Style().Options = cmdoutput.TableOptions()
 	header := table.Row{"NAME", "MEMBERS"}
 	ta.AppendHeader(header)
 	for _, p := range projects {
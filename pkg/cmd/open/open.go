@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
@@ -22,6 +23,7 @@ var (
 )
 
 type OpenStore interface {
+	resolver.ResolverStore
 	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 	GetCurrentUser() (*entity.User, error)
@@ -58,7 +60,7 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string) e
 	// s.Suffix = " finding your workspace"
 	// s.Start()
 
-	workspace, _, err := getWorkspaceFromNameOrIDAndReturnWorkspacesPlusWorkspace(wsIDOrName, tstore)
+	workspace, err := resolver.NewWorkspaceResolver(t, tstore).GetWorkspaceFromNameOrID(wsIDOrName)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -124,62 +126,3 @@ func openCode(localIdentifier string, folderName string, t *terminal.Terminal) e
 
 	return nil
 }
-
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-// NOTE2: small modification on this one-- returning all the workspaces too in case they're needed and shouldn't be fetched twice.
-func getWorkspaceFromNameOrIDAndReturnWorkspacesPlusWorkspace(nameOrID string, sstore OpenStore) (*entity.WorkspaceWithMeta, *[]entity.Workspace, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return nil, nil, breverrors.WrapAndTrace(err)
-	}
-	if org == nil {
-		return nil, nil, fmt.Errorf("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
-	if err != nil {
-		return nil, nil, breverrors.WrapAndTrace(err)
-	}
-
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
-	if err != nil {
-		return nil, nil, breverrors.WrapAndTrace(err)
-	}
-
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, nil, fmt.Errorf("no workspaces found with name or id %s", nameOrID)
-		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, nil, fmt.Errorf("no workspaces found with name or id %s", nameOrID)
-		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, nil, fmt.Errorf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID)
-	}
-
-	if workspace == nil {
-		return nil, nil, fmt.Errorf("no workspaces found with name or id %s", nameOrID)
-	}
-
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
-	if err != nil {
-		return nil, nil, breverrors.WrapAndTrace(err)
-	}
-
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, &workspaces, nil
-}
@@ -1,6 +1,7 @@
 package org
 
 import (
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -12,6 +13,7 @@ import (
 func NewCmdOrgLs(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgCmdStore) *cobra.Command {
 	var showAll bool
 	var org string
+	var output string
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
@@ -32,7 +34,11 @@ func NewCmdOrgLs(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore Or
 		Args: cobra.NoArgs,
 		// ValidArgs: []string{"new", "ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunOrgs(t, orgcmdStore)
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunOrgs(t, orgcmdStore, format)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -47,6 +53,7 @@ func NewCmdOrgLs(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore Or
 	}
 
 	cmd.Flags().BoolVar(&showAll, "all", false, "show all workspaces in org")
+	cmdoutput.AddOutputFlag(cmd, &output)
 
 	return cmd
 }
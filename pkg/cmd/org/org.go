@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -54,7 +55,7 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 		Args: cobra.NoArgs,
 		// ValidArgs: []string{"new", "ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunOrgs(t, orgcmdStore)
+			err := RunOrgs(t, orgcmdStore, cmdoutput.TableFormat)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -74,11 +75,18 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 	return cmd
 }
 
-func RunOrgs(t *terminal.Terminal, store OrgCmdStore) error {
+func RunOrgs(t *terminal.Terminal, store OrgCmdStore, format cmdoutput.Format) error {
 	orgs, err := store.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, format, "OrganizationList", orgs)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	if len(orgs) == 0 {
 		t.Vprint(t.Yellow("You don't have any orgs. Create one! https://console.brev.dev"))
 		return nil
@@ -101,15 +109,6 @@ func RunOrgs(t *terminal.Terminal, store OrgCmdStore) error {
 	return nil
 }
 
-func getBrevTableOptions() table.Options {
-	options := table.OptionsDefault
-	options.DrawBorder = false
-	options.SeparateColumns = false
-	options.SeparateRows = false
-	options.SeparateHeader = false
-	return options
-}
-
 func getOtherOrg(orgs []entity.Organization, org entity.Organization) *entity.Organization {
 	for _, o := range orgs {
 		if org.ID != o.ID {
@@ -122,7 +121,7 @@ func getOtherOrg(orgs []entity.Organization, org entity.Organization) *entity.Or
 func displayOrgTable(t *terminal.Terminal, orgs []entity.Organization, currentOrg *entity.Organization) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
-	ta.Style().Options = getBrevTableOptions()
+	ta.Style().Options = cmdoutput.TableOptions()
 	header := table.Row{"NAME", "ID"}
 	ta.AppendHeader(header)
 	for _, o := range orgs {
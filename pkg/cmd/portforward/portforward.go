@@ -6,10 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"sort"
 	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/portforward"
@@ -24,34 +26,41 @@ var (
 	Port           string
 	sshLinkLong    = "Port forward your Brev machine's port to your local port"
 	sshLinkExample = "brev port-forward <ws_name> -p local_port:remote_port"
+
+	portForwardLong = `Port forward your Brev machine's ports to your local ports, or your local ports to
+your Brev machine with --reverse. Forwards reconnect when the connection drops.
+
+Forwards you use often can be saved as profiles in ~/.brev/forwards.yaml:
+
+  web:
+    workspace: my-workspace
+    ports: ["3000", "8080:80"]
+    reverse: ["5432"]`
+	portForwardExample = `  brev port-forward <ws_name> -p local_port:remote_port
+  brev port-forward <ws_name> -p 3000 -p 8080:80
+  brev port-forward <ws_name> --reverse 5432:5432
+  brev port-forward --profile web`
 )
 
 type PortforwardStore interface {
 	k8s.K8sStore
 	completions.CompletionStore
+	resolver.ResolverStore
 	GetWorkspaceMetaData(workspaceID string) (*entity.WorkspaceMetaData, error)
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetAllWorkspaces(options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetCurrentUser() (*entity.User, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 	GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error)
+	GetForwardProfiles() (string, error)
 }
 
-func ConvertNametoSSHName(store PortforwardStore, workspaceNameOrID string) (string, error) {
-	org, err := store.GetActiveOrganizationOrDefault()
+func ConvertNametoSSHName(t *terminal.Terminal, store PortforwardStore, workspaceNameOrID string) (string, error) {
+	workspace, err := resolver.NewWorkspaceResolver(t, store).GetWorkspace(workspaceNameOrID)
 	if err != nil {
 		return "", breverrors.WrapAndTrace(err)
 	}
-	workspaces, err := store.GetWorkspaceByNameOrID(org.ID, workspaceNameOrID)
-	if err != nil {
-		return "", breverrors.WrapAndTrace(err)
-	}
-	if len(workspaces) == 0 {
-		return "", fmt.Errorf("workspace with id/name %s not found", workspaceNameOrID)
-	} else if len(workspaces) > 1 {
-		return "", fmt.Errorf("duplicate workspace ids: %s", workspaceNameOrID)
-	}
-	sshName := string(workspaces[0].GetLocalIdentifier())
+	sshName := string(workspace.GetLocalIdentifier())
 	return sshName, nil
 }
 
@@ -67,7 +76,7 @@ func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(pfStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if Port == "" {
-				startInput(t)
+				Port = startInput(t)
 			}
 			var portSplit []string
 			if strings.Contains(Port, ":") {
@@ -79,7 +88,7 @@ func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra
 				return breverrors.NewValidationError("port format invalid, use local_port:remote_port")
 			}
 
-			sshName, err := ConvertNametoSSHName(pfStore, args[0])
+			sshName, err := ConvertNametoSSHName(t, pfStore, args[0])
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -91,7 +100,7 @@ func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra
 			return nil
 		},
 	}
-	cmd.Flags().StringVarP(&Port, "port", "p", "", "port forward flag describe me better")
+	cmd.Flags().StringVarP(&Port, "port", "p", "", "forward local_port:remote_port from this machine to the workspace")
 	err := cmd.RegisterFlagCompletionFunc("port", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return nil, cobra.ShellCompDirectiveNoSpace
 	})
@@ -124,18 +133,43 @@ func RunSSHPortForward(forwardType string, localPort string, remotePort string,
 }
 
 func NewCmdPortForward(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Command {
+	var ports []string
+	var reverse []string
+	var profileName string
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"ssh": ""},
 		Use:                   "port-forward",
 		DisableFlagsInUseLine: true,
 		Short:                 "Enable a local ssh link tunnel",
-		Long:                  sshLinkLong,
-		Example:               sshLinkExample,
-		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		Long:                  portForwardLong,
+		Example:               portForwardExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.RangeArgs(0, 1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(pfStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if Port == "" {
-				startInput(t)
+			profile, err := getForwardProfile(pfStore, profileName)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			workspaceName := profile.Workspace
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			if workspaceName == "" {
+				return breverrors.NewValidationError("give the workspace to forward to, or set workspace in the profile")
+			}
+			ports = append(profile.Ports, ports...)
+			reverse = append(profile.Reverse, reverse...)
+			if len(ports) == 0 && len(reverse) == 0 {
+				ports = []string{startInput(t)}
+			}
+			portMappings, err := portforward.ParsePortMappings(ports)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			reverseMappings, err := portforward.ParsePortMappings(reverse)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
 			}
 
 			k8sClientMapper, err := k8s.NewDefaultWorkspaceGroupClientMapper(pfStore)
@@ -154,7 +188,7 @@ func NewCmdPortForward(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Co
 				pf,
 			)
 
-			workspace, err := getWorkspaceFromNameOrID(args[0], pfStore)
+			workspace, err := resolver.NewWorkspaceResolver(t, pfStore).GetWorkspaceFromNameOrID(workspaceName)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -164,7 +198,8 @@ func NewCmdPortForward(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Co
 				return breverrors.WrapAndTrace(err)
 			}
 
-			opts.WithPort(Port)
+			opts.WithPorts(portMappings).WithReverse(reverseMappings)
+			printForwards(t, workspace.Name, portMappings, reverseMappings)
 
 			err = opts.RunPortforward()
 			if err != nil {
@@ -173,9 +208,29 @@ func NewCmdPortForward(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Co
 			return nil
 		},
 	}
-	cmd.Flags().StringVarP(&Port, "port", "p", "", "port forward flag describe me better")
-	err := cmd.RegisterFlagCompletionFunc("port", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return nil, cobra.ShellCompDirectiveNoSpace
+	cmd.Flags().StringArrayVarP(&ports, "port", "p", []string{}, "forward local_port:remote_port from this machine to the workspace, can be repeated")
+	cmd.Flags().StringArrayVarP(&reverse, "reverse", "R", []string{}, "forward remote_port on the workspace to local_port on this machine, given as local_port:remote_port, can be repeated")
+	cmd.Flags().StringVar(&profileName, "profile", "", "use the forwards of a profile in ~/.brev/forwards.yaml")
+	for _, flag := range []string{"port", "reverse"} {
+		err := cmd.RegisterFlagCompletionFunc(flag, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoSpace
+		})
+		if err != nil {
+			breverrors.GetDefaultErrorReporter().ReportError(err)
+			t.Errprint(err, "cli err")
+		}
+	}
+	err := cmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		profiles, err := getForwardProfiles(pfStore)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		names := []string{}
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
 	})
 	if err != nil {
 		breverrors.GetDefaultErrorReporter().ReportError(err)
@@ -185,80 +240,58 @@ func NewCmdPortForward(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Co
 	return cmd
 }
 
-func startInput(t *terminal.Terminal) {
-	t.Vprint(t.Yellow("\nPorts flag was omitted, running interactive mode!\n"))
-	remoteInput := terminal.PromptGetInput(terminal.PromptContent{
-		Label:    "What port on your Brev machine would you like to forward?",
-		ErrorMsg: "error",
-	})
-	localInput := terminal.PromptGetInput(terminal.PromptContent{
-		Label:    "What port should it be on your local machine?",
-		ErrorMsg: "error",
-	})
-
-	Port = localInput + ":" + remoteInput
-
-	t.Vprintf(t.Green("\n-p " + Port + "\n"))
-
-	t.Printf("\nStarting ssh link...\n")
-}
-
-type WorkspaceResolver struct{}
-
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-func getWorkspaceFromNameOrID(nameOrID string, sstore PortforwardStore) (*entity.WorkspaceWithMeta, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
+func getForwardProfiles(pfStore PortforwardStore) (portforward.ForwardProfiles, error) {
+	data, err := pfStore.GetForwardProfiles()
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
-	if org == nil {
-		return nil, breverrors.NewValidationError("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
+	profiles, err := portforward.ParseForwardProfiles(data)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return nil, fmt.Errorf("invalid ~/.brev/forwards.yaml: %w", err)
 	}
+	return profiles, nil
+}
 
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
+// getForwardProfile is an empty profile when no profile is given
+func getForwardProfile(pfStore PortforwardStore, name string) (portforward.ForwardProfile, error) {
+	if name == "" {
+		return portforward.ForwardProfile{}, nil
+	}
+	profiles, err := getForwardProfiles(pfStore)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return portforward.ForwardProfile{}, breverrors.WrapAndTrace(err)
 	}
-
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID))
+	profile, ok := profiles[name]
+	if !ok {
+		return portforward.ForwardProfile{}, breverrors.NewValidationError(fmt.Sprintf("no profile %s in ~/.brev/forwards.yaml", name))
 	}
+	return profile, nil
+}
 
-	if workspace == nil {
-		return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+func printForwards(t *terminal.Terminal, workspaceName string, ports []portforward.PortMapping, reverse []portforward.PortMapping) {
+	for _, m := range ports {
+		t.Vprintf("localhost:%s -> %s:%s\n", m.Local, workspaceName, m.Remote)
 	}
-
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+	for _, m := range reverse {
+		t.Vprintf("%s:%s -> localhost:%s\n", workspaceName, m.Remote, m.Local)
 	}
+}
+
+func startInput(t *terminal.Terminal) string {
+	t.Vprint(t.Yellow("\nPorts flag was omitted, running interactive mode!\n"))
+	remoteInput := terminal.PromptGetInput(terminal.PromptContent{
+		Label:    "What port on your Brev machine would you like to forward?",
+		ErrorMsg: "error",
+	})
+	localInput := terminal.PromptGetInput(terminal.PromptContent{
+		Label:    "What port should it be on your local machine?",
+		ErrorMsg: "error",
+	})
+
+	port := localInput + ":" + remoteInput
 
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+	t.Vprintf(t.Green("\n-p " + port + "\n"))
+
+	t.Printf("\nStarting ssh link...\n")
+	return port
 }
@@ -64,7 +64,7 @@ func Proxy(_ *terminal.Terminal, store ProxyStore, workspaceID string) error {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	url := makeProxyURL(workspace)
+	url := MakeProxyURL(workspace)
 	err = huproxyclient.Run(url, store)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -72,7 +72,7 @@ func Proxy(_ *terminal.Terminal, store ProxyStore, workspaceID string) error {
 	return nil
 }
 
-func makeProxyURL(w *entity.Workspace) string {
+func MakeProxyURL(w *entity.Workspace) string {
 	return fmt.Sprintf("wss://%s/proxy", w.GetSSHURL())
 }
 
@@ -96,6 +96,23 @@ func CheckWorkspaceCanSSH(workspace *entity.Workspace) error {
 	return nil
 }
 
+// CheckWorkspaceVersion errors when the infra or image of the workspace is
+// not supported by this cli version
+func CheckWorkspaceVersion(workspace *entity.Workspace) error {
+	if featureflag.DisableSSHProxyVersionCheck() {
+		return nil
+	}
+	err := checkWorkspaceInfraVersionOrErr(workspace)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = checkWorkspaceImageVersionOrErr(workspace)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func checkWorkspaceStatusOrErr(workspace *entity.Workspace) error {
 	if workspace.Status != "RUNNING" {
 		return fmt.Errorf("workspace is not in RUNNING state, status: %s", workspace.Status)
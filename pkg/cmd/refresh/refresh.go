@@ -16,6 +16,7 @@ import (
 type RefreshStore interface {
 	ssh.ConfigUpdaterStore
 	ssh.SSHConfigurerV2Store
+	ssh.SSHConfigTargetStore
 	GetCurrentUser() (*entity.User, error)
 }
 
@@ -1,17 +1,22 @@
 package reset
 
 import (
-	"fmt"
-	"strings"
+	"os"
 	"time"
 
-	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
+	"github.com/brevdev/brev-cli/pkg/cmd/classes"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/cmd/snapshot"
+	"github.com/brevdev/brev-cli/pkg/cmd/wait"
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/workspacemanagerv2"
+	"golang.org/x/term"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/spf13/cobra"
@@ -20,11 +25,17 @@ import (
 var (
 	startLong    = "Reset your machine if it's acting up. This deletes the machine and gets you a fresh one."
 	startExample = `  brev reset <ws_name>
-  brev reset <ws_name> --hard`
+  brev reset <ws_name> --hard
+  brev reset <ws_name> --hard --class 4x16
+  brev reset "api-*" --yes
+  brev reset --selector status=RUNNING,class=4x16`
 )
 
 type ResetStore interface {
 	completions.CompletionStore
+	resolver.ResolverStore
+	snapshot.SnapshotStore
+	classes.ClassesStore
 	ResetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetAllWorkspaces(options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
@@ -39,6 +50,10 @@ type ResetStore interface {
 
 func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetStore ResetStore) *cobra.Command {
 	var hardreset bool
+	var skipSnapshot bool
+	var workspaceClass string
+	bulkOptions := bulk.Options{ConfirmFuzzy: true}
+	var skipConfirm bool
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -47,11 +62,25 @@ func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetS
 		Short:                 "Reset a workspace if it's in a weird state.",
 		Long:                  startLong,
 		Example:               startExample,
-		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginResetStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if hardreset {
-				err := hardResetProcess(args[0], t, loginResetStore)
+			err := bulkOptions.Validate(args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if workspaceClass != "" && !hardreset {
+				return breverrors.NewValidationError("--class can only be used with --hard, use 'brev resize' to change the class of a workspace")
+			}
+			if bulkOptions.IsBulk(args) {
+				if hardreset {
+					return breverrors.NewValidationError("--hard can only be used with a single workspace")
+				}
+				err = resetWorkspaces(args, bulkOptions, skipConfirm, t, loginResetStore)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+			} else if hardreset {
+				err := hardResetProcess(args[0], t, loginResetStore, skipSnapshot, workspaceClass)
 				if err != nil {
 					return breverrors.WrapAndTrace(err)
 				}
@@ -66,18 +95,44 @@ func NewCmdReset(t *terminal.Terminal, loginResetStore ResetStore, noLoginResetS
 		},
 	}
 
+	bulk.AddFlags(cmd, &bulkOptions)
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation when resetting by --all, --selector or pattern")
 	cmd.Flags().BoolVarP(&hardreset, "hard", "", false, "deletes the workspace and creates a fresh version WARNING: this is destructive and workspace state not tracked in git is lost")
+	cmd.Flags().BoolVar(&skipSnapshot, "skip-snapshot", false, "do not snapshot the workspace volume before a hard reset")
+	cmd.Flags().StringVarP(&workspaceClass, "class", "c", "", "workspace class of the recreated workspace with --hard (default is the current class), see 'brev classes'")
+	err := cmd.RegisterFlagCompletionFunc("class", classes.GetClassCompletionHandler(noLoginResetStore))
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(err)
+		t.Errprint(err, "cli err")
+	}
 	return cmd
 }
 
 // hardResetProcess deletes an existing workspace and creates a new one
-func hardResetProcess(workspaceName string, t *terminal.Terminal, resetStore ResetStore) error {
-	t.Vprint(t.Green("Starting hard reset 🤙 " + t.Yellow("This can take a couple of minutes.\n")))
-	workspace, err := getWorkspaceFromNameOrID(workspaceName, resetStore)
+func hardResetProcess(workspaceName string, t *terminal.Terminal, resetStore ResetStore, skipSnapshot bool, workspaceClass string) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, resetStore).WithConfirmFuzzy(true).GetWorkspaceFromNameOrID(workspaceName)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
+	// validate before anything is deleted
+	err = classes.CheckClass(resetStore, workspace.OrganizationID, workspaceClass)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if workspaceClass == "" {
+		workspaceClass = workspace.WorkspaceClassID
+	}
+
+	if !skipSnapshot {
+		err = offerSnapshot(t, resetStore, workspace.Workspace)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	t.Vprint(t.Green("Starting hard reset 🤙 " + t.Yellow("This can take a couple of minutes.\n")))
+
 	deletedWorkspace, err := resetStore.DeleteWorkspace(workspace.ID)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -87,12 +142,12 @@ func hardResetProcess(workspaceName string, t *terminal.Terminal, resetStore Res
 	time.Sleep(10 * time.Second)
 
 	if len(deletedWorkspace.GitRepo) != 0 {
-		err := hardResetCreateWorkspaceFromRepo(t, resetStore, deletedWorkspace.Name, deletedWorkspace.GitRepo)
+		err := hardResetCreateWorkspaceFromRepo(t, resetStore, deletedWorkspace.Name, deletedWorkspace.GitRepo, workspaceClass)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
 	} else {
-		err := hardResetCreateEmptyWorkspace(t, resetStore, deletedWorkspace.Name)
+		err := hardResetCreateEmptyWorkspace(t, resetStore, deletedWorkspace.Name, workspaceClass)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
@@ -100,8 +155,31 @@ func hardResetProcess(workspaceName string, t *terminal.Terminal, resetStore Res
 	return nil
 }
 
+// offerSnapshot saves the project volume before it is destroyed when the
+// workspace runs on this machine, asking first if there is someone to ask
+func offerSnapshot(t *terminal.Terminal, resetStore ResetStore, workspace entity.Workspace) error {
+	if !workspacemanagerv2.HasLocalVolumes(workspace.ID) {
+		return nil
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		answer := terminal.PromptSelectInput(terminal.PromptSelectContent{
+			Label:    "Snapshot the workspace volume before resetting? Restore it later with 'brev snapshot restore'",
+			ErrorMsg: "error",
+			Items:    []string{"yes", "no"},
+		})
+		if answer != "yes" {
+			return nil
+		}
+	}
+	err := snapshot.CreateSnapshot(t, resetStore, workspace, "pre-reset-"+time.Now().Format("20060102-150405"))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 // hardResetCreateWorkspaceFromRepo clone a GIT repository, triggeres from the --hardreset flag
-func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStore, name, repo string) error {
+func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStore, name, repo string, workspaceClass string) error {
 	t.Vprint(t.Green("\nWorkspace is starting. ") + t.Yellow("This can take up to 2 minutes the first time.\n"))
 	var orgID string
 	activeorg, err := resetStore.GetActiveOrganizationOrDefault()
@@ -113,7 +191,7 @@ func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStor
 	}
 	orgID = activeorg.ID
 	clusterID := config.GlobalConfig.GetDefaultClusterID()
-	options := store.NewCreateWorkspacesOptions(clusterID, name).WithGitRepo(repo)
+	options := store.NewCreateWorkspacesOptions(clusterID, name).WithGitRepo(repo).WithWorkspaceClassID(workspaceClass)
 
 	user, err := resetStore.GetCurrentUser()
 	if err != nil {
@@ -127,7 +205,7 @@ func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStor
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, "RUNNING", resetStore, true)
+	err = wait.PollUntil(t, resetStore, w.ID, wait.Running, true)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -138,7 +216,7 @@ func hardResetCreateWorkspaceFromRepo(t *terminal.Terminal, resetStore ResetStor
 }
 
 // hardResetCreateEmptyWorkspace creates a new empty worksapce,  triggered from the --hardreset flag
-func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore, name string) error {
+func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore, name string, workspaceClass string) error {
 	t.Vprint(t.Green("\nWorkspace is starting. ") + t.Yellow("This can take up to 2 minutes the first time.\n"))
 
 	// ensure name
@@ -157,7 +235,7 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 	}
 	orgID = activeorg.ID
 	clusterID := config.GlobalConfig.GetDefaultClusterID()
-	options := store.NewCreateWorkspacesOptions(clusterID, name)
+	options := store.NewCreateWorkspacesOptions(clusterID, name).WithWorkspaceClassID(workspaceClass)
 
 	user, err := resetStore.GetCurrentUser()
 	if err != nil {
@@ -171,7 +249,7 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, "RUNNING", resetStore, true)
+	err = wait.PollUntil(t, resetStore, w.ID, wait.Running, true)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -182,30 +260,6 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 	return nil
 }
 
-func pollUntil(t *terminal.Terminal, wsid string, state string, resetStore ResetStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := resetStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
-
 func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
 	if options.WorkspaceTemplateID == "" {
 		if featureflag.IsAdmin(user.GlobalUserType) {
@@ -225,7 +279,7 @@ func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *e
 }
 
 func resetWorkspace(workspaceName string, t *terminal.Terminal, resetStore ResetStore) error {
-	workspace, err := getWorkspaceFromNameOrID(workspaceName, resetStore)
+	workspace, err := resolver.NewWorkspaceResolver(t, resetStore).WithConfirmFuzzy(true).GetWorkspaceFromNameOrID(workspaceName)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -240,60 +294,23 @@ func resetWorkspace(workspaceName string, t *terminal.Terminal, resetStore Reset
 	return nil
 }
 
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-func getWorkspaceFromNameOrID(nameOrID string, sstore ResetStore) (*entity.WorkspaceWithMeta, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-	if org == nil {
-		return nil, breverrors.NewValidationError("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
+func resetWorkspaces(args []string, bulkOptions bulk.Options, skipConfirm bool, t *terminal.Terminal, resetStore ResetStore) error {
+	workspaces, err := bulk.GetWorkspaces(t, resetStore, args, bulkOptions)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return breverrors.WrapAndTrace(err)
 	}
-
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+	if bulkOptions.IsPattern(args) && !skipConfirm && !bulk.Confirm("Reset", "reset", t, workspaces) {
+		return nil
 	}
-
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+	err = bulk.RunAndSummarize(t, workspaces, bulkOptions.Parallelism, func(workspace entity.Workspace) (string, error) {
+		_, err := resetStore.ResetWorkspace(workspace.ID)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
 		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID))
-	}
-
-	if workspace == nil {
-		return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-	}
-
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
+		return "resetting", nil
+	})
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return breverrors.WrapAndTrace(err)
 	}
-
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+	return nil
 }
@@ -0,0 +1,139 @@
+// Package resize is for changing the class of an existing workspace
+package resize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/classes"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/cmd/wait"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	resizeLong = `Change the workspace class (cpu x memory) of a workspace. A running workspace
+is stopped, resized and started again, anything not on disk is lost.`
+	resizeExample = `
+  brev resize <ws_name> --class 8x32
+  brev resize <ws_name> --class 4x16 --detached
+	`
+)
+
+type ResizeStore interface {
+	completions.CompletionStore
+	resolver.ResolverStore
+	classes.ClassesStore
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	StopWorkspace(workspaceID string) (*entity.Workspace, error)
+	StartWorkspace(workspaceID string) (*entity.Workspace, error)
+	UpdateWorkspace(workspaceID string, options *store.UpdateWorkspaceOptions) (*entity.Workspace, error)
+}
+
+func NewCmdResize(t *terminal.Terminal, loginResizeStore ResizeStore, noLoginResizeStore ResizeStore) *cobra.Command {
+	var workspaceClass string
+	var detached bool
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "resize",
+		DisableFlagsInUseLine: true,
+		Short:                 "Change the class of a workspace",
+		Long:                  resizeLong,
+		Example:               resizeExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginResizeStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunResize(t, loginResizeStore, args[0], workspaceClass, detached)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&workspaceClass, "class", "c", "", "workspace class to resize to, see 'brev classes'")
+	cmd.Flags().BoolVarP(&detached, "detached", "d", false, "do not wait for the workspace to start again")
+	err := cmd.RegisterFlagCompletionFunc("class", classes.GetClassCompletionHandler(noLoginResizeStore))
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(err)
+		t.Errprint(err, "cli err")
+	}
+
+	return cmd
+}
+
+func RunResize(t *terminal.Terminal, resizeStore ResizeStore, workspaceName string, workspaceClass string, detached bool) error {
+	if workspaceClass == "" {
+		return breverrors.NewValidationError("--class is required, see 'brev classes' for the available classes")
+	}
+	workspace, err := resolver.NewWorkspaceResolver(t, resizeStore).WithConfirmFuzzy(true).GetWorkspace(workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = classes.CheckClass(resizeStore, workspace.OrganizationID, workspaceClass)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if workspace.WorkspaceClassID == workspaceClass {
+		t.Vprintf("%s is already %s\n", workspace.Name, workspaceClass)
+		return nil
+	}
+
+	wasRunning := false
+	switch workspace.Status {
+	case entity.WorkspaceRunningStatus:
+		wasRunning = true
+		t.Vprintf("Stopping %s to resize it\n", workspace.Name)
+		_, err = resizeStore.StopWorkspace(workspace.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = wait.PollUntil(t, resizeStore, workspace.ID, wait.Stopped, false)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	case entity.WorkspaceStoppedStatus:
+	default:
+		return breverrors.NewValidationError(fmt.Sprintf("%s is %s, try again once it is running or stopped", workspace.Name, strings.ToLower(workspace.Status)))
+	}
+
+	_, err = resizeStore.UpdateWorkspace(workspace.ID, &store.UpdateWorkspaceOptions{WorkspaceClassID: workspaceClass})
+	if err != nil && wasRunning {
+		// it was only stopped to be resized, so it is started again as it was
+		_, startErr := resizeStore.StartWorkspace(workspace.ID)
+		if startErr != nil {
+			return multierror.Append(breverrors.WrapAndTrace(err), fmt.Errorf("%s was left stopped, start it with 'brev start %s': %w", workspace.Name, workspace.Name, startErr))
+		}
+		return fmt.Errorf("failed to resize %s, it is starting again as %s: %w", workspace.Name, workspace.WorkspaceClassID, err)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("%s resized from %s to %s", workspace.Name, workspace.WorkspaceClassID, workspaceClass))
+
+	if !wasRunning {
+		return nil
+	}
+	_, err = resizeStore.StartWorkspace(workspace.ID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if detached {
+		t.Vprintf(t.Yellow("\nWorkspace %s is starting. Run 'brev ls' to check status\n", workspace.Name))
+		return nil
+	}
+	err = wait.PollUntil(t, resizeStore, workspace.ID, wait.Running, true)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("\nYour workspace is ready!"))
+	return nil
+}
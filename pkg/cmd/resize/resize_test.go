@@ -0,0 +1,136 @@
+package resize
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResizeStore struct {
+	workspace *entity.Workspace
+	calls     []string
+	updateErr error
+	startErr  error
+}
+
+func (m *mockResizeStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o1"}, nil
+}
+
+func (m *mockResizeStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "u1"}, nil
+}
+
+func (m *mockResizeStore) GetOrganizations(_ *store.GetOrganizationsOptions) ([]entity.Organization, error) {
+	return []entity.Organization{{ID: "o1"}}, nil
+}
+
+func (m *mockResizeStore) GetWorkspaces(_ string, _ *store.GetWorkspacesOptions) ([]entity.Workspace, error) {
+	return []entity.Workspace{*m.workspace}, nil
+}
+
+func (m *mockResizeStore) GetWorkspaceByNameOrID(_ string, nameOrID string) ([]entity.Workspace, error) {
+	return resolver.MatchExact(nameOrID, []entity.Workspace{*m.workspace}), nil
+}
+
+func (m *mockResizeStore) GetWorkspaceMetaData(_ string) (*entity.WorkspaceMetaData, error) {
+	return &entity.WorkspaceMetaData{}, nil
+}
+
+func (m *mockResizeStore) GetWorkspaceClasses(_ string) ([]entity.WorkspaceClass, error) {
+	return []entity.WorkspaceClass{{ID: "2x8", CPU: 2, MemoryGB: 8}, {ID: "4x16", CPU: 4, MemoryGB: 16}}, nil
+}
+
+func (m *mockResizeStore) GetWorkspace(_ string) (*entity.Workspace, error) {
+	w := *m.workspace
+	return &w, nil
+}
+
+func (m *mockResizeStore) StopWorkspace(_ string) (*entity.Workspace, error) {
+	m.calls = append(m.calls, "stop")
+	m.workspace.Status = entity.WorkspaceStoppedStatus
+	return m.workspace, nil
+}
+
+func (m *mockResizeStore) StartWorkspace(_ string) (*entity.Workspace, error) {
+	m.calls = append(m.calls, "start")
+	if m.startErr != nil {
+		return nil, m.startErr
+	}
+	m.workspace.Status = entity.WorkspaceRunningStatus
+	return m.workspace, nil
+}
+
+func (m *mockResizeStore) UpdateWorkspace(_ string, options *store.UpdateWorkspaceOptions) (*entity.Workspace, error) {
+	m.calls = append(m.calls, "update "+options.WorkspaceClassID)
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	m.workspace.WorkspaceClassID = options.WorkspaceClassID
+	return m.workspace, nil
+}
+
+func newMockResizeStore(status string) *mockResizeStore {
+	return &mockResizeStore{workspace: &entity.Workspace{
+		ID:               "abcd1234",
+		Name:             "hello-go",
+		OrganizationID:   "o1",
+		WorkspaceClassID: "2x8",
+		Status:           status,
+	}}
+}
+
+func TestResizeRunningWorkspace(t *testing.T) {
+	s := newMockResizeStore(entity.WorkspaceRunningStatus)
+	err := RunResize(terminal.New(), s, "hello-go", "4x16", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stop", "update 4x16", "start"}, s.calls)
+	assert.Equal(t, "4x16", s.workspace.WorkspaceClassID)
+}
+
+func TestResizeStoppedWorkspace(t *testing.T) {
+	s := newMockResizeStore(entity.WorkspaceStoppedStatus)
+	err := RunResize(terminal.New(), s, "hello-go", "4x16", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"update 4x16"}, s.calls)
+}
+
+func TestResizeValidation(t *testing.T) {
+	s := newMockResizeStore(entity.WorkspaceRunningStatus)
+	err := RunResize(terminal.New(), s, "hello-go", "64x512", false)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "2x8, 4x16")
+	}
+
+	err = RunResize(terminal.New(), s, "hello-go", "2x8", false)
+	assert.Nil(t, err)
+
+	s.workspace.Status = entity.WorkspaceStartingStatus
+	err = RunResize(terminal.New(), s, "hello-go", "4x16", false)
+	assert.NotNil(t, err)
+	assert.Empty(t, s.calls)
+}
+
+func TestResizeFailureRestartsWorkspace(t *testing.T) {
+	s := newMockResizeStore(entity.WorkspaceRunningStatus)
+	s.updateErr = errors.New("no capacity")
+	err := RunResize(terminal.New(), s, "hello-go", "4x16", false)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "no capacity")
+		assert.Contains(t, err.Error(), "starting again as 2x8")
+	}
+	assert.Equal(t, []string{"stop", "update 4x16", "start"}, s.calls)
+
+	s = newMockResizeStore(entity.WorkspaceRunningStatus)
+	s.updateErr = errors.New("no capacity")
+	s.startErr = errors.New("quota")
+	err = RunResize(terminal.New(), s, "hello-go", "4x16", false)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "hello-go was left stopped")
+	}
+}
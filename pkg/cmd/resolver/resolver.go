@@ -0,0 +1,263 @@
+// Package resolver finds the workspace a user means when they pass a name or id
+package resolver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+// MinIDSuffixLength keeps short inputs from matching ids by accident
+const MinIDSuffixLength = 4
+
+type ResolverStore interface {
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetCurrentUser() (*entity.User, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+	GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error)
+	GetWorkspaceMetaData(workspaceID string) (*entity.WorkspaceMetaData, error)
+}
+
+type WorkspaceNotFoundError struct {
+	NameOrID string
+}
+
+var _ breverrors.BrevError = WorkspaceNotFoundError{}
+
+func (e WorkspaceNotFoundError) Error() string {
+	return fmt.Sprintf("no workspaces found with name or id %s", e.NameOrID)
+}
+
+func (e WorkspaceNotFoundError) Directive() string {
+	return "run 'brev ls' to see your workspaces"
+}
+
+type AmbiguousWorkspaceError struct {
+	NameOrID   string
+	Candidates []entity.Workspace
+}
+
+var _ breverrors.BrevError = AmbiguousWorkspaceError{}
+
+func (e AmbiguousWorkspaceError) Error() string {
+	lines := []string{fmt.Sprintf("multiple workspaces match %s:", e.NameOrID)}
+	for _, w := range e.Candidates {
+		lines = append(lines, fmt.Sprintf("\t%s\t%s\t%s", w.Name, w.ID, w.Status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e AmbiguousWorkspaceError) Directive() string {
+	return "run the command again with one of the ids above"
+}
+
+// FuzzyMatchError is returned by destructive commands when nameOrID only
+// matched a workspace by an id suffix or name prefix and that was not confirmed
+type FuzzyMatchError struct {
+	NameOrID  string
+	Workspace entity.Workspace
+}
+
+var _ breverrors.BrevError = FuzzyMatchError{}
+
+func (e FuzzyMatchError) Error() string {
+	return fmt.Sprintf("%s is not an exact match for workspace %s (%s)", e.NameOrID, e.Workspace.Name, e.Workspace.ID)
+}
+
+func (e FuzzyMatchError) Directive() string {
+	return "run the command again with the full name or id"
+}
+
+type WorkspaceResolver struct {
+	store        ResolverStore
+	terminal     *terminal.Terminal
+	interactive  bool
+	confirmFuzzy bool
+	selectFunc   func(label string, items []string) string
+}
+
+// NewWorkspaceResolver prompts on ambiguous names only when stdin is a terminal
+func NewWorkspaceResolver(t *terminal.Terminal, resolverStore ResolverStore) *WorkspaceResolver {
+	return &WorkspaceResolver{
+		store:       resolverStore,
+		terminal:    t,
+		interactive: term.IsTerminal(int(os.Stdin.Fd())),
+		selectFunc: func(label string, items []string) string {
+			return terminal.PromptSelectInput(terminal.PromptSelectContent{
+				Label:    label,
+				ErrorMsg: "error",
+				Items:    items,
+			})
+		},
+	}
+}
+
+func (r *WorkspaceResolver) WithInteractive(interactive bool) *WorkspaceResolver {
+	r.interactive = interactive
+	return r
+}
+
+// WithConfirmFuzzy is for destructive commands, a workspace matched by an id
+// suffix or name prefix is only used once confirmed at the prompt
+func (r *WorkspaceResolver) WithConfirmFuzzy(confirm bool) *WorkspaceResolver {
+	r.confirmFuzzy = confirm
+	return r
+}
+
+func (r *WorkspaceResolver) WithSelectFunc(selectFunc func(label string, items []string) string) *WorkspaceResolver {
+	r.selectFunc = selectFunc
+	return r
+}
+
+// GetWorkspaceFromNameOrID resolves against the current user's workspaces in
+// the active org, ids of teammates' workspaces are also accepted
+func (r WorkspaceResolver) GetWorkspaceFromNameOrID(nameOrID string) (*entity.WorkspaceWithMeta, error) {
+	workspace, err := r.GetWorkspace(nameOrID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	workspaceMetaData, err := r.store.GetWorkspaceMetaData(workspace.ID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+}
+
+// GetWorkspace is GetWorkspaceFromNameOrID without the metadata lookup
+func (r WorkspaceResolver) GetWorkspace(nameOrID string) (*entity.Workspace, error) {
+	if strings.TrimSpace(nameOrID) == "" {
+		return nil, breverrors.NewValidationError("workspace name or id can not be empty")
+	}
+	org, err := r.store.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return nil, breverrors.NewValidationError("no orgs exist")
+	}
+	user, err := r.store.GetCurrentUser()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	workspaces, err := r.store.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{UserID: user.ID})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	matches := MatchExact(nameOrID, workspaces)
+	if len(matches) == 0 {
+		// could be the full id of a teammate's workspace, a failed lookup
+		// just means it isn't one so the error is not returned
+		byID, _ := r.store.GetWorkspaceByNameOrID(org.ID, nameOrID)
+		for _, w := range byID {
+			if w.ID == nameOrID {
+				matches = append(matches, w)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		matches = MatchFuzzy(nameOrID, workspaces)
+		if len(matches) == 1 && string(matches[0].GetLocalIdentifier()) != nameOrID {
+			return r.useFuzzyMatch(nameOrID, matches[0])
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, WorkspaceNotFoundError{NameOrID: nameOrID}
+	case 1:
+		return &matches[0], nil
+	default:
+		return r.disambiguate(nameOrID, matches)
+	}
+}
+
+func (r WorkspaceResolver) useFuzzyMatch(nameOrID string, workspace entity.Workspace) (*entity.Workspace, error) {
+	if !r.confirmFuzzy {
+		if r.terminal != nil {
+			r.terminal.Eprintf("using workspace %s (%s)\n", workspace.Name, workspace.ID)
+		}
+		return &workspace, nil
+	}
+	if !r.interactive || r.selectFunc == nil {
+		return nil, FuzzyMatchError{NameOrID: nameOrID, Workspace: workspace}
+	}
+	answer := r.selectFunc(fmt.Sprintf("%s is not an exact match, use %s?", nameOrID, formatCandidate(workspace)), []string{"no", "yes"})
+	if answer != "yes" {
+		return nil, FuzzyMatchError{NameOrID: nameOrID, Workspace: workspace}
+	}
+	return &workspace, nil
+}
+
+func (r WorkspaceResolver) disambiguate(nameOrID string, candidates []entity.Workspace) (*entity.Workspace, error) {
+	if !r.interactive || r.selectFunc == nil {
+		return nil, AmbiguousWorkspaceError{NameOrID: nameOrID, Candidates: candidates}
+	}
+	items := []string{}
+	for _, w := range candidates {
+		items = append(items, formatCandidate(w))
+	}
+	selected := r.selectFunc(fmt.Sprintf("Multiple workspaces match %s, pick one", nameOrID), items)
+	for i, item := range items {
+		if item == selected {
+			return &candidates[i], nil
+		}
+	}
+	return nil, AmbiguousWorkspaceError{NameOrID: nameOrID, Candidates: candidates}
+}
+
+func formatCandidate(w entity.Workspace) string {
+	return fmt.Sprintf("%s (%s) %s", w.Name, w.ID, w.Status)
+}
+
+// MatchExact returns workspaces whose id or name is exactly nameOrID
+func MatchExact(nameOrID string, workspaces []entity.Workspace) []entity.Workspace {
+	return filter(workspaces, func(w entity.Workspace) bool {
+		return w.ID == nameOrID || w.Name == nameOrID
+	})
+}
+
+// MatchFuzzy tries, in order, the ssh alias from GetLocalIdentifier, an id
+// suffix and a name prefix, returning the first non empty set of matches
+func MatchFuzzy(nameOrID string, workspaces []entity.Workspace) []entity.Workspace {
+	if nameOrID == "" {
+		return []entity.Workspace{}
+	}
+	matchers := []func(w entity.Workspace) bool{
+		func(w entity.Workspace) bool {
+			return string(w.GetLocalIdentifier()) == nameOrID
+		},
+		func(w entity.Workspace) bool {
+			return len(nameOrID) >= MinIDSuffixLength && strings.HasSuffix(w.ID, nameOrID)
+		},
+		func(w entity.Workspace) bool {
+			return strings.HasPrefix(w.Name, nameOrID)
+		},
+	}
+	for _, m := range matchers {
+		matches := filter(workspaces, m)
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+	return []entity.Workspace{}
+}
+
+func filter(workspaces []entity.Workspace, keep func(w entity.Workspace) bool) []entity.Workspace {
+	out := []entity.Workspace{}
+	for _, w := range workspaces {
+		if keep(w) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
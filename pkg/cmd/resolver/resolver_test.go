@@ -0,0 +1,147 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResolverStore struct {
+	workspaces []entity.Workspace
+	teammates  []entity.Workspace
+}
+
+func (m mockResolverStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o1"}, nil
+}
+
+func (m mockResolverStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "u1"}, nil
+}
+
+func (m mockResolverStore) GetWorkspaces(_ string, _ *store.GetWorkspacesOptions) ([]entity.Workspace, error) {
+	return m.workspaces, nil
+}
+
+func (m mockResolverStore) GetWorkspaceByNameOrID(_ string, nameOrID string) ([]entity.Workspace, error) {
+	return MatchExact(nameOrID, append(m.workspaces, m.teammates...)), nil
+}
+
+func (m mockResolverStore) GetWorkspaceMetaData(_ string) (*entity.WorkspaceMetaData, error) {
+	return &entity.WorkspaceMetaData{PodName: "pod"}, nil
+}
+
+var testWorkspaces = []entity.Workspace{
+	{ID: "abcd12345", Name: "hello-react", Status: "RUNNING"},
+	{ID: "efgh67890", Name: "hello-go", Status: "STOPPED"},
+	{ID: "ijkl11111", Name: "api", Status: "RUNNING"},
+	{ID: "mnop22222", Name: "api", Status: "RUNNING"},
+}
+
+func newTestResolver() *WorkspaceResolver {
+	s := mockResolverStore{
+		workspaces: testWorkspaces,
+		teammates:  []entity.Workspace{{ID: "qrst33333", Name: "theirs"}},
+	}
+	return NewWorkspaceResolver(nil, s).WithInteractive(false)
+}
+
+func TestResolveExact(t *testing.T) {
+	r := newTestResolver()
+
+	w, err := r.GetWorkspace("hello-go")
+	assert.Nil(t, err)
+	assert.Equal(t, "efgh67890", w.ID)
+
+	w, err = r.GetWorkspace("abcd12345")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-react", w.Name)
+
+	w, err = r.GetWorkspace("qrst33333")
+	assert.Nil(t, err)
+	assert.Equal(t, "theirs", w.Name)
+
+	// teammates' workspaces are only resolved by id
+	_, err = r.GetWorkspace("theirs")
+	assert.IsType(t, WorkspaceNotFoundError{}, err)
+}
+
+func TestResolveFuzzy(t *testing.T) {
+	r := newTestResolver()
+
+	w, err := r.GetWorkspace("hello-react-2345")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd12345", w.ID)
+
+	w, err = r.GetWorkspace("67890")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-go", w.Name)
+
+	w, err = r.GetWorkspace("hello-r")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-react", w.Name)
+
+	_, err = r.GetWorkspace("nope")
+	assert.IsType(t, WorkspaceNotFoundError{}, err)
+
+	_, err = r.GetWorkspace("")
+	assert.Error(t, err)
+	assert.Empty(t, MatchFuzzy("", testWorkspaces))
+}
+
+func TestResolveFuzzyConfirm(t *testing.T) {
+	r := newTestResolver().WithConfirmFuzzy(true)
+
+	// exact names and ssh aliases need no confirmation
+	w, err := r.GetWorkspace("hello-go")
+	assert.Nil(t, err)
+	assert.Equal(t, "efgh67890", w.ID)
+	w, err = r.GetWorkspace("hello-react-2345")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd12345", w.ID)
+
+	_, err = r.GetWorkspace("hello-r")
+	assert.IsType(t, FuzzyMatchError{}, err)
+
+	answer := "no"
+	r = r.WithInteractive(true).WithSelectFunc(func(_ string, _ []string) string {
+		return answer
+	})
+	_, err = r.GetWorkspace("67890")
+	assert.IsType(t, FuzzyMatchError{}, err)
+	answer = "yes"
+	w, err = r.GetWorkspace("67890")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-go", w.Name)
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	r := newTestResolver()
+
+	_, err := r.GetWorkspace("hello")
+	ambiguous, ok := err.(AmbiguousWorkspaceError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Len(t, ambiguous.Candidates, 2)
+
+	_, err = r.GetWorkspace("api")
+	assert.IsType(t, AmbiguousWorkspaceError{}, err)
+
+	r = r.WithInteractive(true).WithSelectFunc(func(_ string, items []string) string {
+		return items[1]
+	})
+	w, err := r.GetWorkspace("api")
+	assert.Nil(t, err)
+	assert.Equal(t, "mnop22222", w.ID)
+}
+
+func TestGetWorkspaceFromNameOrID(t *testing.T) {
+	r := newTestResolver()
+	w, err := r.GetWorkspaceFromNameOrID("hello-go")
+	assert.Nil(t, err)
+	assert.Equal(t, "pod", w.PodName)
+	assert.Equal(t, "efgh67890", w.ID)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/k8s"
+	"github.com/brevdev/brev-cli/pkg/schedule"
 	"github.com/brevdev/brev-cli/pkg/ssh"
 	"github.com/brevdev/brev-cli/pkg/tasks"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -19,7 +20,7 @@ func NewCmdRunTasks(t *terminal.Terminal, store RunTasksStore) *cobra.Command {
 		Use:                   "run-tasks",
 		DisableFlagsInUseLine: true,
 		Short:                 "Run background tasks for brev",
-		Long:                  "Run tasks keeps the ssh config up to date and a background vpn daemon to connect you to your service mesh. Run with -d to run as a detached daemon in the background. To force a refresh to your config use the refresh command.",
+		Long:                  "Run tasks keeps the ssh config up to date, runs workspace schedules and a background vpn daemon to connect you to your service mesh. Run with -d to run as a detached daemon in the background. To force a refresh to your config use the refresh command.",
 		Example:               "brev run-tasks -d",
 		Args:                  cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -38,8 +39,11 @@ func NewCmdRunTasks(t *terminal.Terminal, store RunTasksStore) *cobra.Command {
 type RunTasksStore interface {
 	ssh.ConfigUpdaterStore
 	ssh.SSHConfigurerV2Store
+	ssh.SSHConfigTargetStore
+	ssh.SSHCertificateStore
 	vpn.ServiceMeshStore
 	tasks.RunTaskAsDaemonStore
+	schedule.SchedulerStore
 	GetCurrentUser() (*entity.User, error)
 	GetCurrentUserKeys() (*entity.UserKeys, error)
 }
@@ -84,5 +88,5 @@ func getDefaultTasks(store RunTasksStore) ([]tasks.Task, error) {
 		PrivateKey: privateKey,
 	}
 
-	return []tasks.Task{cu}, nil
+	return []tasks.Task{cu, ssh.NewSSHCertificateRenewer(store), schedule.NewSchedulerTask(store)}, nil
 }
@@ -0,0 +1,290 @@
+// Package schedule is for managing start and stop schedules of workspaces
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/schedule"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	scheduleLong = `Start and stop workspaces on a schedule. Schedules are saved in ~/.brev and
+acted on by the background daemon, make sure 'brev run-tasks -d' is running.`
+	scheduleExample = `
+  brev schedule add <ws_name> --start 08:30 --stop 19:00 --days weekdays --timezone America/Los_Angeles
+  brev schedule add <ws_name> --stop 22:00
+  brev schedule add <ws_name> --start-cron "0 9 * * 1" --stop-cron "0 18 * * 5"
+  brev schedule ls
+  brev schedule rm <ws_name>
+	`
+)
+
+type ScheduleStore interface {
+	completions.CompletionStore
+	resolver.ResolverStore
+	GetSchedules() ([]store.Schedule, error)
+	SetSchedules(schedules []store.Schedule) error
+}
+
+func NewCmdSchedule(t *terminal.Terminal, loginScheduleStore ScheduleStore, noLoginScheduleStore ScheduleStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "schedule",
+		DisableFlagsInUseLine: true,
+		Short:                 "Start and stop workspaces on a schedule",
+		Long:                  scheduleLong,
+		Example:               scheduleExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunList(t, noLoginScheduleStore, cmdoutput.TableFormat)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(NewCmdScheduleAdd(t, loginScheduleStore, noLoginScheduleStore))
+	cmd.AddCommand(NewCmdScheduleLs(t, noLoginScheduleStore))
+	cmd.AddCommand(NewCmdScheduleRm(t, noLoginScheduleStore))
+
+	return cmd
+}
+
+type AddOptions struct {
+	Start     string
+	Stop      string
+	Days      string
+	StartCron string
+	StopCron  string
+	Timezone  string
+}
+
+// ToSchedule builds cron expressions from the time flags, raw cron flags
+// take precedence
+func (o AddOptions) ToSchedule() (*store.Schedule, error) {
+	s := &store.Schedule{Start: o.StartCron, Stop: o.StopCron, Timezone: o.Timezone}
+	var err error
+	if s.Start == "" && o.Start != "" {
+		s.Start, err = schedule.BuildCron(o.Start, o.Days)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+	if s.Stop == "" && o.Stop != "" {
+		s.Stop, err = schedule.BuildCron(o.Stop, o.Days)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+	err = schedule.Validate(*s)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return s, nil
+}
+
+func NewCmdScheduleAdd(t *terminal.Terminal, loginScheduleStore ScheduleStore, noLoginScheduleStore ScheduleStore) *cobra.Command {
+	var opts AddOptions
+
+	cmd := &cobra.Command{
+		Use:                   "add",
+		DisableFlagsInUseLine: true,
+		Short:                 "Add or replace the schedule of a workspace",
+		Example:               scheduleExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginScheduleStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunAdd(t, loginScheduleStore, args[0], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Start, "start", "", "time of day to start the workspace, ex: 08:30")
+	cmd.Flags().StringVar(&opts.Stop, "stop", "", "time of day to stop the workspace, ex: 19:00")
+	cmd.Flags().StringVar(&opts.Days, "days", "daily", "days --start and --stop apply to [daily, weekdays, weekends] or a list like mon,wed,fri")
+	cmd.Flags().StringVar(&opts.StartCron, "start-cron", "", "cron expression to start the workspace, overrides --start")
+	cmd.Flags().StringVar(&opts.StopCron, "stop-cron", "", "cron expression to stop the workspace, overrides --stop")
+	cmd.Flags().StringVar(&opts.Timezone, "timezone", "", "IANA timezone of the schedule, ex: America/Los_Angeles (default is this machine's timezone)")
+
+	return cmd
+}
+
+func RunAdd(t *terminal.Terminal, scheduleStore ScheduleStore, workspaceName string, opts AddOptions) error {
+	s, err := opts.ToSchedule()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspace, err := resolver.NewWorkspaceResolver(t, scheduleStore).GetWorkspace(workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	s.WorkspaceID = workspace.ID
+	s.WorkspaceName = workspace.Name
+
+	schedules, err := scheduleStore.GetSchedules()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	schedules = append(removeSchedule(schedules, workspace.ID), *s)
+	err = scheduleStore.SetSchedules(schedules)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprint(t.Green("Schedule saved for %s", workspace.Name))
+	action, at, err := schedule.NextTransition(*s, time.Now())
+	if err == nil && action != schedule.NoAction {
+		t.Vprintf("next: %s at %s\n", action, at.Format(time.RFC1123))
+	}
+	t.Vprint(t.Yellow("Schedules are run by the background daemon, make sure 'brev run-tasks -d' is running"))
+	return nil
+}
+
+func NewCmdScheduleLs(t *terminal.Terminal, scheduleStore ScheduleStore) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List workspace schedules",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunList(t, scheduleStore, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmdoutput.AddOutputFlag(cmd, &output)
+
+	return cmd
+}
+
+func RunList(t *terminal.Terminal, scheduleStore ScheduleStore, format cmdoutput.Format) error {
+	schedules, err := scheduleStore.GetSchedules()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, format, "ScheduleList", schedules)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(schedules) == 0 {
+		t.Vprint(t.Yellow("No schedules, add one with:\n\tbrev schedule add <ws_name> --start 08:30 --stop 19:00 --days weekdays"))
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"WORKSPACE", "START", "STOP", "TIMEZONE", "NEXT"})
+	now := time.Now()
+	for _, s := range schedules {
+		next := ""
+		action, at, err := schedule.NextTransition(s, now)
+		if err == nil && action != schedule.NoAction {
+			next = fmt.Sprintf("%s %s", action, at.Format("Mon Jan 2 15:04 MST"))
+		}
+		timezone := s.Timezone
+		if timezone == "" {
+			timezone = "local"
+		}
+		ta.AppendRow(table.Row{s.WorkspaceName, s.Start, s.Stop, timezone, next})
+	}
+	ta.Render()
+	return nil
+}
+
+func NewCmdScheduleRm(t *terminal.Terminal, scheduleStore ScheduleStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm",
+		Short: "Remove the schedule of a workspace",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			schedules, err := scheduleStore.GetSchedules()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			names := []string{}
+			for _, s := range schedules {
+				names = append(names, s.WorkspaceName)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunRemove(t, scheduleStore, args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// RunRemove matches against the saved schedules so a schedule can be removed
+// after its workspace has been deleted
+func RunRemove(t *terminal.Terminal, scheduleStore ScheduleStore, nameOrID string) error {
+	schedules, err := scheduleStore.GetSchedules()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	var found *store.Schedule
+	for i, s := range schedules {
+		if s.WorkspaceID == nameOrID || s.WorkspaceName == nameOrID {
+			found = &schedules[i]
+			break
+		}
+	}
+	if found == nil {
+		return breverrors.NewValidationError(fmt.Sprintf("no schedule found for %s", nameOrID))
+	}
+	name := found.WorkspaceName
+	err = scheduleStore.SetSchedules(removeSchedule(schedules, found.WorkspaceID))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("Schedule removed for %s", name))
+	return nil
+}
+
+func removeSchedule(schedules []store.Schedule, workspaceID string) []store.Schedule {
+	out := []store.Schedule{}
+	for _, s := range schedules {
+		if s.WorkspaceID != workspaceID {
+			out = append(out, s)
+		}
+	}
+	return out
+}
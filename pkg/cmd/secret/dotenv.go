@@ -0,0 +1,126 @@
+package secret
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type dotEnvVar struct {
+	Key   string
+	Value string
+}
+
+// parseDotEnv reads KEY=VALUE lines in the order they are in, a key given
+// twice keeps its last value. Lines can start with export, values can be
+// single quoted, or double quoted with escapes, and both can span lines.
+func parseDotEnv(content string) ([]dotEnvVar, error) {
+	vars := []dotEnvVar{}
+	index := map[string]int{}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "export ") {
+			line = strings.TrimLeft(strings.TrimPrefix(line, "export "), " \t")
+		}
+		eq := strings.Index(line, "=")
+		if eq == -1 {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("line %d: expected KEY=VALUE", lineNo))
+		}
+		key := strings.TrimSpace(line[:eq])
+		if !envKeyRegex.MatchString(key) {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("line %d: invalid variable name %q", lineNo, key))
+		}
+		raw := strings.TrimLeft(line[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'") {
+			quote := raw[0]
+			body := raw[1:]
+			for {
+				end := closingQuote(body, quote)
+				if end != -1 {
+					rest := strings.TrimSpace(body[end+1:])
+					if rest != "" && !strings.HasPrefix(rest, "#") {
+						return nil, breverrors.NewValidationError(fmt.Sprintf("line %d: unexpected text after the quoted value of %s", lineNo, key))
+					}
+					value = body[:end]
+					break
+				}
+				i++
+				if i >= len(lines) {
+					return nil, breverrors.NewValidationError(fmt.Sprintf("line %d: the quoted value of %s is not closed", lineNo, key))
+				}
+				body += "\n" + lines[i]
+			}
+			if quote == '"' {
+				value = unescapeDoubleQuoted(value)
+			}
+		} else {
+			value = raw
+			if comment := strings.Index(value, " #"); comment != -1 {
+				value = value[:comment]
+			}
+			if comment := strings.Index(value, "\t#"); comment != -1 {
+				value = value[:comment]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		if j, ok := index[key]; ok {
+			vars[j].Value = value
+			continue
+		}
+		index[key] = len(vars)
+		vars = append(vars, dotEnvVar{Key: key, Value: value})
+	}
+	return vars, nil
+}
+
+// closingQuote is the index of the quote ending s, or -1, a backslash
+// escapes a double quote
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
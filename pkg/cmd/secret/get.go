@@ -0,0 +1,60 @@
+package secret
+
+import (
+	"io"
+	"os"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdGet(secretStore SecretStore, t *terminal.Terminal) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:     "get NAME",
+		Short:   "Show where a secret is put in workspaces, its value is never shown",
+		Example: "  brev secret get SERVER_URL\n  brev secret get AWS_KEY --scope org",
+		Args:    cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hierarchyType, err := parseScope(scope)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			secret, err := findSecret(secretStore, args[0], hierarchyType)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			displaySecret(os.Stdout, *secret)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope of the secret when both an org and a private one have the name")
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func displaySecret(w io.Writer, s store.Secret) {
+	destination := s.Dest.Config.Path
+	if s.Dest.Type == store.EnvVariable {
+		destination = envKey(s)
+	}
+	ta := table.NewWriter()
+	ta.SetOutputMirror(w)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendRows([]table.Row{
+		{"NAME", s.Name},
+		{"ID", s.ID},
+		{"SCOPE", displayScope(s.HierarchyType)},
+		{"TYPE", displayType(s.Dest.Type)},
+		{"DESTINATION", destination},
+	})
+	ta.Render()
+}
@@ -0,0 +1,151 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdImport(secretStore SecretStore, t *terminal.Terminal, yes *bool) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "import FILE|-",
+		Short: "Add the variables of a .env file as secrets",
+		Long:  "Add the variables of a .env file, or of stdin with -, as environment variable secrets, updating the ones that already exist in the scope",
+		Example: `  brev secret import .env
+  brev secret import .env.production --scope org --yes
+  vault kv get -format=json secret/app | jq -r '.data.data | to_entries[] | "\(.key)=\(.value)"' | brev secret import - --scope org --yes`,
+		Args: cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := readDotEnv(args[0], *yes)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			vars, err := parseDotEnv(string(content))
+			if err != nil {
+				return breverrors.WrapAndTrace(err, args[0])
+			}
+			err = importSecrets(secretStore, t, vars, scope, *yes)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope for the variables (org or private)")
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func readDotEnv(path string, yes bool) ([]byte, error) {
+	if path != "-" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return content, nil
+	}
+	if !yes {
+		return nil, breverrors.NewValidationError("--yes required when importing from stdin, stdin can not answer prompts")
+	}
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return content, nil
+}
+
+func importSecrets(secretStore SecretStore, t *terminal.Terminal, vars []dotEnvVar, scope string, yes bool) error {
+	if len(vars) == 0 {
+		return breverrors.NewValidationError("no variables to import")
+	}
+	for _, v := range vars {
+		t.AddSecret(v.Value)
+	}
+	if scope == "" {
+		if yes {
+			return breverrors.NewValidationError("--scope required with --yes")
+		}
+		scope = terminal.PromptSelectInput(terminal.PromptSelectContent{
+			Label:    "Scope: ",
+			ErrorMsg: "error",
+			Items:    []string{"org", "private"},
+		})
+	}
+	hierarchyType, err := parseScope(scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	hierarchyID, err := getHierarchyID(secretStore, hierarchyType)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	existing, err := secretStore.GetSecrets(hierarchyType, hierarchyID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	existingByName := map[string]store.Secret{}
+	for _, s := range existing {
+		existingByName[s.Name] = s
+	}
+
+	toCreate := []string{}
+	toUpdate := []string{}
+	for _, v := range vars {
+		s, ok := existingByName[v.Key]
+		switch {
+		case !ok:
+			toCreate = append(toCreate, v.Key)
+		case s.Dest.Type != store.EnvVariable:
+			return breverrors.NewValidationError(fmt.Sprintf("%s is a %s secret, remove it or rename the variable", v.Key, displayType(s.Dest.Type)))
+		default:
+			toUpdate = append(toUpdate, v.Key)
+		}
+	}
+	if len(toCreate) > 0 {
+		t.Vprintf("create: %s\n", strings.Join(toCreate, ", "))
+	}
+	if len(toUpdate) > 0 {
+		t.Vprintf("update: %s\n", strings.Join(toUpdate, ", "))
+	}
+	if !confirm(yes, fmt.Sprintf("Import %d variables as %s secrets?", len(vars), displayScope(hierarchyType))) {
+		return nil
+	}
+
+	var allErr error
+	created, updated := 0, 0
+	for _, v := range vars {
+		req := makeSecretRequest(v.Key, hierarchyType, hierarchyID, store.EnvVariable, v.Value, "")
+		if s, ok := existingByName[v.Key]; ok {
+			_, err = secretStore.UpdateSecret(s.ID, req)
+			if err == nil {
+				updated++
+			}
+		} else {
+			_, err = secretStore.CreateSecret(req)
+			if err == nil {
+				created++
+			}
+		}
+		if err != nil {
+			allErr = multierror.Append(allErr, breverrors.WrapAndTrace(err, fmt.Sprintf("failed to import %s", v.Key)))
+			t.Vprintf(t.Red("failed to import %s\n", v.Key))
+		}
+	}
+	t.Vprintf(t.Green("%d created, %d updated\n", created, updated) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment.\n"))
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package secret
+
+import (
+	"os"
+	"sort"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdLs(secretStore SecretStore, t *terminal.Terminal) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List secrets, their values are never shown",
+		Example: "  brev secret ls\n  brev secret ls --scope org",
+		Args:    cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hierarchyType, err := parseScope(scope)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			secrets, err := getSecrets(secretStore, hierarchyType)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			displaySecrets(t, secrets)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "only list org or private secrets")
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func displaySecrets(t *terminal.Terminal, secrets []store.Secret) {
+	if len(secrets) == 0 {
+		t.Vprint("no secrets, add one with brev secret")
+		return
+	}
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"NAME", "SCOPE", "TYPE"})
+	for _, s := range secrets {
+		ta.AppendRow(table.Row{s.Name, displayScope(s.HierarchyType), displayType(s.Dest.Type)})
+	}
+	ta.Render()
+}
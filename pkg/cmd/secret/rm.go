@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCmdRm(secretStore SecretStore, t *terminal.Terminal, yes *bool) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:     "rm NAME...",
+		Aliases: []string{"delete"},
+		Short:   "Delete secrets",
+		Example: "  brev secret rm SERVER_URL\n  brev secret rm AWS_KEY DB_PASSWORD --scope org --yes",
+		Args:    cmderrors.TransformToValidationError(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := removeSecrets(secretStore, t, args, scope, *yes)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope of the secrets when both an org and a private one have a name")
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func removeSecrets(secretStore SecretStore, t *terminal.Terminal, names []string, scope string, yes bool) error {
+	hierarchyType, err := parseScope(scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// every name is resolved before anything is deleted
+	secrets := []store.Secret{}
+	for _, name := range names {
+		secret, err := findSecret(secretStore, name, hierarchyType)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		secrets = append(secrets, *secret)
+	}
+	if !confirm(yes, fmt.Sprintf("Delete %s? Workspaces using them lose them", strings.Join(names, ", "))) {
+		return nil
+	}
+
+	var allErr error
+	for _, s := range secrets {
+		err := secretStore.DeleteSecret(s.ID)
+		if err != nil {
+			allErr = multierror.Append(allErr, breverrors.WrapAndTrace(err, fmt.Sprintf("failed to delete %s", s.Name)))
+			t.Vprintf(t.Red("failed to delete %s secret %s\n", displayScope(s.HierarchyType), s.Name))
+			continue
+		}
+		t.Vprintf(t.Green("deleted %s secret %s\n", displayScope(s.HierarchyType), s.Name))
+	}
+	if allErr != nil {
+		return breverrors.WrapAndTrace(allErr)
+	}
+	return nil
+}
@@ -2,8 +2,9 @@
 package secret
 
 import (
-	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -16,6 +17,9 @@ import (
 
 type SecretStore interface {
 	CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error)
+	GetSecrets(hierarchyType store.HierarchyType, hierarchyID string) ([]store.Secret, error)
+	UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error)
+	DeleteSecret(secretID string) error
 	GetCurrentUser() (*entity.User, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 }
@@ -23,9 +27,10 @@ type SecretStore interface {
 func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command {
 	var envtype string
 	var name string
-	var value string
+	var values valueFlags
 	var path string
 	var scope string
+	var yes bool
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"housekeeping": ""},
@@ -35,7 +40,14 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 		Example: `
   brev secret --name naaamme --value vaaalluueee --type [file, variable] --file-path --scope personal
   brev secret --name SERVER_URL --value https://brev.sh --type variable --scope personal
-  brev secret --name AWS_KEY --value ... --type file --file-path --scope personal
+  brev secret --name AWS_KEY --value-from-file ~/.aws/key --type file --file-path /home/brev/.aws/key --scope personal
+  pass show db | brev secret --name DB_PASSWORD --value-stdin --type variable --scope org
+  brev secret ls
+  brev secret get SERVER_URL
+  brev secret update SERVER_URL --value https://api.brev.sh
+  brev secret rotate DB_PASSWORD --generate --yes
+  brev secret rm SERVER_URL
+  brev secret import .env --scope org
 		`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
@@ -48,7 +60,7 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 		// Args:      cobra.MinimumNArgs(0),
 		// ValidArgs: []string{"orgs", "workspaces"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := addSecret(secretStore, t, envtype, name, value, path, scope)
+			err := addSecret(secretStore, t, envtype, name, values, path, scope, yes)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -58,10 +70,25 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 
 	cmd.Flags().StringVarP(&envtype, "type", "t", "", "type of secret (env var or file)")
 	cmd.Flags().StringVarP(&name, "name", "n", "", "name of environment variable or secret file")
-	cmd.Flags().StringVarP(&value, "value", "v", "", "value of environment variable or secret file")
+	addValueFlags(cmd, &values, "value of environment variable or secret file")
 	cmd.Flags().StringVarP(&path, "file-path", "p", "", "file path (if secret file)")
 	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope for env var (org or private)")
+	cmd.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "never prompt, fail when a required flag is missing and skip confirmations")
 
+	registerTypeCompletion(cmd, t)
+	registerScopeCompletion(cmd, t)
+
+	cmd.AddCommand(NewCmdLs(secretStore, t))
+	cmd.AddCommand(NewCmdGet(secretStore, t))
+	cmd.AddCommand(NewCmdUpdate(secretStore, t, &yes))
+	cmd.AddCommand(NewCmdRotate(secretStore, t, &yes))
+	cmd.AddCommand(NewCmdRm(secretStore, t, &yes))
+	cmd.AddCommand(NewCmdImport(secretStore, t, &yes))
+
+	return cmd
+}
+
+func registerTypeCompletion(cmd *cobra.Command, t *terminal.Terminal) {
 	err := cmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"file", "variable"}, cobra.ShellCompDirectiveNoSpace
 	})
@@ -69,20 +96,26 @@ func NewCmdSecret(secretStore SecretStore, t *terminal.Terminal) *cobra.Command
 		breverrors.GetDefaultErrorReporter().ReportError(err)
 		t.Errprint(err, "cli err")
 	}
+}
 
-	err = cmd.RegisterFlagCompletionFunc("scope", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+func registerScopeCompletion(cmd *cobra.Command, t *terminal.Terminal) {
+	err := cmd.RegisterFlagCompletionFunc("scope", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"org", "private"}, cobra.ShellCompDirectiveNoSpace
 	})
 	if err != nil {
 		breverrors.GetDefaultErrorReporter().ReportError(err)
 		t.Errprint(err, "cli err")
 	}
-
-	return cmd
 }
 
-func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, name string, value string, path string, scope string) error { //nolint:funlen, gocyclo // todo simplify me
-	if name == "" || envtype == "" || value == "" || path == "" {
+func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, name string, values valueFlags, path string, scope string, yes bool) error { //nolint:funlen, gocyclo // todo simplify me
+	// stdin holds the value so it can not answer prompts
+	if yes || values.stdin {
+		err := checkRequired(envtype, name, values.given(), path, scope)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	} else if name == "" || envtype == "" || !values.given() || path == "" {
 		t.Vprintf(t.Yellow("\nSome flags omitted, running interactive mode!\n"))
 	}
 
@@ -101,12 +134,14 @@ func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, na
 		})
 	}
 
+	value, err := values.read(os.Stdin, envtype == "file")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	if value == "" {
-		value = terminal.PromptGetInput(terminal.PromptContent{
-			Label:    "Environment variable/secret value: ",
-			ErrorMsg: "error",
-		})
+		value = promptValue("Environment variable/secret value: ")
 	}
+	t.AddSecret(value)
 
 	if path == "" && envtype == "file" {
 		path = terminal.PromptGetInput(terminal.PromptContent{
@@ -124,56 +159,130 @@ func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, na
 		})
 	}
 
+	// the value is left out so it does not end up in the terminal history
 	if envtype == "file" {
-		t.Vprintf("brev secret --name %s --value %s --type %s --file-path %s --scope %s\n", name, value, envtype, path, scope)
+		t.Vprintf("brev secret --name %s --value <value> --type %s --file-path %s --scope %s\n", name, envtype, path, scope)
 	} else {
-		t.Vprintf("brev secret --name %s --value %s --type %s --scope %s\n", name, value, envtype, scope)
+		t.Vprintf("brev secret --name %s --value <value> --type %s --scope %s\n", name, envtype, scope)
+	}
+
+	iScope, err := parseScope(scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if iScope == "" {
+		iScope = store.Org
+	}
+	iType, err := parseType(envtype)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
 	}
 
 	s := t.NewSpinner()
 	s.Suffix = "  encrypting and saving secret var"
 	s.Start()
 
-	iScope := store.Org
-	var hierarchyID string
-	if scope == "user" {
-		iScope = store.User
-		// get user id
+	hierarchyID, err := getHierarchyID(secretStore, iScope)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+
+	b := makeSecretRequest(name, iScope, hierarchyID, iType, value, path)
+	secret, err := secretStore.CreateSecret(b)
+	if err != nil {
+		s.Stop()
+		t.Vprintf(t.Red(err.Error()))
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(secret.Name)
+	s.Suffix = "  environment secret added"
+	s.Stop()
+
+	t.Vprintf(t.Green("\nEnvironment %s added\n", iType) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment."))
+
+	return nil
+}
+
+func checkRequired(envtype string, name string, hasValue bool, path string, scope string) error {
+	missing := []string{}
+	if name == "" {
+		missing = append(missing, "--name")
+	}
+	if envtype == "" {
+		missing = append(missing, "--type")
+	}
+	if !hasValue {
+		missing = append(missing, "--value-stdin, --value-from-file or --value")
+	}
+	if path == "" && envtype == "file" {
+		missing = append(missing, "--file-path")
+	}
+	if scope == "" {
+		missing = append(missing, "--scope")
+	}
+	if len(missing) > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%s required with --yes or --value-stdin", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// parseScope is empty when no scope is given
+func parseScope(scope string) (store.HierarchyType, error) {
+	switch scope {
+	case "":
+		return "", nil
+	case "org":
+		return store.Org, nil
+	case "user", "private", "personal":
+		return store.User, nil
+	default:
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid scope %q, use org or private", scope))
+	}
+}
+
+func parseType(envtype string) (store.DestType, error) {
+	switch envtype {
+	case "variable", "env":
+		return store.EnvVariable, nil
+	case "file":
+		return store.File, nil
+	default:
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid type %q, use variable or file", envtype))
+	}
+}
+
+// NOTE: hieararchyID needs to be the org ID user ID
+func getHierarchyID(secretStore SecretStore, hierarchyType store.HierarchyType) (string, error) {
+	if hierarchyType == store.User {
 		me, err := secretStore.GetCurrentUser()
 		if err != nil {
-			return breverrors.WrapAndTrace(err)
+			return "", breverrors.WrapAndTrace(err)
 		}
-		hierarchyID = me.ID
-	} else {
-		// get org id
-		defaultOrg, err := secretStore.GetActiveOrganizationOrDefault()
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		if defaultOrg == nil {
-			return fmt.Errorf("no orgs exist")
-		}
-		hierarchyID = defaultOrg.ID
+		return me.ID, nil
+	}
+	defaultOrg, err := secretStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if defaultOrg == nil {
+		return "", fmt.Errorf("no orgs exist")
 	}
+	return defaultOrg.ID, nil
+}
 
-	var configDest store.DestConfig
-	iType := store.File
-	if envtype == "variable" {
-		iType = store.EnvVariable
+func makeSecretRequest(name string, hierarchyType store.HierarchyType, hierarchyID string, destType store.DestType, value string, path string) store.CreateSecretRequest {
+	configDest := store.DestConfig{
+		Path: path,
+	}
+	if destType == store.EnvVariable {
 		configDest = store.DestConfig{
 			Name: name,
 		}
-	} else {
-		configDest = store.DestConfig{
-			Path: path,
-		}
 	}
-
-	// NOTE: hieararchyID needs to be the org ID user ID
-
-	b := store.CreateSecretRequest{
+	return store.CreateSecretRequest{
 		Name:          name,
-		HierarchyType: iScope,
+		HierarchyType: hierarchyType,
 		HierarchyID:   hierarchyID,
 		Src: store.SecretReqSrc{
 			Type: store.KeyValue,
@@ -182,23 +291,87 @@ func addSecret(secretStore SecretStore, t *terminal.Terminal, envtype string, na
 			},
 		},
 		Dest: store.SecretReqDest{
-			Type:   iType,
+			Type:   destType,
 			Config: configDest,
 		},
 	}
-	asstring, _ := json.MarshalIndent(b, "", "\t")
-	fmt.Print(string(asstring))
-	secret, err := secretStore.CreateSecret(b)
+}
+
+// getSecrets is the secrets in the scope, or in both scopes when it is empty
+func getSecrets(secretStore SecretStore, hierarchyType store.HierarchyType) ([]store.Secret, error) {
+	hierarchyTypes := []store.HierarchyType{store.Org, store.User}
+	if hierarchyType != "" {
+		hierarchyTypes = []store.HierarchyType{hierarchyType}
+	}
+	secrets := []store.Secret{}
+	for _, ht := range hierarchyTypes {
+		hierarchyID, err := getHierarchyID(secretStore, ht)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		scoped, err := secretStore.GetSecrets(ht, hierarchyID)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		secrets = append(secrets, scoped...)
+	}
+	return secrets, nil
+}
+
+// findSecret is the secret named name, the scope is needed when both scopes
+// have one
+func findSecret(secretStore SecretStore, name string, hierarchyType store.HierarchyType) (*store.Secret, error) {
+	secrets, err := getSecrets(secretStore, hierarchyType)
 	if err != nil {
-		s.Stop()
-		t.Vprintf(t.Red(err.Error()))
-		return breverrors.WrapAndTrace(err)
+		return nil, breverrors.WrapAndTrace(err)
 	}
-	t.Vprintf(secret.Name)
-	s.Suffix = "  environment secret added"
-	s.Stop()
+	matches := []store.Secret{}
+	for _, s := range secrets {
+		if s.Name == name {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no secret named %s, see brev secret ls", name))
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("%s is both an org and a private secret, choose one with --scope", name))
+	}
+}
 
-	t.Vprintf(t.Green("\nEnvironment %s added\n", iType) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment."))
+// confirm asks before changing secrets unless --yes was given
+func confirm(yes bool, label string) bool {
+	if yes {
+		return true
+	}
+	answer := terminal.PromptSelectInput(terminal.PromptSelectContent{
+		Label:    label,
+		ErrorMsg: "error",
+		Items:    []string{"no", "yes"},
+	})
+	return answer == "yes"
+}
 
-	return nil
+func displayScope(hierarchyType store.HierarchyType) string {
+	if hierarchyType == store.User {
+		return "private"
+	}
+	return string(hierarchyType)
+}
+
+// envKey is the variable a variable secret is set as
+func envKey(s store.Secret) string {
+	if s.Dest.Config.Name != "" {
+		return s.Dest.Config.Name
+	}
+	return s.Name
+}
+
+func displayType(destType store.DestType) string {
+	if destType == store.EnvVariable {
+		return "variable"
+	}
+	return string(destType)
 }
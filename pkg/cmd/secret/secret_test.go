@@ -1 +1,196 @@
 package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type mockSecretStore struct {
+	secrets []store.Secret
+	created []store.CreateSecretRequest
+	updated map[string]store.CreateSecretRequest
+	deleted []string
+}
+
+func (m *mockSecretStore) CreateSecret(req store.CreateSecretRequest) (*store.CreateSecretRequest, error) {
+	m.created = append(m.created, req)
+	return &req, nil
+}
+
+func (m *mockSecretStore) GetSecrets(hierarchyType store.HierarchyType, hierarchyID string) ([]store.Secret, error) {
+	secrets := []store.Secret{}
+	for _, s := range m.secrets {
+		if s.HierarchyType == hierarchyType && s.HierarchyID == hierarchyID {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets, nil
+}
+
+func (m *mockSecretStore) UpdateSecret(secretID string, req store.CreateSecretRequest) (*store.Secret, error) {
+	if m.updated == nil {
+		m.updated = map[string]store.CreateSecretRequest{}
+	}
+	m.updated[secretID] = req
+	return &store.Secret{ID: secretID, CreateSecretRequest: req}, nil
+}
+
+func (m *mockSecretStore) DeleteSecret(secretID string) error {
+	m.deleted = append(m.deleted, secretID)
+	return nil
+}
+
+func (m *mockSecretStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "u-1"}, nil
+}
+
+func (m *mockSecretStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o-1"}, nil
+}
+
+func makeSecret(id string, name string, hierarchyType store.HierarchyType, destType store.DestType) store.Secret {
+	hierarchyID := "o-1"
+	if hierarchyType == store.User {
+		hierarchyID = "u-1"
+	}
+	return store.Secret{ID: id, CreateSecretRequest: makeSecretRequest(name, hierarchyType, hierarchyID, destType, "", "/home/brev/"+name)}
+}
+
+func TestParseDotEnv(t *testing.T) {
+	vars, err := parseDotEnv(`# comment
+export SERVER_URL=https://brev.sh # the api
+EMPTY=
+SPACED = padded value
+SINGLE='it has # and \n'
+DOUBLE="line\nnext \"quoted\" \$HOME" # comment
+KEY="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+SERVER_URL=https://api.brev.sh
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, []dotEnvVar{
+		{Key: "SERVER_URL", Value: "https://api.brev.sh"},
+		{Key: "EMPTY", Value: ""},
+		{Key: "SPACED", Value: "padded value"},
+		{Key: "SINGLE", Value: `it has # and \n`},
+		{Key: "DOUBLE", Value: "line\nnext \"quoted\" $HOME"},
+		{Key: "KEY", Value: "-----BEGIN KEY-----\nabc\n-----END KEY-----"},
+	}, vars)
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	for _, content := range []string{
+		"NO_EQUALS",
+		"1BAD=value",
+		`OPEN="never closed`,
+		`TRAILING="value" junk`,
+	} {
+		_, err := parseDotEnv(content)
+		assert.NotNil(t, err, content)
+	}
+}
+
+func TestFindSecret(t *testing.T) {
+	s := &mockSecretStore{secrets: []store.Secret{
+		makeSecret("s-1", "SERVER_URL", store.Org, store.EnvVariable),
+		makeSecret("s-2", "TOKEN", store.Org, store.EnvVariable),
+		makeSecret("s-3", "TOKEN", store.User, store.EnvVariable),
+	}}
+
+	secret, err := findSecret(s, "SERVER_URL", "")
+	assert.Nil(t, err)
+	if assert.NotNil(t, secret) {
+		assert.Equal(t, "s-1", secret.ID)
+	}
+
+	_, err = findSecret(s, "TOKEN", "")
+	assert.NotNil(t, err)
+	secret, err = findSecret(s, "TOKEN", store.User)
+	assert.Nil(t, err)
+	if assert.NotNil(t, secret) {
+		assert.Equal(t, "s-3", secret.ID)
+	}
+
+	_, err = findSecret(s, "MISSING", "")
+	assert.NotNil(t, err)
+}
+
+func TestDisplaySecret(t *testing.T) {
+	s := makeSecret("s-1", "AWS_KEY", store.Org, store.File)
+	s.Src.Config.Value = "hunter2"
+	buf := &bytes.Buffer{}
+	displaySecret(buf, s)
+	assert.Contains(t, buf.String(), "/home/brev/AWS_KEY")
+	assert.Contains(t, buf.String(), "org")
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestCheckRequired(t *testing.T) {
+	assert.Nil(t, checkRequired("variable", "A", true, "", "org"))
+	err := checkRequired("file", "A", false, "", "org")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "--value, --file-path")
+	}
+}
+
+func TestImportSecrets(t *testing.T) {
+	s := &mockSecretStore{secrets: []store.Secret{
+		makeSecret("s-1", "SERVER_URL", store.User, store.EnvVariable),
+		makeSecret("s-2", "SERVER_URL", store.Org, store.EnvVariable),
+	}}
+	vars := []dotEnvVar{{Key: "SERVER_URL", Value: "https://brev.sh"}, {Key: "TOKEN", Value: "abc"}}
+
+	err := importSecrets(s, terminal.New(), vars, "", true)
+	assert.NotNil(t, err)
+
+	err = importSecrets(s, terminal.New(), vars, "private", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []store.CreateSecretRequest{makeSecretRequest("TOKEN", store.User, "u-1", store.EnvVariable, "abc", "")}, s.created)
+	assert.Equal(t, map[string]store.CreateSecretRequest{
+		"s-1": makeSecretRequest("SERVER_URL", store.User, "u-1", store.EnvVariable, "https://brev.sh", ""),
+	}, s.updated)
+}
+
+func TestImportSecretsFileConflict(t *testing.T) {
+	s := &mockSecretStore{secrets: []store.Secret{makeSecret("s-1", "AWS_KEY", store.Org, store.File)}}
+	err := importSecrets(s, terminal.New(), []dotEnvVar{{Key: "AWS_KEY", Value: "abc"}}, "org", true)
+	assert.NotNil(t, err)
+	assert.Empty(t, s.updated)
+}
+
+func TestRotateSecret(t *testing.T) {
+	s := &mockSecretStore{secrets: []store.Secret{makeSecret("s-1", "AWS_KEY", store.Org, store.File)}}
+
+	err := rotateSecret(s, terminal.New(), "AWS_KEY", valueFlags{}, false, "", true)
+	assert.NotNil(t, err)
+
+	err = rotateSecret(s, terminal.New(), "AWS_KEY", valueFlags{}, true, "", true)
+	assert.Nil(t, err)
+	rotated := s.updated["s-1"]
+	assert.Equal(t, store.File, rotated.Dest.Type)
+	assert.Equal(t, "/home/brev/AWS_KEY", rotated.Dest.Config.Path)
+	assert.Len(t, rotated.Src.Config.Value, 43)
+}
+
+func TestRemoveSecrets(t *testing.T) {
+	s := &mockSecretStore{secrets: []store.Secret{
+		makeSecret("s-1", "A", store.Org, store.EnvVariable),
+		makeSecret("s-2", "B", store.User, store.EnvVariable),
+	}}
+
+	// nothing is deleted when a name is unknown
+	err := removeSecrets(s, terminal.New(), []string{"A", "C"}, "", true)
+	assert.NotNil(t, err)
+	assert.Empty(t, s.deleted)
+
+	err = removeSecrets(s, terminal.New(), []string{"A", "B"}, "", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s-1", "s-2"}, s.deleted)
+}
@@ -0,0 +1,191 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+const generatedSecretBytes = 32
+
+func NewCmdUpdate(secretStore SecretStore, t *terminal.Terminal, yes *bool) *cobra.Command {
+	var envtype string
+	var values valueFlags
+	var path string
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "update NAME",
+		Short: "Change the value, type or file path of a secret",
+		Example: `  brev secret update SERVER_URL --value https://api.brev.sh
+  brev secret update AWS_KEY --type file --file-path /home/brev/.aws/key --value-from-file ~/.aws/key --scope org`,
+		Args: cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := updateSecret(secretStore, t, args[0], envtype, values, path, scope, *yes)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&envtype, "type", "t", "", "new type of secret (variable or file)")
+	addValueFlags(cmd, &values, "new value, values are write only so it is always needed")
+	cmd.Flags().StringVarP(&path, "file-path", "p", "", "new file path (if secret file)")
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope of the secret when both an org and a private one have its name")
+	registerTypeCompletion(cmd, t)
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func updateSecret(secretStore SecretStore, t *terminal.Terminal, name string, envtype string, values valueFlags, path string, scope string, yes bool) error {
+	// stdin holds the value so it can not answer prompts
+	yes = yes || values.stdin
+	hierarchyType, err := parseScope(scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	secret, err := findSecret(secretStore, name, hierarchyType)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	destType := secret.Dest.Type
+	if envtype != "" {
+		destType, err = parseType(envtype)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	if path == "" {
+		path = secret.Dest.Config.Path
+	}
+	if destType == store.File && path == "" {
+		if yes {
+			return breverrors.NewValidationError("--file-path required with --yes for a file secret")
+		}
+		path = terminal.PromptGetInput(terminal.PromptContent{
+			Label:    "Path for the file: ",
+			ErrorMsg: "error",
+			Default:  "/home/brev/workspace/secret.txt",
+		})
+	}
+	value, err := getValue(values, yes, destType == store.File)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.AddSecret(value)
+
+	req := makeSecretRequest(secret.Name, secret.HierarchyType, secret.HierarchyID, destType, value, path)
+	_, err = secretStore.UpdateSecret(secret.ID, req)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("%s secret %s updated\n", displayScope(secret.HierarchyType), secret.Name) + t.Yellow("\tNote: It might take up to 2 minutes to load into your environment.\n"))
+	return nil
+}
+
+func NewCmdRotate(secretStore SecretStore, t *terminal.Terminal, yes *bool) *cobra.Command {
+	var values valueFlags
+	var generate bool
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "rotate NAME",
+		Short: "Replace the value of a secret",
+		Long:  "Replace the value of a secret with a given one or a generated one, which is printed once",
+		Example: `  brev secret rotate DB_PASSWORD
+  openssl rand -hex 32 | brev secret rotate DB_PASSWORD --value-stdin --yes
+  brev secret rotate API_TOKEN --generate --yes`,
+		Args: cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := rotateSecret(secretStore, t, args[0], values, generate, scope, *yes)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	addValueFlags(cmd, &values, "new value")
+	cmd.Flags().BoolVarP(&generate, "generate", "g", false, "generate a random value and print it")
+	cmd.Flags().StringVarP(&scope, "scope", "s", "", "scope of the secret when both an org and a private one have its name")
+	registerScopeCompletion(cmd, t)
+
+	return cmd
+}
+
+func rotateSecret(secretStore SecretStore, t *terminal.Terminal, name string, values valueFlags, generate bool, scope string, yes bool) error {
+	if generate && values.given() {
+		return breverrors.NewValidationError("use a value flag or --generate, not both")
+	}
+	if values.stdin && !yes {
+		return breverrors.NewValidationError("--yes required with --value-stdin, stdin can not confirm the rotation")
+	}
+	hierarchyType, err := parseScope(scope)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	secret, err := findSecret(secretStore, name, hierarchyType)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	var value string
+	if generate {
+		value, err = generateValue()
+	} else {
+		value, err = getValue(values, yes, secret.Dest.Type == store.File)
+		t.AddSecret(value)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !confirm(yes, fmt.Sprintf("Rotate %s secret %s? Workspaces using it get the new value", displayScope(secret.HierarchyType), secret.Name)) {
+		return nil
+	}
+
+	req := makeSecretRequest(secret.Name, secret.HierarchyType, secret.HierarchyID, secret.Dest.Type, value, secret.Dest.Config.Path)
+	_, err = secretStore.UpdateSecret(secret.ID, req)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("%s secret %s rotated\n", displayScope(secret.HierarchyType), secret.Name))
+	if generate {
+		t.Vprintf("new value, it is not shown again:\n%s\n", value)
+		t.AddSecret(value)
+	}
+	t.Vprintf(t.Yellow("\tNote: It might take up to 2 minutes to load into your environment.\n"))
+	return nil
+}
+
+// getValue prompts for a value unless one is given
+func getValue(values valueFlags, yes bool, keepNewline bool) (string, error) {
+	value, err := values.read(os.Stdin, keepNewline)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if value != "" {
+		return value, nil
+	}
+	if yes {
+		return "", breverrors.NewValidationError("--value-stdin, --value-from-file or --value required with --yes")
+	}
+	return promptValue("New value: "), nil
+}
+
+func generateValue() (string, error) {
+	b := make([]byte, generatedSecretBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
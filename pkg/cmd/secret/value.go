@@ -0,0 +1,79 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+// valueFlags are the ways to give a secret value, --value is kept in the
+// shell history so the others are preferred
+type valueFlags struct {
+	value    string
+	fromFile string
+	stdin    bool
+}
+
+func addValueFlags(cmd *cobra.Command, v *valueFlags, usage string) {
+	cmd.Flags().StringVarP(&v.value, "value", "v", "", usage+", kept in your shell history so prefer --value-stdin or --value-from-file")
+	cmd.Flags().StringVar(&v.fromFile, "value-from-file", "", "read the value from a file")
+	cmd.Flags().BoolVar(&v.stdin, "value-stdin", false, "read the value from stdin")
+}
+
+func (v valueFlags) given() bool {
+	return v.value != "" || v.fromFile != "" || v.stdin
+}
+
+// read is the value of the flag given, or "" when none is. A trailing newline
+// is dropped unless keepNewline, for the contents of a file secret.
+func (v valueFlags) read(stdin io.Reader, keepNewline bool) (string, error) {
+	given := 0
+	for _, ok := range []bool{v.value != "", v.fromFile != "", v.stdin} {
+		if ok {
+			given++
+		}
+	}
+	if given > 1 {
+		return "", breverrors.NewValidationError("use only one of --value, --value-from-file and --value-stdin")
+	}
+
+	var data []byte
+	var err error
+	source := ""
+	switch {
+	case v.fromFile != "":
+		source = v.fromFile
+		data, err = os.ReadFile(v.fromFile)
+	case v.stdin:
+		source = "stdin"
+		data, err = io.ReadAll(stdin)
+	default:
+		return v.value, nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	value := string(data)
+	if !keepNewline {
+		value = strings.TrimSuffix(value, "\n")
+		value = strings.TrimSuffix(value, "\r")
+	}
+	if value == "" {
+		return "", breverrors.NewValidationError(fmt.Sprintf("no value in %s", source))
+	}
+	return value, nil
+}
+
+func promptValue(label string) string {
+	return terminal.PromptGetInput(terminal.PromptContent{
+		Label:    label,
+		ErrorMsg: "error",
+		Mask:     '*',
+	})
+}
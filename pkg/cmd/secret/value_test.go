@@ -0,0 +1,37 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueFlagsRead(t *testing.T) {
+	value, err := valueFlags{}.read(strings.NewReader("unused"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "", value)
+
+	value, err = valueFlags{value: "v"}.read(nil, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "v", value)
+
+	value, err = valueFlags{stdin: true}.read(strings.NewReader("token\n"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", value)
+
+	path := filepath.Join(t.TempDir(), "key")
+	assert.Nil(t, os.WriteFile(path, []byte("line1\nline2\n"), 0o600))
+	value, err = valueFlags{fromFile: path}.read(nil, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "line1\nline2\n", value)
+
+	_, err = valueFlags{stdin: true}.read(strings.NewReader("\n"), false)
+	assert.NotNil(t, err)
+	_, err = valueFlags{value: "v", stdin: true}.read(strings.NewReader("token"), false)
+	assert.NotNil(t, err)
+	_, err = valueFlags{fromFile: filepath.Join(t.TempDir(), "missing")}.read(nil, false)
+	assert.NotNil(t, err)
+}
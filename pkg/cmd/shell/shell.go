@@ -4,26 +4,56 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/proxy"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/huproxyclient"
+	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/sshclient"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"golang.org/x/term"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	openLong    = "[command in beta] This will shell in to your workspace"
-	openExample = "brev shell workspace_id_or_name\nbrev shell my-app\nbrev open h9fp5vxwe"
+	openLong = `[command in beta] This will shell in to your workspace, or run a command in it when one is given after --
+
+The built in ssh client applies SetEnv, SendEnv and RequestTTY from
+~/.brev/ssh_overrides.yaml, other options only apply with --system-ssh.`
+	openExample = `brev shell workspace_id_or_name
+brev shell my-app
+brev open h9fp5vxwe
+brev shell my-app -- make test
+brev shell my-app -e GITHUB_TOKEN -- ./deploy.sh
+brev shell my-app -t -- htop`
 )
 
 type ShellStore interface {
-	GetActiveOrganizationOrDefault() (*entity.Organization, error)
-	GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error)
+	resolver.ResolverStore
+	huproxyclient.HubProxyStore
+	sshclient.ClientStore
+	GetCurrentUserKeys() (*entity.UserKeys, error)
+	WritePrivateKey(pem string) error
+	GetSSHOverrides() (string, error)
+}
+
+type shellOptions struct {
+	command   []string
+	env       []string
+	tty       bool
+	systemSSH bool
 }
 
-func NewCmdShell(_ *terminal.Terminal, store ShellStore) *cobra.Command {
+func NewCmdShell(t *terminal.Terminal, store ShellStore) *cobra.Command {
+	opts := shellOptions{}
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"ssh": ""},
 		Use:                   "shell",
@@ -31,48 +61,240 @@ func NewCmdShell(_ *terminal.Terminal, store ShellStore) *cobra.Command {
 		Short:                 "[beta] open a shell in your workspace",
 		Long:                  openLong,
 		Example:               openExample,
-		Args:                  cmderrors.TransformToValidationError(cmderrors.TransformToValidationError(cobra.ExactArgs(1))),
+		Args:                  cmderrors.TransformToValidationError(shellArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := runShellCommand(store, args[0])
+			opts.command = args[1:]
+			err := runShellCommand(t, store, args[0], opts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().StringArrayVarP(&opts.env, "env", "e", []string{}, "set an environment variable in the workspace, KEY=VALUE or KEY to send its local value, can be repeated")
+	cmd.Flags().BoolVarP(&opts.tty, "tty", "t", false, "allocate a terminal for the command, a shell always gets one")
+	cmd.Flags().BoolVar(&opts.systemSSH, "system-ssh", false, "connect with the ssh binary and your ssh config instead of brev's built in client")
 
 	return cmd
 }
 
-func runShellCommand(store ShellStore, workspaceNameOrID string) error {
-	org, err := store.GetActiveOrganizationOrDefault()
+// shellArgs is the workspace, then the command after --
+func shellArgs(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if dash == -1 {
+		return cobra.ExactArgs(1)(cmd, args) //nolint:wrapcheck // made a validation error
+	}
+	if dash != 1 {
+		return fmt.Errorf("give the workspace before --, ex: brev shell my-app -- ls")
+	}
+	if len(args) == 1 {
+		return fmt.Errorf("give a command after --, ex: brev shell my-app -- ls")
+	}
+	return nil
+}
+
+func runShellCommand(t *terminal.Terminal, store ShellStore, workspaceNameOrID string, opts shellOptions) error {
+	env, err := resolveEnv(opts.env)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspace, err := resolver.NewWorkspaceResolver(t, store).GetWorkspace(workspaceNameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if workspace.Status != entity.WorkspaceRunningStatus {
+		return breverrors.NewValidationError(fmt.Sprintf("workspace %s is %s, start it with brev start %s", workspace.Name, strings.ToLower(workspace.Status), workspace.Name))
+	}
+	tty := opts.tty || (len(opts.command) == 0 && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())))
+
+	if opts.systemSSH {
+		sshName := string(workspace.GetLocalIdentifier())
+		err = runSSH(sshName, opts.command, env, tty)
+	} else {
+		overrides := getEmbeddedSSHOverrides(t, store, *workspace)
+		if !opts.tty && overrides.tty != nil {
+			tty = *overrides.tty
+		}
+		// --env wins over SetEnv since it is sent last
+		overrides.env = append(overrides.env, env...)
+		overrides.forwardEnv = append(overrides.forwardEnv, getForwardedEnv()...)
+		err = runEmbeddedSSH(store, workspace, opts.command, overrides.env, overrides.forwardEnv, tty)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// resolveEnv takes the local value of variables given without one
+func resolveEnv(env []string) ([]string, error) {
+	resolved := []string{}
+	for _, kv := range env {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if key == "" {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid --env %q, use KEY=VALUE or KEY", kv))
+		}
+		if !strings.Contains(kv, "=") {
+			value, ok := os.LookupEnv(key)
+			if !ok {
+				return nil, breverrors.NewValidationError(fmt.Sprintf("--env %s is not set locally, use %s=VALUE", key, key))
+			}
+			kv = key + "=" + value
+		}
+		resolved = append(resolved, kv)
+	}
+	return resolved, nil
+}
+
+// getForwardedEnv is the locale, which ssh sends by default too
+func getForwardedEnv() []string {
+	forwarded := []string{}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "LANG=") || strings.HasPrefix(kv, "LC_") {
+			forwarded = append(forwarded, kv)
+		}
+	}
+	return forwarded
+}
+
+// embeddedSSHOverrides are the options of ssh_overrides.yaml the built in
+// client can apply
+type embeddedSSHOverrides struct {
+	env        []string
+	forwardEnv []string
+	tty        *bool
+}
+
+// getEmbeddedSSHOverrides reads the overrides of the workspace like the ssh
+// config does and warns about the options that are left out
+func getEmbeddedSSHOverrides(t *terminal.Terminal, store ShellStore, workspace entity.Workspace) embeddedSSHOverrides {
+	result := embeddedSSHOverrides{}
+	data, err := store.GetSSHOverrides()
+	if err != nil {
+		t.Eprint(t.Yellow(fmt.Sprintf("could not read ~/.brev/ssh_overrides.yaml: %v", err)))
+		return result
+	}
+	overrides, err := ssh.ParseSSHOverrides(data)
+	if err != nil {
+		t.Eprint(t.Yellow(fmt.Sprintf("ignoring invalid ~/.brev/ssh_overrides.yaml: %v", err)))
+		return result
+	}
+	options := overrides.ForWorkspace(workspace).Options
+	keys := []string{}
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ignored := []string{}
+	for _, key := range keys {
+		values := options[key]
+		switch strings.ToLower(key) {
+		case "setenv":
+			for _, v := range values {
+				result.env = append(result.env, strings.Fields(v)...)
+			}
+		case "sendenv":
+			for _, v := range values {
+				result.forwardEnv = append(result.forwardEnv, matchLocalEnv(strings.Fields(v))...)
+			}
+		case "requesttty":
+			switch strings.ToLower(values[len(values)-1]) {
+			case "yes", "force":
+				tty := true
+				result.tty = &tty
+			case "no":
+				tty := false
+				result.tty = &tty
+			}
+		default:
+			ignored = append(ignored, key)
+		}
+	}
+	if len(ignored) > 0 {
+		t.Eprint(t.Yellow(fmt.Sprintf("the built in ssh client ignores %s from ~/.brev/ssh_overrides.yaml, use --system-ssh to apply them", strings.Join(ignored, ", "))))
+	}
+	return result
+}
+
+// matchLocalEnv is the local variables matching the SendEnv patterns
+func matchLocalEnv(patterns []string) []string {
+	matched := []string{}
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, key); ok {
+				matched = append(matched, kv)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// runEmbeddedSSH connects through brev proxy like the ssh config does, but
+// in process
+func runEmbeddedSSH(store ShellStore, workspace *entity.Workspace, command []string, env []string, forwardEnv []string, tty bool) error {
+	keys, err := store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = store.WritePrivateKey(keys.PrivateKey)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	workspaces, err := store.GetWorkspaceByNameOrID(org.ID, workspaceNameOrID)
+	signers, err := sshclient.GetSigners(store, *workspace)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if len(workspaces) == 0 {
-		return breverrors.NewValidationError(fmt.Sprintf("workspace with id/name %s not found", workspaceNameOrID))
+
+	conn, err := huproxyclient.Dial(proxy.MakeProxyURL(workspace), store)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
 	}
-	if len(workspaces) > 1 {
-		return breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with id/name %s", workspaceNameOrID))
+	client, err := sshclient.NewClient(conn, workspace.ID, signers, sshclient.KnownHostsCallback(store))
+	if err != nil {
+		_ = conn.Close()
+		return breverrors.WrapAndTrace(err)
 	}
-	sshName := string(workspaces[0].GetLocalIdentifier())
-	err = runSSH(sshName)
+	defer client.Close() //nolint:errcheck // session is done
+
+	err = sshclient.Run(client, sshclient.SessionOptions{
+		Command:    strings.Join(command, " "),
+		TTY:        tty,
+		Env:        env,
+		ForwardEnv: forwardEnv,
+		Stdin:      os.Stdin,
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func runSSH(sshAlias string) error {
-	sshCmd := exec.Command("ssh", sshAlias)
+func runSSH(sshAlias string, command []string, env []string, tty bool) error {
+	sshArgs := []string{}
+	if tty {
+		sshArgs = append(sshArgs, "-t")
+	}
+	for _, kv := range env {
+		sshArgs = append(sshArgs, "-o", "SetEnv="+kv)
+	}
+	sshArgs = append(sshArgs, sshAlias)
+	if len(command) > 0 {
+		sshArgs = append(sshArgs, "--")
+		sshArgs = append(sshArgs, command...)
+	}
+	sshCmd := exec.Command("ssh", sshArgs...) //nolint:gosec // the host is from the brev ssh config
 	sshCmd.Stderr = os.Stderr
 	sshCmd.Stdout = os.Stdout
 	sshCmd.Stdin = os.Stdin
 	err := sshCmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return breverrors.ExitCodeError{Code: exitErr.ExitCode()}
+	}
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
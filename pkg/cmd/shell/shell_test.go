@@ -1 +1,79 @@
 package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEnv(t *testing.T) {
+	err := os.Setenv("BREV_SHELL_TEST", "local")
+	assert.Nil(t, err)
+	defer os.Unsetenv("BREV_SHELL_TEST") //nolint:errcheck // test
+
+	env, err := resolveEnv([]string{"A=1", "B=", "BREV_SHELL_TEST"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A=1", "B=", "BREV_SHELL_TEST=local"}, env)
+
+	_, err = resolveEnv([]string{"BREV_SHELL_TEST_UNSET"})
+	assert.NotNil(t, err)
+	_, err = resolveEnv([]string{"=1"})
+	assert.NotNil(t, err)
+}
+
+func TestShellArgs(t *testing.T) {
+	parse := func(args ...string) error {
+		cmd := &cobra.Command{Args: shellArgs, RunE: func(*cobra.Command, []string) error { return nil }}
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+	assert.Nil(t, parse("my-app"))
+	assert.Nil(t, parse("my-app", "--", "ls", "-la"))
+	assert.NotNil(t, parse("my-app", "other"))
+	assert.NotNil(t, parse("my-app", "other", "--", "ls"))
+	assert.NotNil(t, parse("my-app", "--"))
+	assert.NotNil(t, parse())
+}
+
+type overridesStore struct {
+	ShellStore
+	overrides string
+}
+
+func (s overridesStore) GetSSHOverrides() (string, error) {
+	return s.overrides, nil
+}
+
+func TestGetEmbeddedSSHOverrides(t *testing.T) {
+	err := os.Setenv("BREV_SHELL_TEST_SEND", "sent")
+	assert.Nil(t, err)
+	defer os.Unsetenv("BREV_SHELL_TEST_SEND") //nolint:errcheck // test
+
+	store := overridesStore{overrides: `defaults:
+  SetEnv: A=1 B=2
+  ServerAliveInterval: 60
+workspaces:
+  my-app:
+    options:
+      SendEnv: BREV_SHELL_TEST_S*
+      RequestTTY: force
+`}
+	o := getEmbeddedSSHOverrides(terminal.New(), store, entity.Workspace{Name: "my-app", ID: "abcd1234"})
+	assert.Equal(t, []string{"A=1", "B=2"}, o.env)
+	assert.Equal(t, []string{"BREV_SHELL_TEST_SEND=sent"}, o.forwardEnv)
+	if assert.NotNil(t, o.tty) {
+		assert.True(t, *o.tty)
+	}
+
+	o = getEmbeddedSSHOverrides(terminal.New(), store, entity.Workspace{Name: "other", ID: "efgh5678"})
+	assert.Equal(t, []string{"A=1", "B=2"}, o.env)
+	assert.Empty(t, o.forwardEnv)
+	assert.Nil(t, o.tty)
+
+	o = getEmbeddedSSHOverrides(terminal.New(), overridesStore{overrides: "defaults: [nope"}, entity.Workspace{Name: "my-app"})
+	assert.Equal(t, embeddedSSHOverrides{}, o)
+}
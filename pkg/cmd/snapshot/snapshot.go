@@ -0,0 +1,373 @@
+// Package snapshot is for saving and restoring the project volume of workspaces run on this machine
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/workspacemanagerv2"
+)
+
+const DefaultKeep = 3
+
+var (
+	snapshotLong = `Save and restore the project volume (/home/brev/workspace) of workspaces whose
+containers run on this machine. Snapshots are stored in ~/.brev/snapshots and
+volumes that did not change between snapshots are only stored once.`
+	snapshotExample = `
+  brev snapshot create <ws_name>
+  brev snapshot create <ws_name> --name before-upgrade
+  brev snapshot ls <ws_name>
+  brev snapshot restore <ws_name> before-upgrade
+  brev snapshot prune <ws_name> --keep 3 --yes
+	`
+)
+
+type SnapshotStore interface {
+	completions.CompletionStore
+	resolver.ResolverStore
+	UserHomeDir() (string, error)
+}
+
+func NewCmdSnapshot(t *terminal.Terminal, loginSnapshotStore SnapshotStore, noLoginSnapshotStore SnapshotStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "snapshot",
+		DisableFlagsInUseLine: true,
+		Short:                 "Snapshot and restore workspace volumes",
+		Long:                  snapshotLong,
+		Example:               snapshotExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunList(t, loginSnapshotStore, "", cmdoutput.TableFormat)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(NewCmdSnapshotCreate(t, loginSnapshotStore, noLoginSnapshotStore))
+	cmd.AddCommand(NewCmdSnapshotLs(t, loginSnapshotStore, noLoginSnapshotStore))
+	cmd.AddCommand(NewCmdSnapshotRestore(t, loginSnapshotStore, noLoginSnapshotStore))
+	cmd.AddCommand(NewCmdSnapshotPrune(t, loginSnapshotStore, noLoginSnapshotStore))
+
+	return cmd
+}
+
+func NewCmdSnapshotCreate(t *terminal.Terminal, loginSnapshotStore SnapshotStore, noLoginSnapshotStore SnapshotStore) *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:               "create",
+		Short:             "Snapshot the project volume of a workspace",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginSnapshotStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspace, err := resolver.NewWorkspaceResolver(t, loginSnapshotStore).GetWorkspace(args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = CreateSnapshot(t, loginSnapshotStore, *workspace, name)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "name of the snapshot (default is the current time)")
+
+	return cmd
+}
+
+// NewLocalContainerWorkspace is just enough of a ContainerWorkspace to snapshot
+// and restore the project volume of a workspace on this machine
+func NewLocalContainerWorkspace(snapshotStore SnapshotStore, workspaceID string) (*workspacemanagerv2.ContainerWorkspace, error) {
+	if !workspacemanagerv2.HasLocalVolumes(workspaceID) {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("workspace %s does not have volumes on this machine", workspaceID))
+	}
+	ss, err := getSnapshotStore(snapshotStore)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return workspacemanagerv2.NewContainerWorkspace(
+		workspacemanagerv2.DockerContainerManager{},
+		workspaceID,
+		"",
+		[]workspacemanagerv2.Volume{workspacemanagerv2.NewProjectVolume(workspaceID)},
+	).WithSnapshotStore(ss), nil
+}
+
+func getSnapshotStore(snapshotStore SnapshotStore) (*workspacemanagerv2.SnapshotStore, error) {
+	home, err := snapshotStore.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	root, err := files.GetSnapshotsPath(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return workspacemanagerv2.NewSnapshotStore(root), nil
+}
+
+func CreateSnapshot(t *terminal.Terminal, snapshotStore SnapshotStore, workspace entity.Workspace, name string) error {
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	containerWorkspace, err := NewLocalContainerWorkspace(snapshotStore, workspace.ID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	s := t.NewSpinner()
+	s.Suffix = " snapshotting " + workspace.Name
+	s.Start()
+	snapshot, err := containerWorkspace.Snapshot(context.Background(), name)
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("Snapshot %s of %s saved (%s)", snapshot.Name, workspace.Name, formatSize(snapshot.Size())))
+	return nil
+}
+
+func NewCmdSnapshotLs(t *terminal.Terminal, loginSnapshotStore SnapshotStore, noLoginSnapshotStore SnapshotStore) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:               "ls",
+		Short:             "List snapshots, of all workspaces or only one",
+		Args:              cmderrors.TransformToValidationError(cobra.MaximumNArgs(1)),
+		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginSnapshotStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			workspaceID := ""
+			if len(args) == 1 {
+				workspace, err := resolver.NewWorkspaceResolver(t, loginSnapshotStore).GetWorkspace(args[0])
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				workspaceID = workspace.ID
+			}
+			err = RunList(t, loginSnapshotStore, workspaceID, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmdoutput.AddOutputFlag(cmd, &output)
+
+	return cmd
+}
+
+func RunList(t *terminal.Terminal, snapshotStore SnapshotStore, workspaceID string, format cmdoutput.Format) error {
+	ss, err := getSnapshotStore(snapshotStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	snapshots, err := ss.ListSnapshots(workspaceID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, format, "SnapshotList", snapshots)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(snapshots) == 0 {
+		t.Vprint(t.Yellow("No snapshots, create one with:\n\tbrev snapshot create <ws_name>"))
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"NAME", "WORKSPACE ID", "CREATED", "SIZE"})
+	for _, s := range snapshots {
+		ta.AppendRow(table.Row{s.Name, s.WorkspaceID, s.CreatedAt.Local().Format("2006-01-02 15:04"), formatSize(s.Size())})
+	}
+	ta.Render()
+	return nil
+}
+
+func NewCmdSnapshotRestore(t *terminal.Terminal, loginSnapshotStore SnapshotStore, noLoginSnapshotStore SnapshotStore) *cobra.Command {
+	var skipConfirm bool
+
+	cmd := &cobra.Command{
+		Use:               "restore",
+		Short:             "Restore the project volume of a workspace from a snapshot",
+		Long:              "Restore the project volume of a workspace from a snapshot. The workspace is stopped while restoring and anything not in the snapshot is lost.",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginSnapshotStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunRestore(t, loginSnapshotStore, args[0], args[1], skipConfirm)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation before replacing the volume")
+
+	return cmd
+}
+
+func RunRestore(t *terminal.Terminal, snapshotStore SnapshotStore, workspaceName string, name string, skipConfirm bool) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, snapshotStore).WithConfirmFuzzy(true).GetWorkspace(workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	containerWorkspace, err := NewLocalContainerWorkspace(snapshotStore, workspace.ID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// a missing snapshot is reported before asking
+	ss, err := getSnapshotStore(snapshotStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = ss.GetSnapshot(workspace.ID, name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	confirmed, err := confirm(skipConfirm, fmt.Sprintf("Replace the project volume of %s (%s) with snapshot %s? Changes since the snapshot are lost", workspace.Name, workspace.ID, name))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	s := t.NewSpinner()
+	s.Suffix = " restoring " + workspace.Name
+	s.Start()
+	err = containerWorkspace.Restore(context.Background(), name)
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprint(t.Green("Restored %s from snapshot %s", workspace.Name, name))
+	return nil
+}
+
+func NewCmdSnapshotPrune(t *terminal.Terminal, loginSnapshotStore SnapshotStore, noLoginSnapshotStore SnapshotStore) *cobra.Command {
+	var keep int
+	var skipConfirm bool
+
+	cmd := &cobra.Command{
+		Use:               "prune",
+		Short:             "Delete all but the newest snapshots of a workspace",
+		Args:              cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginSnapshotStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunPrune(t, loginSnapshotStore, args[0], keep, skipConfirm)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&keep, "keep", DefaultKeep, "number of newest snapshots to keep")
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation before deleting snapshots")
+
+	return cmd
+}
+
+func RunPrune(t *terminal.Terminal, snapshotStore SnapshotStore, workspaceName string, keep int, skipConfirm bool) error {
+	if keep < 0 {
+		return breverrors.NewValidationError("--keep can not be negative")
+	}
+	workspace, err := resolver.NewWorkspaceResolver(t, snapshotStore).WithConfirmFuzzy(true).GetWorkspace(workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	ss, err := getSnapshotStore(snapshotStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// snapshots are listed newest first, the same order Prune keeps them in
+	snapshots, err := ss.ListSnapshots(workspace.ID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(snapshots) <= keep {
+		t.Vprintf("%s has %d snapshots, nothing to prune\n", workspace.Name, len(snapshots))
+		return nil
+	}
+	t.Vprint(t.Yellow(fmt.Sprintf("The following snapshots of %s (%s) will be deleted:", workspace.Name, workspace.ID)))
+	for _, s := range snapshots[keep:] {
+		t.Vprintf("\t%s\n", s.Name)
+	}
+	confirmed, err := confirm(skipConfirm, fmt.Sprintf("Delete %d snapshots?", len(snapshots)-keep))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	pruned, err := ss.Prune(workspace.ID, keep)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, p := range pruned {
+		t.Vprintf("deleted %s\n", p.Name)
+	}
+	t.Vprint(t.Green("Pruned %d snapshots of %s", len(pruned), workspace.Name))
+	return nil
+}
+
+// confirm asks before a destructive change, without a terminal to ask on
+// --yes is required
+func confirm(skipConfirm bool, label string) (bool, error) {
+	if skipConfirm {
+		return true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, breverrors.NewValidationError("not a terminal, pass --yes to confirm")
+	}
+	answer := terminal.PromptSelectInput(terminal.PromptSelectContent{
+		Label:    label,
+		ErrorMsg: "error",
+		Items:    []string{"no", "yes"},
+	})
+	return answer == "yes", nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -2,35 +2,30 @@ package sshall
 
 import (
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/k8s"
-	"github.com/brevdev/brev-cli/pkg/portforward"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/ssh"
-	"k8s.io/apimachinery/pkg/util/runtime"
 )
 
-type (
-	connectionMap map[entity.WorkspaceWithMeta]chan struct{}
-	retrymap      map[entity.WorkspaceWithMeta]int
-)
+const defaultRefreshInterval = 30 * time.Second
+
+// WorkspaceLister is the running workspaces, SSHAll polls it to open and close
+// tunnels as workspaces start and stop
+type WorkspaceLister func() ([]entity.WorkspaceWithMeta, error)
 
 type SSHAll struct {
-	workspaces                 []entity.WorkspaceWithMeta
-	workspaceGroupClientMapper k8s.WorkspaceGroupClientMapper
-	sshResolver                SSHResolver
-	workspaceConnections       connectionMap
-	workspaceConnectionsMutex  *sync.RWMutex
-	retries                    retrymap
+	workspaces      []entity.WorkspaceWithMeta
+	supervisor      *Supervisor
+	listWorkspaces  WorkspaceLister
+	refreshInterval time.Duration
+	statusStore     StatusStore
 }
 
 type SSHResolver interface {
@@ -44,97 +39,26 @@ func NewSSHAll(
 	sshResolver SSHResolver,
 ) *SSHAll {
 	return &SSHAll{
-		workspaces:                 workspaces,
-		workspaceGroupClientMapper: workspaceGroupClientMapper,
-		sshResolver:                sshResolver,
-		workspaceConnections:       make(connectionMap),
-		workspaceConnectionsMutex:  &sync.RWMutex{},
-		retries:                    make(retrymap),
+		workspaces:      workspaces,
+		supervisor:      NewSupervisor(PortForwardTunneler{WorkspaceGroupClientMapper: workspaceGroupClientMapper}, sshResolver),
+		refreshInterval: defaultRefreshInterval,
 	}
 }
 
-func (s SSHAll) workspaceSSHConnectionHealthCheck(w entity.WorkspaceWithMeta) (bool, error) {
-	var hostKey ssh.PublicKey
-	// A public key may be used to authenticate against the remote
-	// 	var hostKey ssh.PublicKey
-	// A public key may be used to authenticate against the remote
-	// server by using an unencrypted PEM-encoded private key file.
-	//
-	// If you have an encrypted private key, the crypto/x509 package
-	// can be used to decrypt it.
-	privateKeyPath, err := s.sshResolver.GetPrivateKeyPath()
-	if err != nil {
-		return false, breverrors.WrapAndTrace(err)
-	}
-	key, err := ioutil.ReadFile(privateKeyPath) //nolint:gosec // not including file via variable
-	if err != nil {
-		return false, breverrors.WrapAndTrace(err, "unable to read private key: %v")
-	}
-
-	// Create the Signer for this private key.
-	signer, err := ssh.ParsePrivateKey(key)
-	if err != nil {
-		return false, breverrors.WrapAndTrace(err, "unable to parse private key: %v")
-	}
-
-	config := &ssh.ClientConfig{
-		User: "brev",
-		Auth: []ssh.AuthMethod{
-			// Use the PublicKeys method for remote authentication.
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.FixedHostKey(hostKey),
-	}
-
-	// Connect to the remote server and perform the SSH handshake.
-	client, err := ssh.Dial("tcp", w.DNS, config)
-	if err != nil {
-		return false, breverrors.WrapAndTrace(err, "unable to connect: %v")
-	}
-	err = client.Close()
-	if err != nil {
-		return true, breverrors.WrapAndTrace(err)
-	}
-	return true, nil
+// WithWorkspaceLister opens and closes tunnels as workspaces start and stop
+func (s *SSHAll) WithWorkspaceLister(listWorkspaces WorkspaceLister) *SSHAll {
+	s.listWorkspaces = listWorkspaces
+	return s
 }
 
-// NOTE: not sure if passing in all the workspaces to check for name is useful here, like in entity.go: GetLocalIdentifier
-func (s SSHAll) runPortForwardWorkspace(workspace entity.WorkspaceWithMeta, _ []entity.WorkspaceWithMeta) {
-	err := s.portforwardWorkspace(workspace)
-	if err != nil {
-		// todo have verbose version with trace
-		fmt.Printf("%v [workspace=%s]\n", errors.Cause(err), workspace.DNS)
-	}
+// WithStatusStore serves the state of the tunnels to brev sshall status
+func (s *SSHAll) WithStatusStore(statusStore StatusStore) *SSHAll {
+	s.statusStore = statusStore
+	return s
 }
 
 func (s SSHAll) Run() error {
-	errorQueue := make(chan error, 1)
-
-	// set up error handling for the ssh connections, very much brute force
-	// since kubectl was not intended to be used a library like this
-	runtime.ErrorHandlers = append(runtime.ErrorHandlers, func(err error) {
-		errorQueue <- err
-	})
-
-	go func() {
-		for {
-			<-errorQueue
-			fmt.Println("resetting unhealthy connections")
-			for _, w := range s.workspaces {
-				isHealthy, _ := s.workspaceSSHConnectionHealthCheck(w)
-				if !isHealthy {
-					fmt.Printf("resetting [w=%s]\n", w.DNS)
-					TryClose(s.workspaceConnections[w])
-					if s.retries[w] > 0 {
-						s.retries[w]--
-						s.runPortForwardWorkspace(w, s.workspaces)
-					}
-				}
-			}
-		}
-	}()
-
-	if len(s.workspaces) == 0 {
+	if len(s.workspaces) == 0 && s.listWorkspaces == nil {
 		fmt.Println("No workspaces in org")
 		return nil
 	}
@@ -150,26 +74,57 @@ Host *
 	fmt.Println()
 	for _, w := range s.workspaces {
 		fmt.Printf("ssh %s\n", w.GetLocalIdentifier())
-		s.retries[w] = 3 // TODO magic number
-		s.runPortForwardWorkspace(w, s.workspaces)
 	}
 	fmt.Println()
 
+	s.supervisor.OnChange = printTunnelStatus
+	s.supervisor.Sync(s.workspaces)
+	defer s.supervisor.StopAll()
+
+	if s.statusStore != nil {
+		closeStatus, err := ServeStatus(s.supervisor, s.statusStore)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		defer closeStatus()
+	}
+
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
 	defer signal.Stop(signals)
-	<-signals
 
-	return nil
+	var refresh <-chan time.Time
+	if s.listWorkspaces != nil {
+		ticker := time.NewTicker(s.refreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-refresh:
+			s.refreshWorkspaces()
+		}
+	}
 }
 
-func TryClose(toClose chan struct{}) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("recovered panic:", r)
-		}
-	}()
-	close(toClose)
+func (s SSHAll) refreshWorkspaces() {
+	workspaces, err := s.listWorkspaces()
+	if err != nil {
+		// keep the tunnels we have until the workspaces can be listed again
+		fmt.Printf("could not refresh workspaces: %v\n", errors.Cause(err))
+		return
+	}
+	s.supervisor.Sync(workspaces)
+}
+
+func printTunnelStatus(status TunnelStatus) {
+	if status.LastError != "" && status.State != TunnelHealthy {
+		fmt.Printf("ssh %s: %s (%s)\n", status.Name, status.State, status.LastError)
+		return
+	}
+	fmt.Printf("ssh %s: %s\n", status.Name, status.State)
 }
 
 // NADER IS SO FUCKING SORRY FOR DOING THIS TWICE BUT I HAVE NO CLUE WHERE THIS HELPER FUNCTION SHOULD GO SO ITS COPY/PASTED ELSEWHERE
@@ -197,49 +152,6 @@ func WorkspacesFromWorkspaceWithMeta(wwm []entity.WorkspaceWithMeta) []entity.Wo
 	return workspaces
 }
 
-func (s SSHAll) portforwardWorkspace(workspace entity.WorkspaceWithMeta) error {
-	port, err := s.sshResolver.GetConfiguredWorkspacePort(workspace.Workspace.GetLocalIdentifier())
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	if port == "" {
-		return fmt.Errorf("port not found")
-	}
-	portMapping := makeSSHPortMapping(port)
-	err = s.portforwardWorkspaceAtPort(workspace, portMapping)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	return nil
-}
-
-func (s *SSHAll) portforwardWorkspaceAtPort(workspace entity.WorkspaceWithMeta, portMapping string) error {
-	dpf := portforward.NewDefaultPortForwarder()
-	pf := portforward.NewPortForwardOptions(
-		s.workspaceGroupClientMapper,
-		dpf,
-	)
-
-	s.workspaceConnectionsMutex.Lock()
-	s.workspaceConnections[workspace] = pf.StopChannel
-	s.workspaceConnectionsMutex.Unlock()
-
-	_, err := pf.WithWorkspace(workspace)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	pf.WithPort(portMapping)
-
-	go func() {
-		err = pf.RunPortforward()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	return nil
-}
-
 type (
 	RandomSSHResolver struct {
 		WorkspaceResolver WorkspaceResolver
@@ -0,0 +1,148 @@
+package sshall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+const (
+	statusPath          = "/status"
+	statusClientTimeout = 5 * time.Second
+)
+
+type StatusStore interface {
+	GetSSHAllStatusAddress() (string, error)
+	WriteSSHAllStatusAddress(address string) error
+	DeleteSSHAllStatusAddress() error
+}
+
+// ServeStatus serves the status of the tunnels on localhost and records the
+// address in the store until the returned func is called
+func ServeStatus(supervisor *Supervisor, store StatusStore) (func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(statusPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(supervisor.Statuses())
+		if err != nil {
+			breverrors.GetDefaultErrorReporter().ReportError(err)
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: statusClientTimeout}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	err = store.WriteSSHAllStatusAddress(listener.Addr().String())
+	if err != nil {
+		_ = server.Close()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return func() {
+		err := store.DeleteSSHAllStatusAddress()
+		if err != nil {
+			breverrors.GetDefaultErrorReporter().ReportError(err)
+		}
+		_ = server.Close()
+	}, nil
+}
+
+// GetStatus asks the running sshall for the status of its tunnels
+func GetStatus(store StatusStore) ([]TunnelStatus, error) {
+	address, err := store.GetSSHAllStatusAddress()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if address == "" {
+		return nil, breverrors.NewValidationError("sshall is not running, start it with brev jetbrains")
+	}
+	client := &http.Client{Timeout: statusClientTimeout}
+	res, err := client.Get("http://" + address + statusPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "sshall is not responding, it may have exited")
+	}
+	defer res.Body.Close() //nolint:errcheck // read only
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sshall status returned %s", res.Status)
+	}
+	statuses := []TunnelStatus{}
+	err = json.NewDecoder(res.Body).Decode(&statuses)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return statuses, nil
+}
+
+func NewCmdSSHAll(t *terminal.Terminal, store StatusStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: map[string]string{"housekeeping": ""},
+		Use:         "sshall",
+		Short:       "Inspect the ssh tunnels brev jetbrains keeps open",
+		Example:     "brev sshall status",
+		Args:        cobra.NoArgs,
+	}
+	cmd.AddCommand(NewCmdStatus(t, store))
+	return cmd
+}
+
+func NewCmdStatus(t *terminal.Terminal, store StatusStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status",
+		Short:   "Show the state of the tunnel to each workspace",
+		Example: "brev sshall status",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := GetStatus(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			displayStatuses(t, statuses)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func displayStatuses(t *terminal.Terminal, statuses []TunnelStatus) {
+	if len(statuses) == 0 {
+		t.Vprint("no tunnels are open, start a workspace with brev start")
+		return
+	}
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	ta.AppendHeader(table.Row{"NAME", "PORT", "STATE", "SINCE", "LAST ERROR"})
+	for _, s := range statuses {
+		lastError := s.LastError
+		if s.NextRetry != nil {
+			lastError = fmt.Sprintf("%s, retrying at %s", lastError, s.NextRetry.Local().Format("15:04:05"))
+		}
+		ta.AppendRow(table.Row{s.Name, s.LocalPort, colorState(t, s.State), s.Since.Local().Format("15:04:05"), lastError})
+	}
+	ta.Render()
+}
+
+func colorState(t *terminal.Terminal, state TunnelState) string {
+	switch state {
+	case TunnelHealthy:
+		return t.Green(string(state))
+	case TunnelFailed:
+		return t.Red(string(state))
+	default:
+		return t.Yellow(string(state))
+	}
+}
@@ -0,0 +1,335 @@
+package sshall
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/k8s"
+	"github.com/brevdev/brev-cli/pkg/portforward"
+)
+
+type TunnelState string
+
+const (
+	// TunnelConnecting is a tunnel that has not been healthy yet
+	TunnelConnecting TunnelState = "connecting"
+	TunnelHealthy    TunnelState = "healthy"
+	// TunnelDegraded is a tunnel that was healthy and is failing its health
+	// checks or reconnecting
+	TunnelDegraded TunnelState = "degraded"
+	// TunnelFailed is a tunnel that failed FailedAfter times in a row, it is
+	// still retried
+	TunnelFailed TunnelState = "failed"
+)
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultHealthInterval = 5 * time.Second
+	defaultFailedAfter    = 3
+	healthCheckTimeout    = 5 * time.Second
+)
+
+// TunnelStatus is the state of the tunnel to one workspace
+type TunnelStatus struct {
+	WorkspaceID string      `json:"workspaceId"`
+	Name        string      `json:"name"`
+	LocalPort   string      `json:"localPort"`
+	State       TunnelState `json:"state"`
+	// Failures is the number of failures in a row
+	Failures  int        `json:"failures"`
+	LastError string     `json:"lastError,omitempty"`
+	Since     time.Time  `json:"since"`
+	NextRetry *time.Time `json:"nextRetry,omitempty"`
+}
+
+type Tunneler interface {
+	// Open forwards localPort to ssh of the workspace until stop is called,
+	// done gets the error the tunnel ended with
+	Open(workspace entity.WorkspaceWithMeta, localPort string) (stop func(), done <-chan error)
+}
+
+// Backoff doubles the wait after each failure in a row, from Initial up to Max
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (b Backoff) Delay(failures int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < failures && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+type tunnel struct {
+	workspace entity.WorkspaceWithMeta
+	status    TunnelStatus
+	// connected is whether the tunnel was ever healthy
+	connected bool
+	cancel    chan struct{}
+	done      chan struct{}
+}
+
+// Supervisor keeps a tunnel open to ssh of each workspace it is given,
+// retrying failed tunnels with exponential backoff
+type Supervisor struct {
+	Tunneler    Tunneler
+	SSHResolver SSHResolver
+	// CheckHealth is whether ssh answers on the local port of a tunnel
+	CheckHealth    func(localPort string) error
+	Backoff        Backoff
+	HealthInterval time.Duration
+	// FailedAfter is the number of failures in a row, connecting or health
+	// checks, after which a tunnel is failed and opened again
+	FailedAfter int
+	// OnChange is called with the status of a tunnel each time its state
+	// changes
+	OnChange func(TunnelStatus)
+
+	mu      sync.Mutex
+	tunnels map[string]*tunnel
+	now     func() time.Time
+}
+
+func NewSupervisor(tunneler Tunneler, sshResolver SSHResolver) *Supervisor {
+	return &Supervisor{
+		Tunneler:       tunneler,
+		SSHResolver:    sshResolver,
+		CheckHealth:    CheckSSHHealth,
+		Backoff:        Backoff{Initial: defaultInitialBackoff, Max: defaultMaxBackoff},
+		HealthInterval: defaultHealthInterval,
+		FailedAfter:    defaultFailedAfter,
+		OnChange:       func(TunnelStatus) {},
+		tunnels:        map[string]*tunnel{},
+		now:            time.Now,
+	}
+}
+
+// Sync opens tunnels to the workspaces that do not have one and closes the
+// tunnels of workspaces that are gone
+func (s *Supervisor) Sync(workspaces []entity.WorkspaceWithMeta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, w := range workspaces {
+		wanted[w.ID] = true
+		if _, ok := s.tunnels[w.ID]; ok {
+			continue
+		}
+		t := &tunnel{
+			workspace: w,
+			status: TunnelStatus{
+				WorkspaceID: w.ID,
+				Name:        string(w.GetLocalIdentifier()),
+				State:       TunnelConnecting,
+				Since:       s.now(),
+			},
+			cancel: make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		s.tunnels[w.ID] = t
+		go s.supervise(t)
+	}
+	for id, t := range s.tunnels {
+		if !wanted[id] {
+			close(t.cancel)
+			delete(s.tunnels, id)
+		}
+	}
+}
+
+// StopAll closes every tunnel and waits for them to stop
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	tunnels := s.tunnels
+	s.tunnels = map[string]*tunnel{}
+	s.mu.Unlock()
+	for _, t := range tunnels {
+		close(t.cancel)
+		<-t.done
+	}
+}
+
+// Statuses is the status of every tunnel sorted by name
+func (s *Supervisor) Statuses() []TunnelStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := []TunnelStatus{}
+	for _, t := range s.tunnels {
+		statuses = append(statuses, t.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func (s *Supervisor) supervise(t *tunnel) {
+	defer close(t.done)
+	for {
+		err := s.runTunnel(t)
+		if err == nil {
+			return
+		}
+		delay := s.recordFailure(t, err)
+		select {
+		case <-t.cancel:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runTunnel opens the tunnel and health checks it until it fails or is
+// cancelled, it is nil only when cancelled
+func (s *Supervisor) runTunnel(t *tunnel) error {
+	port, err := s.SSHResolver.GetConfiguredWorkspacePort(t.workspace.GetLocalIdentifier())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if port == "" {
+		return fmt.Errorf("port not found")
+	}
+	s.update(t, func(status *TunnelStatus) { status.LocalPort = port })
+
+	stop, done := s.Tunneler.Open(t.workspace, port)
+	defer stop()
+	ticker := time.NewTicker(s.HealthInterval)
+	defer ticker.Stop()
+	unhealthy := 0
+	for {
+		select {
+		case <-t.cancel:
+			return nil
+		case err := <-done:
+			if err == nil {
+				err = fmt.Errorf("tunnel closed")
+			}
+			return err
+		case <-ticker.C:
+		}
+		err := s.CheckHealth(port)
+		if err == nil {
+			unhealthy = 0
+			s.recordHealthy(t)
+			continue
+		}
+		unhealthy++
+		if unhealthy >= s.FailedAfter {
+			return breverrors.WrapAndTrace(err)
+		}
+		s.recordUnhealthy(t, err)
+	}
+}
+
+func (s *Supervisor) recordHealthy(t *tunnel) {
+	s.update(t, func(status *TunnelStatus) {
+		t.connected = true
+		status.State = TunnelHealthy
+		status.Failures = 0
+		status.LastError = ""
+		status.NextRetry = nil
+	})
+}
+
+func (s *Supervisor) recordUnhealthy(t *tunnel, err error) {
+	s.update(t, func(status *TunnelStatus) {
+		status.LastError = err.Error()
+		if t.connected {
+			status.State = TunnelDegraded
+		}
+	})
+}
+
+// recordFailure is the wait before the tunnel is opened again
+func (s *Supervisor) recordFailure(t *tunnel, err error) time.Duration {
+	var delay time.Duration
+	s.update(t, func(status *TunnelStatus) {
+		status.Failures++
+		status.LastError = err.Error()
+		switch {
+		case status.Failures >= s.FailedAfter:
+			status.State = TunnelFailed
+		case t.connected:
+			status.State = TunnelDegraded
+		default:
+			status.State = TunnelConnecting
+		}
+		delay = s.Backoff.Delay(status.Failures)
+		nextRetry := s.now().Add(delay)
+		status.NextRetry = &nextRetry
+	})
+	return delay
+}
+
+// update changes the status of the tunnel, calling OnChange when its state
+// changed
+func (s *Supervisor) update(t *tunnel, change func(status *TunnelStatus)) {
+	s.mu.Lock()
+	previous := t.status.State
+	change(&t.status)
+	changed := t.status.State != previous
+	if changed {
+		t.status.Since = s.now()
+	}
+	status := t.status
+	s.mu.Unlock()
+	if changed {
+		s.OnChange(status)
+	}
+}
+
+// CheckSSHHealth is whether an ssh server answers on the local port
+func CheckSSHHealth(localPort string) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", localPort), healthCheckTimeout)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer conn.Close() //nolint:errcheck // read only
+	err = conn.SetReadDeadline(time.Now().Add(healthCheckTimeout))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	banner, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !strings.HasPrefix(banner, "SSH-") {
+		return fmt.Errorf("no ssh server on port %s", localPort)
+	}
+	return nil
+}
+
+// PortForwardTunneler opens tunnels with brev port-forward, leaving retries
+// to the Supervisor
+type PortForwardTunneler struct {
+	WorkspaceGroupClientMapper k8s.WorkspaceGroupClientMapper
+}
+
+var _ Tunneler = PortForwardTunneler{}
+
+func (p PortForwardTunneler) Open(workspace entity.WorkspaceWithMeta, localPort string) (func(), <-chan error) {
+	done := make(chan error, 1)
+	pf, err := portforward.NewPortForwardOptions(p.WorkspaceGroupClientMapper, portforward.NewQuietPortForwarder()).WithWorkspace(workspace)
+	if err != nil {
+		done <- breverrors.WrapAndTrace(err)
+		return func() {}, done
+	}
+	pf.WithPort(makeSSHPortMapping(localPort))
+	pf.MaxReconnectAttempts = 0
+	go func() {
+		done <- pf.RunPortforward()
+	}()
+	return pf.Stop, done
+}
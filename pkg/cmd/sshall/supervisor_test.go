@@ -0,0 +1,218 @@
+package sshall
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 2*time.Second, b.Delay(2))
+	assert.Equal(t, 8*time.Second, b.Delay(4))
+	assert.Equal(t, 10*time.Second, b.Delay(5))
+	assert.Equal(t, 10*time.Second, b.Delay(100))
+}
+
+type fakeResolver struct{}
+
+func (fakeResolver) GetConfiguredWorkspacePort(id entity.WorkspaceLocalID) (string, error) {
+	return "2222", nil
+}
+
+func (fakeResolver) GetPrivateKeyPath() (string, error) {
+	return "/home/test/.brev/brev.pem", nil
+}
+
+type fakeTunneler struct {
+	mu      sync.Mutex
+	opened  map[string]int
+	stopped map[string]int
+	openErr error
+}
+
+func (f *fakeTunneler) Open(workspace entity.WorkspaceWithMeta, _ string) (func(), <-chan error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened[workspace.ID]++
+	done := make(chan error, 1)
+	if f.openErr != nil {
+		done <- f.openErr
+	}
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.stopped[workspace.ID]++
+	}, done
+}
+
+func (f *fakeTunneler) counts(id string) (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.opened[id], f.stopped[id]
+}
+
+type healthSwitch struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (h *healthSwitch) set(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+func (h *healthSwitch) check(string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func newTestSupervisor(tunneler *fakeTunneler, health *healthSwitch) *Supervisor {
+	s := NewSupervisor(tunneler, fakeResolver{})
+	s.CheckHealth = health.check
+	s.HealthInterval = time.Millisecond
+	s.Backoff = Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+	return s
+}
+
+func newTestWorkspace(id, name string) entity.WorkspaceWithMeta {
+	return entity.WorkspaceWithMeta{Workspace: entity.Workspace{ID: id, Name: name}}
+}
+
+func waitForState(t *testing.T, s *Supervisor, id string, state TunnelState) TunnelStatus {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, status := range s.Statuses() {
+			if status.WorkspaceID == id && status.State == state {
+				return status
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%s never became %s: %+v", id, state, s.Statuses())
+	return TunnelStatus{}
+}
+
+func TestSupervisorHealthStates(t *testing.T) {
+	tunneler := &fakeTunneler{opened: map[string]int{}, stopped: map[string]int{}}
+	health := &healthSwitch{err: fmt.Errorf("connection refused")}
+	s := newTestSupervisor(tunneler, health)
+	s.FailedAfter = 1000 // stay degraded rather than reopen
+
+	s.Sync([]entity.WorkspaceWithMeta{newTestWorkspace("ws0001", "one")})
+	assert.Equal(t, TunnelConnecting, s.Statuses()[0].State)
+
+	health.set(nil)
+	status := waitForState(t, s, "ws0001", TunnelHealthy)
+	assert.Equal(t, "2222", status.LocalPort)
+	assert.Empty(t, status.LastError)
+
+	health.set(fmt.Errorf("connection reset"))
+	status = waitForState(t, s, "ws0001", TunnelDegraded)
+	assert.Equal(t, "connection reset", status.LastError)
+
+	health.set(nil)
+	waitForState(t, s, "ws0001", TunnelHealthy)
+
+	s.StopAll()
+	opened, stopped := tunneler.counts("ws0001")
+	assert.Equal(t, 1, opened)
+	assert.Equal(t, 1, stopped)
+}
+
+func TestSupervisorRetriesFailedTunnels(t *testing.T) {
+	tunneler := &fakeTunneler{opened: map[string]int{}, stopped: map[string]int{}, openErr: fmt.Errorf("pod not found")}
+	health := &healthSwitch{}
+	s := newTestSupervisor(tunneler, health)
+
+	s.Sync([]entity.WorkspaceWithMeta{newTestWorkspace("ws0001", "one")})
+	status := waitForState(t, s, "ws0001", TunnelFailed)
+	assert.GreaterOrEqual(t, status.Failures, s.FailedAfter)
+	assert.Contains(t, status.LastError, "pod not found")
+	assert.NotNil(t, status.NextRetry)
+
+	s.StopAll()
+	opened, _ := tunneler.counts("ws0001")
+	assert.GreaterOrEqual(t, opened, s.FailedAfter)
+}
+
+func TestSupervisorSyncAddsAndRemoves(t *testing.T) {
+	tunneler := &fakeTunneler{opened: map[string]int{}, stopped: map[string]int{}}
+	health := &healthSwitch{}
+	s := newTestSupervisor(tunneler, health)
+	changes := make(chan TunnelStatus, 10)
+	s.OnChange = func(status TunnelStatus) {
+		changes <- status
+	}
+
+	s.Sync([]entity.WorkspaceWithMeta{newTestWorkspace("ws0001", "one")})
+	waitForState(t, s, "ws0001", TunnelHealthy)
+	assert.Equal(t, TunnelHealthy, (<-changes).State)
+
+	s.Sync([]entity.WorkspaceWithMeta{newTestWorkspace("ws0002", "two")})
+	waitForState(t, s, "ws0002", TunnelHealthy)
+	statuses := s.Statuses()
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "two-0002", statuses[0].Name)
+
+	assert.Eventually(t, func() bool {
+		_, stopped := tunneler.counts("ws0001")
+		return stopped == 1
+	}, 5*time.Second, time.Millisecond)
+
+	s.StopAll()
+	assert.Empty(t, s.Statuses())
+}
+
+type memStatusStore struct {
+	address string
+}
+
+func (m *memStatusStore) GetSSHAllStatusAddress() (string, error) {
+	return m.address, nil
+}
+
+func (m *memStatusStore) WriteSSHAllStatusAddress(address string) error {
+	m.address = address
+	return nil
+}
+
+func (m *memStatusStore) DeleteSSHAllStatusAddress() error {
+	m.address = ""
+	return nil
+}
+
+func TestServeStatus(t *testing.T) {
+	tunneler := &fakeTunneler{opened: map[string]int{}, stopped: map[string]int{}}
+	s := newTestSupervisor(tunneler, &healthSwitch{})
+	s.Sync([]entity.WorkspaceWithMeta{newTestWorkspace("ws0001", "one")})
+	defer s.StopAll()
+	waitForState(t, s, "ws0001", TunnelHealthy)
+
+	store := &memStatusStore{}
+	_, err := GetStatus(store)
+	assert.NotNil(t, err)
+
+	closeStatus, err := ServeStatus(s, store)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.NotEmpty(t, store.address)
+
+	statuses, err := GetStatus(store)
+	assert.Nil(t, err)
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, "ws0001", statuses[0].WorkspaceID)
+		assert.Equal(t, TunnelHealthy, statuses[0].State)
+	}
+
+	closeStatus()
+	assert.Empty(t, store.address)
+}
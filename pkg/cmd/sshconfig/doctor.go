@@ -0,0 +1,435 @@
+package sshconfig
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/ssh_config"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/proxy"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	doctorLong = `Walk each layer ssh goes through to reach a workspace and report what is
+broken: the Include in ~/.ssh/config, the brev private key, the generated config,
+the host entry, the workspace status and version, and a test dial.
+With --fix the problems brev can repair are repaired.`
+	doctorExample = `
+  brev ssh-config doctor
+  brev ssh-config doctor <ws_name>
+  brev ssh-config doctor <ws_name> --fix
+	`
+)
+
+const (
+	dialTimeout           = 10 * time.Second
+	privateKeyPermissions = 0o600
+)
+
+type DoctorStore interface {
+	ssh.SSHConfigurerTaskStore
+	resolver.ResolverStore
+	completions.CompletionStore
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	GetCurrentUserKeys() (*entity.UserKeys, error)
+	GetFileAsString(path string) (string, error)
+	FileExists(path string) (bool, error)
+	GetFileMode(path string) (os.FileMode, error)
+	SetFileMode(path string, mode os.FileMode) error
+}
+
+func NewCmdDoctor(t *terminal.Terminal, loginDoctorStore DoctorStore, noLoginDoctorStore DoctorStore) *cobra.Command {
+	var fix bool
+
+	cmd := &cobra.Command{
+		Use:                   "doctor",
+		DisableFlagsInUseLine: true,
+		Short:                 "Find out why ssh to a workspace fails",
+		Long:                  doctorLong,
+		Example:               doctorExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.MaximumNArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginDoctorStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			err := RunDoctor(t, NewDoctor(loginDoctorStore), workspaceName, fix)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&fix, "fix", false, "repair the problems brev can repair")
+
+	return cmd
+}
+
+// CheckResult is the outcome of one layer, Fix is set when brev can repair
+// the failure itself
+type CheckResult struct {
+	Passed  bool
+	Skipped bool
+	Detail  string
+	Hint    string
+	Fix     func() error
+}
+
+type Check struct {
+	Name string
+	Run  func() CheckResult
+}
+
+func pass(detail string) CheckResult {
+	return CheckResult{Passed: true, Detail: detail}
+}
+
+func fail(detail string, hint string, fix func() error) CheckResult {
+	return CheckResult{Detail: detail, Hint: hint, Fix: fix}
+}
+
+func skip(detail string) CheckResult {
+	return CheckResult{Skipped: true, Detail: detail}
+}
+
+type Doctor struct {
+	store DoctorStore
+	dial  func(network string, address string) error
+}
+
+func NewDoctor(doctorStore DoctorStore) *Doctor {
+	return &Doctor{
+		store: doctorStore,
+		dial: func(network string, address string) error {
+			conn, err := net.DialTimeout(network, address, dialTimeout)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = conn.Close()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func RunDoctor(t *terminal.Terminal, d *Doctor, workspaceName string, fix bool) error {
+	checks := d.LocalChecks()
+	if workspaceName != "" {
+		workspace, err := d.getWorkspace(t, workspaceName)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		checks = append(checks, d.WorkspaceChecks(workspace)...)
+	}
+
+	failed := 0
+	for _, check := range checks {
+		result := check.Run()
+		if !result.Passed && !result.Skipped && fix && result.Fix != nil {
+			err := result.Fix()
+			if err != nil {
+				result.Detail = fmt.Sprintf("%s, fix failed: %v", result.Detail, err)
+			} else {
+				result = check.Run()
+				if result.Passed {
+					result.Detail += " (fixed)"
+				}
+			}
+		}
+		printResult(t, check.Name, result)
+		if !result.Passed && !result.Skipped {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%d of %d checks failed", failed, len(checks)))
+	}
+	t.Vprint(t.Green("\nall checks passed"))
+	return nil
+}
+
+func printResult(t *terminal.Terminal, name string, result CheckResult) {
+	switch {
+	case result.Passed:
+		t.Vprintf("%s %s: %s\n", t.Green("✓"), name, result.Detail)
+	case result.Skipped:
+		t.Vprintf("%s %s: %s\n", t.Yellow("-"), name, result.Detail)
+	default:
+		t.Vprintf("%s %s: %s\n", t.Red("✗"), name, result.Detail)
+		hint := result.Hint
+		if result.Fix != nil {
+			hint = strings.TrimSpace(hint + " or run with --fix")
+		}
+		if hint != "" {
+			t.Vprintf("    %s\n", t.Yellow(hint))
+		}
+	}
+}
+
+func (d Doctor) getWorkspace(t *terminal.Terminal, workspaceName string) (*entity.Workspace, error) {
+	workspace, err := resolver.NewWorkspaceResolver(t, d.store).GetWorkspace(workspaceName)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	// the listed workspace does not have the version and image
+	workspace, err = d.store.GetWorkspace(workspace.ID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return workspace, nil
+}
+
+// LocalChecks do not need a workspace
+func (d Doctor) LocalChecks() []Check {
+	return []Check{
+		{Name: "include", Run: d.checkInclude},
+		{Name: "private key", Run: d.checkPrivateKey},
+		{Name: "ssh overrides", Run: d.checkOverrides},
+		{Name: "brev ssh config", Run: d.checkBrevConfigParses},
+	}
+}
+
+func (d Doctor) WorkspaceChecks(workspace *entity.Workspace) []Check {
+	return []Check{
+		{Name: "workspace status", Run: func() CheckResult { return d.checkStatus(workspace) }},
+		{Name: "host entry", Run: func() CheckResult { return d.checkHostEntry(workspace) }},
+		{Name: "workspace version", Run: func() CheckResult { return d.checkVersion(workspace) }},
+		{Name: "dial", Run: func() CheckResult { return d.checkDial(workspace) }},
+	}
+}
+
+func (d Doctor) checkInclude() CheckResult {
+	userConfigPath, err := d.store.GetUserSSHConfigPath()
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	brevConfigPath, err := d.store.GetBrevSSHConfigPath()
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	conf, err := d.store.GetUserSSHConfig()
+	if err != nil {
+		return fail(err.Error(), fmt.Sprintf("make sure %s is readable", userConfigPath), nil)
+	}
+	if !ssh.DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+		return fail(fmt.Sprintf("%s does not include %s", userConfigPath, brevConfigPath),
+			fmt.Sprintf("add 'Include %s' to the top of %s", brevConfigPath, userConfigPath),
+			ssh.NewSSHConfigurerV2(d.store).EnsureConfigHasInclude)
+	}
+	return pass(fmt.Sprintf("%s includes %s", userConfigPath, brevConfigPath))
+}
+
+func (d Doctor) checkPrivateKey() CheckResult {
+	path, err := d.store.GetPrivateKeyPath()
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	exists, err := d.store.FileExists(path)
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	if !exists {
+		return fail(fmt.Sprintf("%s does not exist", path), "run 'brev refresh'", d.rewritePrivateKey)
+	}
+	mode, err := d.store.GetFileMode(path)
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	if mode.Perm()&0o077 != 0 {
+		return fail(fmt.Sprintf("%s has permissions %#o, ssh ignores keys others can read", path, mode.Perm()),
+			fmt.Sprintf("chmod 600 %s", path), func() error { return d.fixPrivateKeyPermissions(path) })
+	}
+	key, err := d.store.GetFileAsString(path)
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	err = store.VerifyPrivateKey([]byte(key))
+	if err != nil {
+		return fail(fmt.Sprintf("%s is not a valid private key: %v", path, err), "run 'brev refresh'", d.rewritePrivateKey)
+	}
+	return pass(path)
+}
+
+func (d Doctor) rewritePrivateKey() error {
+	keys, err := d.store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = d.store.WritePrivateKey(keys.PrivateKey)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// fixPrivateKeyPermissions only changes the mode so a key that differs from
+// the server one, like during a rotation, is kept
+func (d Doctor) fixPrivateKeyPermissions(path string) error {
+	err := d.store.SetFileMode(path, privateKeyPermissions)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	mode, err := d.store.GetFileMode(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if mode.Perm() != privateKeyPermissions {
+		return fmt.Errorf("%s still has permissions %#o", path, mode.Perm())
+	}
+	return nil
+}
+
+func (d Doctor) checkOverrides() CheckResult {
+	data, err := d.store.GetSSHOverrides()
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	if strings.TrimSpace(data) == "" {
+		return skip("no ~/.brev/ssh_overrides.yaml")
+	}
+	_, err = ssh.ParseSSHOverrides(data)
+	if err != nil {
+		return fail(err.Error(), "fix ~/.brev/ssh_overrides.yaml, brev ignores it until it is valid", nil)
+	}
+	return pass("~/.brev/ssh_overrides.yaml is valid")
+}
+
+func (d Doctor) getBrevConfig() (*ssh_config.Config, string, error) {
+	path, err := d.store.GetBrevSSHConfigPath()
+	if err != nil {
+		return nil, "", breverrors.WrapAndTrace(err)
+	}
+	exists, err := d.store.FileExists(path)
+	if err != nil {
+		return nil, path, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return nil, path, fmt.Errorf("%s does not exist", path)
+	}
+	data, err := d.store.GetFileAsString(path)
+	if err != nil {
+		return nil, path, breverrors.WrapAndTrace(err)
+	}
+	config, err := ssh_config.Decode(strings.NewReader(data))
+	if err != nil {
+		return nil, path, breverrors.WrapAndTrace(err)
+	}
+	return config, path, nil
+}
+
+func (d Doctor) checkBrevConfigParses() CheckResult {
+	_, path, err := d.getBrevConfig()
+	if err != nil {
+		return fail(err.Error(), "run 'brev refresh'", d.refresh)
+	}
+	return pass(fmt.Sprintf("%s parses", path))
+}
+
+// refresh regenerates every ssh config like 'brev refresh'
+func (d Doctor) refresh() error {
+	configs, err := ssh.GetSSHConfigs(d.store)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	keys, err := d.store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	cu := ssh.ConfigUpdater{
+		Store:      d.store,
+		Configs:    configs,
+		PrivateKey: keys.PrivateKey,
+	}
+	err = cu.Run()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (d Doctor) checkStatus(workspace *entity.Workspace) CheckResult {
+	if workspace.Status != entity.WorkspaceRunningStatus {
+		return fail(fmt.Sprintf("%s is %s, only running workspaces get a host entry", workspace.Name, strings.ToLower(workspace.Status)),
+			fmt.Sprintf("brev start %s", workspace.Name), nil)
+	}
+	return pass(fmt.Sprintf("%s is running", workspace.Name))
+}
+
+func (d Doctor) checkHostEntry(workspace *entity.Workspace) CheckResult {
+	config, path, err := d.getBrevConfig()
+	if err != nil {
+		return skip(err.Error())
+	}
+	host := string(workspace.GetLocalIdentifier())
+	proxyCommand, err := config.Get(host, "ProxyCommand")
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	hostName, err := config.Get(host, "HostName")
+	if err != nil {
+		return fail(err.Error(), "", nil)
+	}
+	if proxyCommand == "" && hostName == "" {
+		return fail(fmt.Sprintf("no entry for %s in %s", host, path), "run 'brev refresh'", d.refresh)
+	}
+	if proxyCommand != "" && !strings.HasSuffix(proxyCommand, " "+workspace.ID) {
+		return fail(fmt.Sprintf("ProxyCommand of %s is '%s', want it to end with %s", host, proxyCommand, workspace.ID), "run 'brev refresh'", d.refresh)
+	}
+	if hostName != "" {
+		port, err := config.Get(host, "Port")
+		if err != nil {
+			return fail(err.Error(), "", nil)
+		}
+		if port != "22" {
+			return fail(fmt.Sprintf("%s has port '%s', want 22", host, port), "run 'brev refresh'", d.refresh)
+		}
+	}
+	return pass(fmt.Sprintf("%s in %s", host, path))
+}
+
+func (d Doctor) checkVersion(workspace *entity.Workspace) CheckResult {
+	err := proxy.CheckWorkspaceVersion(workspace)
+	if err != nil {
+		return fail(err.Error(), "reset the workspace to upgrade it or use an older cli", nil)
+	}
+	version := workspace.Version
+	if version == "" {
+		version = "dev"
+	}
+	return pass(fmt.Sprintf("version %s, image %s", version, workspace.WorkspaceTemplate.Image))
+}
+
+func (d Doctor) checkDial(workspace *entity.Workspace) CheckResult {
+	if workspace.Status != entity.WorkspaceRunningStatus {
+		return skip("workspace is not running")
+	}
+	address := net.JoinHostPort(workspace.GetSSHURL(), "443")
+	config, _, err := d.getBrevConfig()
+	if err == nil {
+		hostName, _ := config.Get(string(workspace.GetLocalIdentifier()), "HostName")
+		if hostName != "" {
+			address = net.JoinHostPort(hostName, "22")
+		}
+	}
+	err = d.dial("tcp", address)
+	if err != nil {
+		return fail(fmt.Sprintf("could not reach %s: %v", address, err), "check your network, vpn or proxy settings", nil)
+	}
+	return pass(fmt.Sprintf("reached %s", address))
+}
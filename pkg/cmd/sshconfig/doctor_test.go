@@ -0,0 +1,166 @@
+package sshconfig
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os/user"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/tweekmonster/luser"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type mockDoctorStore struct {
+	*store.FileStore
+	fs         afero.Fs
+	workspace  entity.Workspace
+	privateKey string
+}
+
+func (m mockDoctorStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o1"}, nil
+}
+
+func (m mockDoctorStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "u1"}, nil
+}
+
+func (m mockDoctorStore) GetOrganizations(_ *store.GetOrganizationsOptions) ([]entity.Organization, error) {
+	return []entity.Organization{{ID: "o1"}}, nil
+}
+
+func (m mockDoctorStore) GetWorkspaces(_ string, _ *store.GetWorkspacesOptions) ([]entity.Workspace, error) {
+	return []entity.Workspace{m.workspace}, nil
+}
+
+func (m mockDoctorStore) GetWorkspaceByNameOrID(_ string, nameOrID string) ([]entity.Workspace, error) {
+	return resolver.MatchExact(nameOrID, []entity.Workspace{m.workspace}), nil
+}
+
+func (m mockDoctorStore) GetWorkspaceMetaData(_ string) (*entity.WorkspaceMetaData, error) {
+	return &entity.WorkspaceMetaData{}, nil
+}
+
+func (m mockDoctorStore) GetWorkspace(_ string) (*entity.Workspace, error) {
+	w := m.workspace
+	return &w, nil
+}
+
+func (m mockDoctorStore) GetContextWorkspaces() ([]entity.Workspace, error) {
+	return []entity.Workspace{m.workspace}, nil
+}
+
+func (m mockDoctorStore) GetCurrentUserKeys() (*entity.UserKeys, error) {
+	return &entity.UserKeys{PrivateKey: m.privateKey}, nil
+}
+
+func newMockDoctorStore(t *testing.T) mockDoctorStore {
+	memfs := afero.NewMemMapFs()
+	fs := store.NewBasicStore().WithFileSystem(memfs)
+	fs.User = &luser.User{User: &user.User{HomeDir: "/home/test"}}
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return mockDoctorStore{
+		FileStore:  fs,
+		fs:         memfs,
+		privateKey: string(privateKey),
+		workspace: entity.Workspace{
+			ID:                "abcd1234",
+			Name:              "hello-go",
+			DNS:               "hello-go-abcd.brev.sh",
+			Status:            entity.WorkspaceRunningStatus,
+			WorkspaceTemplate: entity.WorkspaceTemplate{Image: "brevdev/ubuntu-proxy:0.3.2"},
+		},
+	}
+}
+
+func newTestDoctor(s mockDoctorStore, dialed *string) *Doctor {
+	d := NewDoctor(s)
+	d.dial = func(_ string, address string) error {
+		*dialed = address
+		return nil
+	}
+	return d
+}
+
+func TestDoctorFix(t *testing.T) {
+	s := newMockDoctorStore(t)
+	dialed := ""
+	d := newTestDoctor(s, &dialed)
+
+	err := RunDoctor(terminal.New(), d, "", false)
+	assert.NotNil(t, err)
+
+	err = RunDoctor(terminal.New(), d, "hello-go", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-hello-go-abcd.brev.sh:443", dialed)
+
+	for _, check := range append(d.LocalChecks(), d.WorkspaceChecks(&s.workspace)...) {
+		result := check.Run()
+		assert.True(t, result.Passed || result.Skipped, check.Name)
+	}
+}
+
+func TestDoctorPrivateKeyPermissions(t *testing.T) {
+	s := newMockDoctorStore(t)
+	d := NewDoctor(s)
+	path, err := s.GetPrivateKeyPath()
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = s.WritePrivateKey(s.privateKey)
+	assert.Nil(t, err)
+	assert.True(t, d.checkPrivateKey().Passed)
+
+	err = s.fs.Chmod(path, 0o644)
+	assert.Nil(t, err)
+	// the fix keeps the local key even when the server has another one
+	s.privateKey = ""
+	d = NewDoctor(s)
+	result := d.checkPrivateKey()
+	assert.False(t, result.Passed)
+	if assert.NotNil(t, result.Fix) {
+		assert.Nil(t, result.Fix())
+	}
+	assert.True(t, d.checkPrivateKey().Passed)
+
+	err = s.WritePrivateKey("not a key")
+	assert.Nil(t, err)
+	assert.False(t, d.checkPrivateKey().Passed)
+}
+
+func TestDoctorWorkspaceChecks(t *testing.T) {
+	s := newMockDoctorStore(t)
+	d := newTestDoctor(s, new(string))
+	err := s.WriteBrevSSHConfig("Host hello-go-1234\n  ProxyCommand brev proxy abcd1234\n")
+	assert.Nil(t, err)
+
+	w := s.workspace
+	w.Status = entity.WorkspaceStoppedStatus
+	assert.False(t, d.checkStatus(&w).Passed)
+	assert.True(t, d.checkDial(&w).Skipped)
+	assert.True(t, d.checkHostEntry(&w).Passed)
+
+	w.ID = "efgh1234"
+	assert.False(t, d.checkHostEntry(&w).Passed)
+
+	w = s.workspace
+	d.dial = func(_ string, _ string) error {
+		return errors.New("connection refused")
+	}
+	assert.False(t, d.checkDial(&w).Passed)
+
+	w.WorkspaceTemplate.Image = "brevdev/ubuntu:0.1"
+	w.Version = "1.0.0"
+	assert.False(t, d.checkVersion(&w).Passed)
+}
@@ -0,0 +1,43 @@
+// Package sshconfig is for inspecting the ssh config brev generates
+package sshconfig
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	sshConfigLong = `brev writes an ssh host entry for every running workspace to ~/.brev/ssh_config
+and includes it from ~/.ssh/config. These commands help when ssh to a workspace fails.`
+	sshConfigExample = `
+  brev ssh-config doctor
+  brev ssh-config doctor <ws_name>
+  brev ssh-config doctor <ws_name> --fix
+	`
+)
+
+func NewCmdSSHConfig(t *terminal.Terminal, loginDoctorStore DoctorStore, noLoginDoctorStore DoctorStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "ssh-config",
+		DisableFlagsInUseLine: true,
+		Short:                 "Inspect and repair the brev ssh config",
+		Long:                  sshConfigLong,
+		Example:               sshConfigExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.AddCommand(NewCmdDoctor(t, loginDoctorStore, noLoginDoctorStore))
+
+	return cmd
+}
@@ -0,0 +1,146 @@
+package sshkeys
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type RotateStore interface {
+	ssh.SSHConfigurerTaskStore
+	GetCurrentUserKeys() (*entity.UserKeys, error)
+	UpdateCurrentUserKeys(keys *entity.UpdateUserKeys) (*entity.UserKeys, error)
+	RotatePrivateKey(pem string, rotation *entity.SSHKeyRotation) error
+}
+
+func NewCmdRotate(t *terminal.Terminal, store RotateStore) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Annotations: map[string]string{"housekeeping": ""},
+		Use:         "rotate",
+		Short:       "Replace your SSH-Key with a new one",
+		Long: `Generate a new SSH-Key and replace the one brev gives your machines and workspaces with it.
+Workspaces that are running keep the previous key until they are restarted, so
+the previous key stays in ~/.brev until then.`,
+		Example: `brev ssh-key rotate`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RotateSSHKey(t, store, force)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "rotate even if running workspaces still wait on the last rotation")
+
+	return cmd
+}
+
+func RotateSSHKey(t *terminal.Terminal, store RotateStore, force bool) error {
+	previousRotation, err := store.GetSSHKeyRotation()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !force && previousRotation != nil && len(previousRotation.PendingWorkspaceIDs) > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("workspaces %s still use the key from before the last rotation, restart them or use --force",
+			strings.Join(previousRotation.PendingWorkspaceIDs, ", ")))
+	}
+	workspaces, err := store.GetContextWorkspaces()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	running := []string{}
+	for _, w := range workspaces {
+		if w.Status == entity.WorkspaceRunningStatus {
+			running = append(running, w.ID)
+		}
+	}
+
+	oldKeys, err := store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	privateKey, publicKey, err := ssh.GenerateSSHKey()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	authorizedKey := strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(publicKey)))
+
+	// the server is changed first, a machine that syncs afterwards gets the
+	// new private key and nothing local is lost if it fails
+	err = updateServerKeys(store, oldKeys, privateKey, authorizedKey)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = store.RotatePrivateKey(privateKey, &entity.SSHKeyRotation{
+		PublicKey:           authorizedKey,
+		RotatedAt:           time.Now(),
+		PendingWorkspaceIDs: running,
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err, "the new key was uploaded but not saved, brev refresh writes it")
+	}
+
+	configs, err := ssh.GetSSHConfigs(store)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	cu := ssh.ConfigUpdater{
+		Store:      store,
+		Configs:    configs,
+		PrivateKey: privateKey,
+	}
+	err = cu.Run()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprintf(t.Green("Your SSH-Key was rotated\n"))
+	previousKeyPath, err := store.GetPreviousPrivateKeyPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, w := range workspaces {
+		if w.Status == entity.WorkspaceRunningStatus {
+			t.Vprintf("\t%s: %s\n", w.Name, t.Yellow("uses the previous key from %s until it is restarted", previousKeyPath))
+		} else {
+			t.Vprintf("\t%s: %s\n", w.Name, t.Green("uses the new key"))
+		}
+	}
+	t.Print("\n")
+	DisplaySSHKeys(t, authorizedKey)
+	return nil
+}
+
+// updateServerKeys replaces the keypair on the server and checks it serves
+// the new one, putting the old one back when it does not
+func updateServerKeys(store RotateStore, oldKeys *entity.UserKeys, privateKey string, authorizedKey string) error {
+	_, err := store.UpdateCurrentUserKeys(&entity.UpdateUserKeys{PrivateKey: privateKey, PublicKey: authorizedKey})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	keys, err := store.GetCurrentUserKeys()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if keys.PrivateKey == privateKey && strings.TrimSpace(keys.PublicKey) == authorizedKey {
+		return nil
+	}
+	err = breverrors.NewValidationError("the server did not take the new SSH-Key, your key was not changed")
+	_, restoreErr := store.UpdateCurrentUserKeys(&entity.UpdateUserKeys{PrivateKey: oldKeys.PrivateKey, PublicKey: oldKeys.PublicKey})
+	if restoreErr != nil {
+		return multierror.Append(err, restoreErr)
+	}
+	return err
+}
@@ -11,6 +11,7 @@ import (
 )
 
 type SSHKeyStore interface {
+	RotateStore
 	GetCurrentUser() (*entity.User, error)
 }
 
@@ -20,7 +21,7 @@ func NewCmdSSHKeys(t *terminal.Terminal, sshKeyStore SSHKeyStore) *cobra.Command
 		Use:         "ssh-key",
 		Short:       "Get your pulic SSH-Key",
 		Long:        "Get your pulic SSH-Key to add to pull and push from your git repository.",
-		Example:     `brev ssh-key`,
+		Example:     "brev ssh-key\nbrev ssh-key rotate",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
 			if err != nil {
@@ -40,6 +41,8 @@ func NewCmdSSHKeys(t *terminal.Terminal, sshKeyStore SSHKeyStore) *cobra.Command
 		},
 	}
 
+	cmd.AddCommand(NewCmdRotate(t, sshKeyStore))
+
 	return cmd
 }
 
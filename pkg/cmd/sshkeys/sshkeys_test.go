@@ -1 +1,149 @@
 package sshkeys
+
+import (
+	"errors"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/tweekmonster/luser"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type mockRotateStore struct {
+	*store.FileStore
+	fs         afero.Fs
+	workspaces []entity.Workspace
+	updateErr  error
+	// ignoreUpdate is a server that answers the update but keeps the keys
+	ignoreUpdate bool
+	serverKeys   entity.UserKeys
+}
+
+func (m *mockRotateStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{ID: "u1", Username: "me", Email: "me@example.com"}, nil
+}
+
+func (m *mockRotateStore) GetContextWorkspaces() ([]entity.Workspace, error) {
+	return m.workspaces, nil
+}
+
+func (m *mockRotateStore) GetCurrentUserKeys() (*entity.UserKeys, error) {
+	keys := m.serverKeys
+	return &keys, nil
+}
+
+func (m *mockRotateStore) UpdateCurrentUserKeys(keys *entity.UpdateUserKeys) (*entity.UserKeys, error) {
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	if !m.ignoreUpdate {
+		m.serverKeys.PrivateKey = keys.PrivateKey
+		m.serverKeys.PublicKey = keys.PublicKey
+	}
+	keysCopy := m.serverKeys
+	return &keysCopy, nil
+}
+
+const oldPrivateKey = "old key"
+
+func newMockRotateStore(t *testing.T) *mockRotateStore {
+	memfs := afero.NewMemMapFs()
+	fs := store.NewBasicStore().WithFileSystem(memfs)
+	fs.User = &luser.User{User: &user.User{HomeDir: "/home/test"}}
+	assert.Nil(t, fs.WritePrivateKey(oldPrivateKey))
+	return &mockRotateStore{
+		FileStore:  fs,
+		fs:         memfs,
+		serverKeys: entity.UserKeys{PrivateKey: oldPrivateKey, PublicKey: "ssh-rsa old"},
+		workspaces: []entity.Workspace{
+			{ID: "running1", Name: "running", DNS: "running-abcd.brev.sh", Status: entity.WorkspaceRunningStatus},
+			{ID: "stopped1", Name: "stopped", DNS: "stopped-abcd.brev.sh", Status: entity.WorkspaceStoppedStatus},
+		},
+	}
+}
+
+func TestRotateSSHKey(t *testing.T) {
+	s := newMockRotateStore(t)
+	err := RotateSSHKey(terminal.New(), s, false)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	newKey, err := s.GetPrivateKey()
+	assert.Nil(t, err)
+	assert.Contains(t, newKey, "RSA PRIVATE KEY")
+	previousPath, err := s.GetPreviousPrivateKeyPath()
+	assert.Nil(t, err)
+	previous, err := afero.ReadFile(s.fs, previousPath)
+	assert.Nil(t, err)
+	assert.Equal(t, oldPrivateKey, string(previous))
+
+	rotation, err := s.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"running1"}, rotation.PendingWorkspaceIDs)
+	// other machines get the new key from the server
+	assert.Equal(t, rotation.PublicKey, s.serverKeys.PublicKey)
+	assert.Equal(t, newKey, s.serverKeys.PrivateKey)
+
+	// the running workspace can still use the previous key
+	configPath, err := s.GetBrevSSHConfigPath()
+	assert.Nil(t, err)
+	configData, err := afero.ReadFile(s.fs, configPath)
+	assert.Nil(t, err)
+	config := string(configData)
+	assert.Contains(t, config, "IdentityFile "+previousPath)
+	assert.Equal(t, 1, strings.Count(config, previousPath))
+
+	// once the running workspace restarted the rotation is done
+	err = s.PruneSSHKeyRotation(nil)
+	assert.Nil(t, err)
+	rotation, err = s.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Nil(t, rotation)
+	exists, err := afero.Exists(s.fs, previousPath)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// a second rotation waits on a running workspace
+	err = RotateSSHKey(terminal.New(), s, false)
+	assert.Nil(t, err)
+	err = RotateSSHKey(terminal.New(), s, false)
+	assert.NotNil(t, err)
+}
+
+func assertKeyUnchanged(t *testing.T, s *mockRotateStore) {
+	key, err := s.GetPrivateKey()
+	assert.Nil(t, err)
+	assert.Equal(t, oldPrivateKey, key)
+	rotation, err := s.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Nil(t, rotation)
+	previousPath, err := s.GetPreviousPrivateKeyPath()
+	assert.Nil(t, err)
+	exists, err := afero.Exists(s.fs, previousPath)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, oldPrivateKey, s.serverKeys.PrivateKey)
+}
+
+func TestRotateSSHKeyUploadFailure(t *testing.T) {
+	s := newMockRotateStore(t)
+	s.updateErr = errors.New("upload failed")
+	err := RotateSSHKey(terminal.New(), s, false)
+	assert.NotNil(t, err)
+	assertKeyUnchanged(t, s)
+}
+
+func TestRotateSSHKeyServerIgnoresKeys(t *testing.T) {
+	s := newMockRotateStore(t)
+	s.ignoreUpdate = true
+	err := RotateSSHKey(terminal.New(), s, false)
+	assert.NotNil(t, err)
+	assertKeyUnchanged(t, s)
+}
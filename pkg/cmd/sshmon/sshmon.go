@@ -2,15 +2,23 @@ package sshmon
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/brevdev/brev-cli/pkg/analytics"
+	"github.com/brevdev/brev-cli/pkg/autoforward"
+	"github.com/brevdev/brev-cli/pkg/autostop"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
 	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/brevdev/brev-cli/pkg/workspacesecrets"
 	"github.com/spf13/cobra"
 )
 
 type SSHMonStore interface {
 	analytics.SSHAnalyticsStore
+	autostop.AutoStopStore
+	autoforward.ListeningPortsStore
+	workspacesecrets.RefreshStore
 }
 
 func NewCmdSSHMon(store SSHMonStore, segmentAPIWriteKey string) *cobra.Command {
@@ -40,8 +48,16 @@ func NewCmdSSHMon(store SSHMonStore, segmentAPIWriteKey string) *cobra.Command {
 				&analytics.SSHAnalyticsSSHPing{
 					SSHAnalytics: sshAnalytics,
 				},
+				autostop.NewAutoStopTask(store),
+				autoforward.NewListeningPortsTask(store),
 			}
-			err := tasks.RunTasks(sshMontasks)
+			workspaceUser, err := setupworkspace.GetUserFromUserStr("brev")
+			if err != nil {
+				log.Printf("not refreshing secrets: %v", err)
+			} else {
+				sshMontasks = append(sshMontasks, workspacesecrets.NewRefreshTask(store, workspaceUser))
+			}
+			err = tasks.RunTasks(sshMontasks)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -7,9 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
+	"github.com/brevdev/brev-cli/pkg/cmd/classes"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/cmd/templates"
+	"github.com/brevdev/brev-cli/pkg/cmd/wait"
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
@@ -27,10 +31,15 @@ var (
   brev start <existing_ws_name>
   brev start <git url>
   brev start <git url> --org myFancyOrg
+  brev start <git url> --template ubuntu
+  brev start <ws_name> <ws_name>
+  brev start --all
+  brev start --selector status=STOPPED,class=4x16
 	`
 )
 
 type StartStore interface {
+	resolver.ResolverStore
 	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 	GetCurrentUser() (*entity.User, error)
@@ -41,6 +50,8 @@ type StartStore interface {
 	GetWorkspaceMetaData(workspaceID string) (*entity.WorkspaceMetaData, error)
 	GetSetupScriptContentsByURL(url string) (string, error)
 	GetFileAsString(path string) (string, error)
+	GetWorkspaceTemplates(organizationID string) ([]entity.WorkspaceTemplate, error)
+	GetWorkspaceClasses(organizationID string) ([]entity.WorkspaceClass, error)
 }
 
 func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartStore StartStore) *cobra.Command {
@@ -48,9 +59,10 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 	var name string
 	var detached bool
 	var empty bool
-	var is4x16 bool
 	var workspaceClass string
+	var template string
 	var setupScript string
+	var bulkOptions bulk.Options
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -63,11 +75,25 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 && !empty {
-				return breverrors.NewValidationError("an argument is required, or use the '--empty' flag")
+				if !bulkOptions.All && bulkOptions.Selector == "" {
+					return breverrors.NewValidationError("an argument is required, or use the '--empty' flag")
+				}
+			}
+
+			if !empty && bulkOptions.IsBulk(args) {
+				err := bulkOptions.Validate(args)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				err = startWorkspaces(args, bulkOptions, t, loginStartStore)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
 			}
 
 			if empty {
-				err := createEmptyWorkspace(t, org, loginStartStore, name, detached, setupScript, workspaceClass)
+				err := createEmptyWorkspace(t, org, loginStartStore, name, detached, setupScript, workspaceClass, template)
 				if err != nil {
 					return breverrors.WrapAndTrace(err)
 				}
@@ -79,12 +105,12 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 
 				if isURL {
 					// CREATE A WORKSPACE
-					err := clone(t, args[0], org, loginStartStore, name, is4x16, setupScript, workspaceClass)
+					err := clone(t, args[0], org, loginStartStore, name, setupScript, workspaceClass, template)
 					if err != nil {
 						return breverrors.WrapAndTrace(err)
 					}
 				} else {
-					workspace, _ := getWorkspaceFromNameOrID(args[0], loginStartStore) // ignoring err todo handle me better
+					workspace, _ := resolver.NewWorkspaceResolver(t, loginStartStore).GetWorkspaceFromNameOrID(args[0]) // ignoring err todo handle me better
 					if workspace == nil {
 						// get org, check for workspace to join before assuming start via path
 						activeOrg, err := loginStartStore.GetActiveOrganizationOrDefault()
@@ -103,13 +129,13 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 						}
 						if len(workspaces) == 0 {
 							// then this is a path, and we should import dependencies from it and start
-							err = startWorkspaceFromPath(args[0], loginStartStore, t, detached, name, org, is4x16, workspaceClass)
+							err = startWorkspaceFromPath(args[0], loginStartStore, t, detached, name, org, workspaceClass, template)
 							if err != nil {
 								return breverrors.WrapAndTrace(err)
 							}
 						} else {
 							// the user wants to join a workspace
-							err = joinProjectWithNewWorkspace(workspaces[0], t, activeOrg.ID, loginStartStore, name, user, workspaceClass)
+							err = joinProjectWithNewWorkspace(workspaces[0], t, activeOrg.ID, loginStartStore, name, user, workspaceClass, template)
 							if err != nil {
 								return breverrors.WrapAndTrace(err)
 							}
@@ -117,7 +143,7 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 
 					} else {
 						// Start an existing one (either theirs or someone elses)
-						err := startWorkspace(args[0], loginStartStore, t, detached, name, workspaceClass)
+						err := startWorkspace(args[0], loginStartStore, t, detached, name, workspaceClass, template)
 						if err != nil {
 							return breverrors.WrapAndTrace(err)
 						}
@@ -127,10 +153,12 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 			return nil
 		},
 	}
+	bulk.AddFlags(cmd, &bulkOptions)
 	cmd.Flags().BoolVarP(&detached, "detached", "d", false, "run the command in the background instead of blocking the shell")
 	cmd.Flags().BoolVarP(&empty, "empty", "e", false, "create an empty workspace")
 	cmd.Flags().StringVarP(&name, "name", "n", "", "name your workspace when creating a new one")
-	cmd.Flags().StringVarP(&workspaceClass, "class", "c", "", "workspace resource class (cpu x memory) default 2x8 [2x8, 4x16, 8x32, 16x32]")
+	cmd.Flags().StringVarP(&workspaceClass, "class", "c", "", "workspace resource class (cpu x memory) when creating a workspace, see 'brev classes'")
+	cmd.Flags().StringVarP(&template, "template", "t", "", "workspace template name or id when creating a workspace, see 'brev templates ls'")
 	cmd.Flags().StringVarP(&setupScript, "setup-script", "s", "", "replace the default setup script")
 	cmd.Flags().StringVarP(&org, "org", "o", "", "organization (will override active org if creating a workspace)")
 	err := cmd.RegisterFlagCompletionFunc("org", completions.GetOrgsNameCompletionHandler(noLoginStartStore, t))
@@ -138,10 +166,45 @@ func NewCmdStart(t *terminal.Terminal, loginStartStore StartStore, noLoginStartS
 		breverrors.GetDefaultErrorReporter().ReportError(err)
 		t.Errprint(err, "cli err")
 	}
+	err = cmd.RegisterFlagCompletionFunc("class", classes.GetClassCompletionHandler(noLoginStartStore))
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(err)
+		t.Errprint(err, "cli err")
+	}
+	err = cmd.RegisterFlagCompletionFunc("template", getTemplateNameCompletionHandler(noLoginStartStore))
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(err)
+		t.Errprint(err, "cli err")
+	}
 	return cmd
 }
 
-func startWorkspaceFromPath(path string, loginStartStore StartStore, t *terminal.Terminal, detached bool, name string, org string, is4x16 bool, workspaceClass string) error {
+// startWorkspaces starts many existing workspaces without waiting on any of them
+func startWorkspaces(args []string, bulkOptions bulk.Options, t *terminal.Terminal, startStore StartStore) error {
+	workspaces, err := bulk.GetWorkspaces(t, startStore, args, bulkOptions)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = bulk.RunAndSummarize(t, workspaces, bulkOptions.Parallelism, func(workspace entity.Workspace) (string, error) {
+		if workspace.Status == "RUNNING" {
+			return "already running", nil
+		}
+		if workspace.Status != "STOPPED" {
+			return "skipped, " + strings.ToLower(workspace.Status), nil
+		}
+		_, err := startStore.StartWorkspace(workspace.ID)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return "starting", nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func startWorkspaceFromPath(path string, loginStartStore StartStore, t *terminal.Terminal, detached bool, name string, org string, workspaceClass string, template string) error {
 	pathExists := dirExists(path)
 	if !pathExists {
 		return fmt.Errorf(strings.Join([]string{"Path:", path, "does not exist."}, " "))
@@ -179,7 +242,7 @@ func startWorkspaceFromPath(path string, loginStartStore StartStore, t *terminal
 		fmt.Println("setup script generated.")
 	}
 
-	err := clone(t, gitURL, org, loginStartStore, name, is4x16, brevpath, workspaceClass)
+	err := clone(t, gitURL, org, loginStartStore, name, brevpath, workspaceClass, template)
 
 	return err
 }
@@ -196,7 +259,7 @@ func dirExists(path string) bool {
 	return false
 }
 
-func createEmptyWorkspace(t *terminal.Terminal, orgflag string, startStore StartStore, name string, detached bool, setupScript string, workspaceClass string) error {
+func createEmptyWorkspace(t *terminal.Terminal, orgflag string, startStore StartStore, name string, detached bool, setupScript string, workspaceClass string, template string) error {
 	// ensure name
 	if len(name) == 0 {
 		return breverrors.NewValidationError("name field is required for empty workspaces")
@@ -247,16 +310,25 @@ func createEmptyWorkspace(t *terminal.Terminal, orgflag string, startStore Start
 	clusterID := config.GlobalConfig.GetDefaultClusterID()
 	options := store.NewCreateWorkspacesOptions(clusterID, name)
 
+	err = classes.CheckClass(startStore, orgID, workspaceClass)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	if workspaceClass != "" {
 		options.WithClassID(workspaceClass)
 	}
 
+	err = withTemplate(options, startStore, orgID, template)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
 	user, err := startStore.GetCurrentUser()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	options = resolveWorkspaceUserOptions(options, user)
+	options = ResolveWorkspaceUserOptions(options, user)
 
 	if len(setupScriptContents) > 0 {
 		options.WithStartupScript(setupScriptContents)
@@ -271,7 +343,7 @@ func createEmptyWorkspace(t *terminal.Terminal, orgflag string, startStore Start
 	if detached {
 		return nil
 	} else {
-		err = pollUntil(t, w.ID, "RUNNING", startStore, true)
+		err = wait.PollUntil(t, startStore, w.ID, wait.Running, true)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
@@ -284,7 +356,8 @@ func createEmptyWorkspace(t *terminal.Terminal, orgflag string, startStore Start
 	}
 }
 
-func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
+// ResolveWorkspaceUserOptions fills in the template and class defaults for the user
+func ResolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
 	if options.WorkspaceTemplateID == "" {
 		if featureflag.IsAdmin(user.GlobalUserType) {
 			options.WorkspaceTemplateID = store.DevWorkspaceTemplateID
@@ -302,8 +375,8 @@ func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *e
 	return options
 }
 
-func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Terminal, detached bool, name string, workspaceClass string) error {
-	workspace, err := getWorkspaceFromNameOrID(workspaceName, startStore)
+func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Terminal, detached bool, name string, workspaceClass string, template string) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, startStore).GetWorkspaceFromNameOrID(workspaceName)
 	org, othererr := startStore.GetActiveOrganizationOrDefault()
 	if othererr != nil {
 		return breverrors.WrapAndTrace(othererr)
@@ -326,7 +399,7 @@ func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Ter
 		if len(workspaces) == 0 {
 			return breverrors.NewValidationError(fmt.Sprintf("your team has no projects named %s", workspaceName))
 		}
-		othererr = joinProjectWithNewWorkspace(workspaces[0], t, org.ID, startStore, name, user, workspaceClass)
+		othererr = joinProjectWithNewWorkspace(workspaces[0], t, org.ID, startStore, name, user, workspaceClass, template)
 		if othererr != nil {
 			return breverrors.WrapAndTrace(othererr)
 		}
@@ -337,7 +410,10 @@ func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Ter
 			return nil
 		}
 		if workspaceClass != "" {
-			return breverrors.NewValidationError("Workspace already exists. Can not pass workspace class flag to start stopped workspace")
+			return breverrors.NewValidationError("Workspace already exists. Can not pass workspace class flag to start stopped workspace, use 'brev resize' to change its class")
+		}
+		if template != "" {
+			return breverrors.NewValidationError("Workspace already exists. Can not pass template flag to start stopped workspace")
 		}
 
 		if len(name) > 0 {
@@ -356,7 +432,7 @@ func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Ter
 			return nil
 		}
 
-		err = pollUntil(t, workspace.ID, "RUNNING", startStore, true)
+		err = wait.PollUntil(t, startStore, workspace.ID, wait.Running, true)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
 		}
@@ -372,20 +448,28 @@ func startWorkspace(workspaceName string, startStore StartStore, t *terminal.Ter
 // "https://github.com/brevdev/microservices-demo.git
 // "https://github.com/brevdev/microservices-demo.git"
 // "git@github.com:brevdev/microservices-demo.git"
-func joinProjectWithNewWorkspace(templateWorkspace entity.Workspace, t *terminal.Terminal, orgID string, startStore StartStore, name string, user *entity.User, workspaceClass string) error {
+func joinProjectWithNewWorkspace(templateWorkspace entity.Workspace, t *terminal.Terminal, orgID string, startStore StartStore, name string, user *entity.User, workspaceClass string, template string) error {
 	clusterID := config.GlobalConfig.GetDefaultClusterID()
+	err := classes.CheckClass(startStore, orgID, workspaceClass)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	if workspaceClass == "" {
 		workspaceClass = templateWorkspace.WorkspaceClassID
 	}
 
 	options := store.NewCreateWorkspacesOptions(clusterID, templateWorkspace.Name).WithGitRepo(templateWorkspace.GitRepo).WithWorkspaceClassID(workspaceClass)
+	err = withTemplate(options, startStore, orgID, template)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	if len(name) > 0 {
 		options.Name = name
 	} else {
 		t.Vprintf("Name flag omitted, using auto generated name: %s", t.Green(options.Name))
 	}
 
-	options = resolveWorkspaceUserOptions(options, user)
+	options = ResolveWorkspaceUserOptions(options, user)
 
 	t.Vprint("\nWorkspace is starting. " + t.Yellow("This can take up to 2 minutes the first time.\n"))
 
@@ -394,7 +478,7 @@ func joinProjectWithNewWorkspace(templateWorkspace entity.Workspace, t *terminal
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, "RUNNING", startStore, true)
+	err = wait.PollUntil(t, startStore, w.ID, wait.Running, true)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -409,14 +493,11 @@ func IsUrl(str string) bool {
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
 
-func clone(t *terminal.Terminal, url string, orgflag string, startStore StartStore, name string, is4x16 bool, setupScriptPath string, workspaceClass string) error {
+func clone(t *terminal.Terminal, url string, orgflag string, startStore StartStore, name string, setupScriptPath string, workspaceClass string, template string) error {
 	t.Vprintf("This is the setup script: %s", setupScriptPath)
 	// https://gist.githubusercontent.com/naderkhalil/4a45d4d293dc3a9eb330adcd5440e148/raw/3ab4889803080c3be94a7d141c7f53e286e81592/setup.sh
 	// fetch contents of file
 	// todo: read contents of file
-	if is4x16 {
-		workspaceClass = "4x16"
-	}
 
 	var setupScriptContents string
 	var err error
@@ -475,7 +556,7 @@ func clone(t *terminal.Terminal, url string, orgflag string, startStore StartSto
 		orgID = orgs[0].ID
 	}
 
-	err = createWorkspace(t, newWorkspace, orgID, startStore, workspaceClass, setupScriptContents)
+	err = createWorkspace(t, newWorkspace, orgID, startStore, workspaceClass, template, setupScriptContents)
 	if err != nil {
 		t.Vprint(t.Red(err.Error()))
 	}
@@ -526,8 +607,7 @@ func MakeNewWorkspaceFromURL(url string) NewWorkspace {
 	}
 }
 
-func createWorkspace(t *terminal.Terminal, workspace NewWorkspace, orgID string, startStore StartStore, workspaceClass string, setupScript string) error {
-	t.Vprint("\nWorkspace is starting. " + t.Yellow("This can take up to 2 minutes the first time.\n"))
+func createWorkspace(t *terminal.Terminal, workspace NewWorkspace, orgID string, startStore StartStore, workspaceClass string, template string, setupScript string) error {
 	clusterID := config.GlobalConfig.GetDefaultClusterID()
 	options := store.NewCreateWorkspacesOptions(clusterID, workspace.Name).WithGitRepo(workspace.GitRepo)
 
@@ -536,22 +616,32 @@ func createWorkspace(t *terminal.Terminal, workspace NewWorkspace, orgID string,
 		return breverrors.WrapAndTrace(err)
 	}
 
+	err = classes.CheckClass(startStore, orgID, workspaceClass)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	if workspaceClass != "" {
 		options = options.WithWorkspaceClassID(workspaceClass)
 	}
 
-	options = resolveWorkspaceUserOptions(options, user)
+	err = withTemplate(options, startStore, orgID, template)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	options = ResolveWorkspaceUserOptions(options, user)
 
 	if len(setupScript) > 0 {
 		options.WithStartupScript(setupScript)
 	}
 
+	t.Vprint("\nWorkspace is starting. " + t.Yellow("This can take up to 2 minutes the first time.\n"))
 	w, err := startStore.CreateWorkspace(orgID, options)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = pollUntil(t, w.ID, "RUNNING", startStore, true)
+	err = wait.PollUntil(t, startStore, w.ID, wait.Running, true)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -563,91 +653,40 @@ func createWorkspace(t *terminal.Terminal, workspace NewWorkspace, orgID string,
 	return nil
 }
 
-func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace) {
-	t.Vprintf(t.Green("Connect to the workspace:\n"))
-	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev open %s\t# brev open <NAME> -> open workspace in preferred editor\n", workspace.Name)))
-	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev shell %s\t# brev shell <NAME> -> shell into workspace\n", workspace.Name)))
-	t.Vprintf(t.Yellow(fmt.Sprintf("\tssh %s\t# ssh <SSH-NAME> -> ssh directly to workspace\n", workspace.GetLocalIdentifier())))
-}
-
-func pollUntil(t *terminal.Terminal, wsid string, state string, startStore StartStore, canSafelyExit bool) error {
-	s := t.NewSpinner()
-	isReady := false
-	if canSafelyExit {
-		t.Vprintf("You can safely ctrl+c to exit\n")
-	}
-	s.Suffix = " hang tight 🤙"
-	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := startStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
-	}
-	return nil
-}
-
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-func getWorkspaceFromNameOrID(nameOrID string, sstore StartStore) (*entity.WorkspaceWithMeta, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-	if org == nil {
-		return nil, breverrors.NewValidationError("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+// withTemplate resolves a template name or id so typos fail before anything is created
+func withTemplate(options *store.CreateWorkspacesOptions, startStore StartStore, orgID string, template string) error {
+	if template == "" {
+		return nil
 	}
-
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
+	templateID, err := templates.GetTemplateID(startStore, orgID, template)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return breverrors.WrapAndTrace(err)
 	}
+	options.WithWorkspaceTemplateID(templateID)
+	return nil
+}
 
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+func getTemplateNameCompletionHandler(startStore StartStore) completions.CompletionHandler {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		org, err := startStore.GetActiveOrganizationOrDefault()
+		if err != nil || org == nil {
+			return nil, cobra.ShellCompDirectiveError
 		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+		workspaceTemplates, err := startStore.GetWorkspaceTemplates(org.ID)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
 		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID))
-	}
-
-	if workspace == nil {
-		return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-	}
-
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		names := []string{}
+		for _, wt := range workspaceTemplates {
+			names = append(names, wt.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
 	}
+}
 
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace) {
+	t.Vprintf(t.Green("Connect to the workspace:\n"))
+	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev open %s\t# brev open <NAME> -> open workspace in preferred editor\n", workspace.Name)))
+	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev shell %s\t# brev shell <NAME> -> shell into workspace\n", workspace.Name)))
+	t.Vprintf(t.Yellow(fmt.Sprintf("\tssh %s\t# ssh <SSH-NAME> -> ssh directly to workspace\n", workspace.GetLocalIdentifier())))
 }
@@ -2,10 +2,11 @@
 package stop
 
 import (
-	"fmt"
+	"strings"
 
-	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/bulk"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
@@ -15,11 +16,18 @@ import (
 
 var (
 	stopLong    = "Stop a Brev machine that's in a running state"
-	stopExample = "brev stop <ws_name>"
+	stopExample = `
+  brev stop <ws_name>
+  brev stop <ws_name> <ws_name>
+  brev stop --all --yes
+  brev stop "hello-*"
+  brev stop --selector status=RUNNING,class=4x16
+	`
 )
 
 type StopStore interface {
 	completions.CompletionStore
+	resolver.ResolverStore
 	GetAllWorkspaces(options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	StopWorkspace(workspaceID string) (*entity.Workspace, error)
@@ -30,6 +38,9 @@ type StopStore interface {
 }
 
 func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore StopStore) *cobra.Command {
+	bulkOptions := bulk.Options{ConfirmFuzzy: true}
+	var skipConfirm bool
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "stop",
@@ -37,22 +48,55 @@ func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore
 		Short:                 "Stop a workspace if it's running",
 		Long:                  stopLong,
 		Example:               stopExample,
-		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStopStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := stopWorkspace(args[0], t, loginStopStore)
+			err := bulkOptions.Validate(args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if bulkOptions.IsBulk(args) {
+				err = stopWorkspaces(args, bulkOptions, skipConfirm, t, loginStopStore)
+			} else {
+				err = stopWorkspace(args[0], t, loginStopStore)
+			}
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	bulk.AddFlags(cmd, &bulkOptions)
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "do not ask for confirmation when stopping by --all, --selector or pattern")
 
 	return cmd
 }
 
+func stopWorkspaces(args []string, bulkOptions bulk.Options, skipConfirm bool, t *terminal.Terminal, stopStore StopStore) error {
+	workspaces, err := bulk.GetWorkspaces(t, stopStore, args, bulkOptions)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if bulkOptions.IsPattern(args) && !skipConfirm && !bulk.Confirm("Stop", "stopped", t, workspaces) {
+		return nil
+	}
+	err = bulk.RunAndSummarize(t, workspaces, bulkOptions.Parallelism, func(workspace entity.Workspace) (string, error) {
+		if workspace.Status != entity.WorkspaceRunningStatus {
+			return "skipped, " + strings.ToLower(workspace.Status), nil
+		}
+		_, err := stopStore.StopWorkspace(workspace.ID)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return "stopping", nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func stopWorkspace(workspaceName string, t *terminal.Terminal, stopStore StopStore) error {
-	workspace, err := getWorkspaceFromNameOrID(workspaceName, stopStore)
+	workspace, err := resolver.NewWorkspaceResolver(t, stopStore).WithConfirmFuzzy(true).GetWorkspaceFromNameOrID(workspaceName)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -68,76 +112,22 @@ func stopWorkspace(workspaceName string, t *terminal.Terminal, stopStore StopSto
 	return nil
 }
 
+// StopThisWorkspace stops the workspace the cli is running in
 func StopThisWorkspace(store StopStore, _ *terminal.Terminal) error {
 	isWorkspace, err := store.IsWorkspace()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if isWorkspace {
-		_ = 0
-		// get current workspace
-		// stopWorkspace("")
-		// stop the workspace
-	} else {
+	if !isWorkspace {
 		return breverrors.NewValidationError("this is not a workspace -- please provide a workspace id")
 	}
-	return nil
-}
-
-// NOTE: this function is copy/pasted in many places. If you modify it, modify it elsewhere.
-// Reasoning: there wasn't a utils file so I didn't know where to put it
-//                + not sure how to pass a generic "store" object
-func getWorkspaceFromNameOrID(nameOrID string, sstore StopStore) (*entity.WorkspaceWithMeta, error) {
-	// Get Active Org
-	org, err := sstore.GetActiveOrganizationOrDefault()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-	if org == nil {
-		return nil, breverrors.NewValidationError("no orgs exist")
-	}
-
-	// Get Current User
-	currentUser, err := sstore.GetCurrentUser()
-	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-
-	// Get Workspaces for User
-	var workspace *entity.Workspace // this will be the returned workspace
-	workspaces, err := sstore.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{Name: nameOrID, UserID: currentUser.ID})
+	workspaceID, err := store.GetCurrentWorkspaceID()
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
-	}
-
-	switch len(workspaces) {
-	case 0:
-		// In this case, check workspace by ID
-		wsbyid, othererr := sstore.GetWorkspace(nameOrID) // Note: workspaceName is ID in this case
-		if othererr != nil {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-		if wsbyid != nil {
-			workspace = wsbyid
-		} else {
-			// Can this case happen?
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
-		}
-	case 1:
-		workspace = &workspaces[0]
-	default:
-		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple workspaces found with name %s\n\nTry running the command by id instead of name:\n\tbrev command <id>", nameOrID))
-	}
-
-	if workspace == nil {
-		return nil, breverrors.NewValidationError(fmt.Sprintf("no workspaces found with name or id %s", nameOrID))
+		return breverrors.WrapAndTrace(err)
 	}
-
-	// Get WorkspaceMetaData
-	workspaceMetaData, err := sstore.GetWorkspaceMetaData(workspace.ID)
+	_, err = store.StopWorkspace(workspaceID)
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return breverrors.WrapAndTrace(err)
 	}
-
-	return &entity.WorkspaceWithMeta{WorkspaceMetaData: *workspaceMetaData, Workspace: *workspace}, nil
+	return nil
 }
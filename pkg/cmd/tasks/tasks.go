@@ -32,6 +32,7 @@ type TaskStore interface {
 	vpn.ServiceMeshStore
 	server.RPCServerTaskStore
 	ssh.ConfigUpaterFactoryStore
+	ssh.SSHConfigTargetStore
 }
 
 func NewCmdTasks(t *terminal.Terminal, store TaskStore) *cobra.Command {
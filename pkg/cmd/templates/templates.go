@@ -0,0 +1,244 @@
+// Package templates lists the workspace templates available to an org
+package templates
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/cmdoutput"
+	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	templatesLong = `Workspace templates set the image a workspace runs. Pass a template name or
+id to 'brev start --template' to create a workspace from it.`
+	templatesExample = `
+  brev templates ls
+  brev templates show ubuntu
+  brev start https://github.com/brevdev/microservices-demo.git --template ubuntu
+	`
+)
+
+type TemplatesStore interface {
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetWorkspaceTemplates(organizationID string) ([]entity.WorkspaceTemplate, error)
+}
+
+func NewCmdTemplates(t *terminal.Terminal, templatesStore TemplatesStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "templates",
+		DisableFlagsInUseLine: true,
+		Short:                 "List available workspace templates",
+		Long:                  templatesLong,
+		Example:               templatesExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := cmdcontext.InvokeParentPersistentPreRun(cmd, args)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunList(t, templatesStore, cmdoutput.TableFormat)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(NewCmdTemplatesLs(t, templatesStore))
+	cmd.AddCommand(NewCmdTemplatesShow(t, templatesStore))
+
+	return cmd
+}
+
+func NewCmdTemplatesLs(t *terminal.Terminal, templatesStore TemplatesStore) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List workspace templates",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunList(t, templatesStore, format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmdoutput.AddOutputFlag(cmd, &output)
+
+	return cmd
+}
+
+func NewCmdTemplatesShow(t *terminal.Terminal, templatesStore TemplatesStore) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show a workspace template",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			templates, err := getTemplates(templatesStore)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			names := []string{}
+			for _, wt := range templates {
+				names = append(names, wt.Name)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmdoutput.ParseFormat(output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunShow(t, templatesStore, args[0], format)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmdoutput.AddOutputFlag(cmd, &output)
+
+	return cmd
+}
+
+func getTemplates(templatesStore TemplatesStore) ([]entity.WorkspaceTemplate, error) {
+	org, err := templatesStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return nil, breverrors.NewValidationError("no orgs exist")
+	}
+	templates, err := templatesStore.GetWorkspaceTemplates(org.ID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+func RunList(t *terminal.Terminal, templatesStore TemplatesStore, format cmdoutput.Format) error {
+	templates, err := getTemplates(templatesStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.WriteList(os.Stdout, format, "WorkspaceTemplateList", templates)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(templates) == 0 {
+		t.Vprint(t.Yellow("No workspace templates available"))
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = cmdoutput.TableOptions()
+	header := table.Row{"NAME", "ID", "IMAGE", "PORT"}
+	if format.IsWide() {
+		header = append(header, "REGISTRY", "PUBLIC")
+	}
+	ta.AppendHeader(header)
+	for _, wt := range templates {
+		row := table.Row{wt.Name, wt.ID, wt.Image, wt.Port}
+		if format.IsWide() {
+			row = append(row, wt.RegistryURI, wt.Public)
+		}
+		ta.AppendRow(row)
+	}
+	ta.Render()
+	return nil
+}
+
+func RunShow(t *terminal.Terminal, templatesStore TemplatesStore, nameOrID string, format cmdoutput.Format) error {
+	templates, err := getTemplates(templatesStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	wt, err := ResolveTemplate(templates, nameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if format.IsMachineReadable() {
+		err = cmdoutput.Write(os.Stdout, format, wt)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	t.Vprintf("%s %s\n", t.Yellow("name:    "), wt.Name)
+	t.Vprintf("%s %s\n", t.Yellow("id:      "), wt.ID)
+	t.Vprintf("%s %s\n", t.Yellow("image:   "), wt.Image)
+	t.Vprintf("%s %s\n", t.Yellow("registry:"), wt.RegistryURI)
+	t.Vprintf("%s %d\n", t.Yellow("port:    "), wt.Port)
+	t.Vprintf("%s %t\n", t.Yellow("public:  "), wt.Public)
+	return nil
+}
+
+// ResolveTemplate matches an id exactly or a name case insensitively
+func ResolveTemplate(templates []entity.WorkspaceTemplate, nameOrID string) (*entity.WorkspaceTemplate, error) {
+	matches := []entity.WorkspaceTemplate{}
+	for i, wt := range templates {
+		if wt.ID == nameOrID {
+			return &templates[i], nil
+		}
+		if strings.EqualFold(wt.Name, nameOrID) {
+			matches = append(matches, wt)
+		}
+	}
+	if len(matches) == 1 {
+		return &matches[0], nil
+	}
+	if len(matches) > 1 {
+		ids := []string{}
+		for _, wt := range matches {
+			ids = append(ids, wt.ID)
+		}
+		return nil, breverrors.NewValidationError(fmt.Sprintf("more than one template named %s, use an id instead: %s", nameOrID, strings.Join(ids, ", ")))
+	}
+	names := []string{}
+	for _, wt := range templates {
+		names = append(names, wt.Name)
+	}
+	return nil, breverrors.NewValidationError(fmt.Sprintf("no template named %s, available templates: %s", nameOrID, strings.Join(names, ", ")))
+}
+
+// GetTemplateID resolves a template name or id against the templates of an org
+func GetTemplateID(templatesStore TemplatesStore, organizationID string, nameOrID string) (string, error) {
+	templates, err := templatesStore.GetWorkspaceTemplates(organizationID)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	wt, err := ResolveTemplate(templates, nameOrID)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return wt.ID, nil
+}
@@ -0,0 +1,58 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTemplates = []entity.WorkspaceTemplate{
+	{ID: "4nbb4lg2s", Name: "ubuntu", Image: "brevdev/ubuntu-proxy:0.3.2", Port: 22778},
+	{ID: "v7nd45zsc", Name: "dev", Image: "brevdev/ubuntu-proxy:0.3.7", Port: 22778},
+	{ID: "a1b2c3d4e", Name: "cuda", Image: "brevdev/cuda:11.7", Port: 22778},
+	{ID: "f5g6h7i8j", Name: "CUDA", Image: "acme/cuda:11.7", Port: 22778},
+}
+
+func TestResolveTemplate(t *testing.T) {
+	wt, err := ResolveTemplate(testTemplates, "ubuntu")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "4nbb4lg2s", wt.ID)
+	}
+
+	wt, err = ResolveTemplate(testTemplates, "Dev")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "v7nd45zsc", wt.ID)
+	}
+
+	wt, err = ResolveTemplate(testTemplates, "f5g6h7i8j")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "CUDA", wt.Name)
+	}
+
+	_, err = ResolveTemplate(testTemplates, "cuda")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "a1b2c3d4e")
+	}
+
+	_, err = ResolveTemplate(testTemplates, "ubuntu-20")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "available templates")
+	}
+}
+
+type mockTemplatesStore struct{}
+
+func (m mockTemplatesStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
+	return &entity.Organization{ID: "o1"}, nil
+}
+
+func (m mockTemplatesStore) GetWorkspaceTemplates(_ string) ([]entity.WorkspaceTemplate, error) {
+	return testTemplates, nil
+}
+
+func TestGetTemplateID(t *testing.T) {
+	id, err := GetTemplateID(mockTemplatesStore{}, "o1", "ubuntu")
+	assert.Nil(t, err)
+	assert.Equal(t, "4nbb4lg2s", id)
+}
@@ -2,6 +2,8 @@ package up
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/sshall"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -67,12 +69,7 @@ func (s *upOptions) Complete(t *terminal.Terminal, _ *cobra.Command, _ []string)
 		return breverrors.WrapAndTrace(err)
 	}
 
-	var runningWorkspaces []entity.WorkspaceWithMeta
-	for _, w := range workspaces {
-		if w.Status == "RUNNING" {
-			runningWorkspaces = append(runningWorkspaces, w)
-		}
-	}
+	runningWorkspaces := getRunningWorkspaces(workspaces)
 	if len(runningWorkspaces) != len(workspaces) {
 		// if above message of skipped workspaces was displayed, show how to start:
 		t.Vprint(t.Yellow("\tYou can start a workspace with %s", t.Green("$ brev start <name>")))
@@ -118,7 +115,7 @@ func (s *upOptions) Complete(t *terminal.Terminal, _ *cobra.Command, _ []string)
 		}
 	}
 
-	s.on = NewUp(runningWorkspaces, sshConfigurer, workspaceGroupClientMapper)
+	s.on = NewUp(runningWorkspaces, sshConfigurer, workspaceGroupClientMapper, s.upStore)
 	// spinner.Stop()
 	return nil
 }
@@ -133,6 +130,7 @@ type UpStore interface {
 	GetWorkspaceMetaData(workspaceID string) (*entity.WorkspaceMetaData, error)
 	GetCurrentUser() (*entity.User, error)
 	DoesJetbrainsFilePathExist() (bool, error)
+	sshall.StatusStore
 }
 
 func (s upOptions) Validate(_ *terminal.Terminal) error {
@@ -146,6 +144,7 @@ func (s upOptions) RunOn(_ *terminal.Terminal) error {
 
 type SSHConfigurer interface {
 	Sync() error
+	SetWorkspaces(workspaces []entity.WorkspaceWithMeta)
 	sshall.SSHResolver
 }
 
@@ -153,13 +152,15 @@ type Up struct {
 	sshConfigurer              SSHConfigurer
 	workspaceGroupClientMapper k8s.WorkspaceGroupClientMapper
 	workspaces                 []entity.WorkspaceWithMeta
+	upStore                    UpStore
 }
 
-func NewUp(workspaces []entity.WorkspaceWithMeta, sshConfigurer SSHConfigurer, workspaceGroupClientMapper k8s.WorkspaceGroupClientMapper) *Up {
+func NewUp(workspaces []entity.WorkspaceWithMeta, sshConfigurer SSHConfigurer, workspaceGroupClientMapper k8s.WorkspaceGroupClientMapper, upStore UpStore) *Up {
 	return &Up{
 		workspaces:                 workspaces,
 		sshConfigurer:              sshConfigurer,
 		workspaceGroupClientMapper: workspaceGroupClientMapper,
+		upStore:                    upStore,
 	}
 }
 
@@ -169,7 +170,9 @@ func (o Up) Run() error {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	sshall := sshall.NewSSHAll(o.workspaces, o.workspaceGroupClientMapper, o.sshConfigurer)
+	sshall := sshall.NewSSHAll(o.workspaces, o.workspaceGroupClientMapper, o.sshConfigurer).
+		WithWorkspaceLister(o.newWorkspaceLister()).
+		WithStatusStore(o.upStore)
 	err = sshall.Run()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -178,6 +181,49 @@ func (o Up) Run() error {
 	return nil
 }
 
+// newWorkspaceLister lists the running workspaces, adding the ones that
+// started to the ssh config so their tunnels can be opened
+func (o Up) newWorkspaceLister() sshall.WorkspaceLister {
+	configured := workspaceIDs(o.workspaces)
+	return func() ([]entity.WorkspaceWithMeta, error) {
+		workspaces, err := GetActiveWorkspaces(o.upStore)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		runningWorkspaces := getRunningWorkspaces(workspaces)
+		ids := workspaceIDs(runningWorkspaces)
+		if ids == configured {
+			return runningWorkspaces, nil
+		}
+		o.sshConfigurer.SetWorkspaces(runningWorkspaces)
+		err = o.sshConfigurer.Sync()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		configured = ids
+		return runningWorkspaces, nil
+	}
+}
+
+func workspaceIDs(workspaces []entity.WorkspaceWithMeta) string {
+	ids := []string{}
+	for _, w := range workspaces {
+		ids = append(ids, w.ID)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func getRunningWorkspaces(workspaces []entity.WorkspaceWithMeta) []entity.WorkspaceWithMeta {
+	var runningWorkspaces []entity.WorkspaceWithMeta
+	for _, w := range workspaces {
+		if w.Status == "RUNNING" {
+			runningWorkspaces = append(runningWorkspaces, w)
+		}
+	}
+	return runningWorkspaces
+}
+
 func GetActiveWorkspaces(upStore UpStore) ([]entity.WorkspaceWithMeta, error) {
 	// fmt.Println("Resolving workspaces...")
 
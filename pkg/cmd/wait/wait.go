@@ -0,0 +1,99 @@
+// Package wait blocks until a workspace reaches a state
+package wait
+
+import (
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/resolver"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/spf13/cobra"
+)
+
+const DefaultTimeout = 10 * time.Minute
+
+var (
+	waitLong    = "Wait for a workspace to be running, stopped or deleted. Exits non zero if the timeout is hit."
+	waitExample = `
+  brev wait <ws_name>
+  brev wait <ws_name> --for=stopped --timeout=5m
+  brev start <ws_name> -d && brev wait <ws_name> --for=running && brev shell <ws_name>
+	`
+)
+
+type WaitCmdStore interface {
+	completions.CompletionStore
+	resolver.ResolverStore
+	WaitStore
+}
+
+func NewCmdWait(t *terminal.Terminal, loginWaitStore WaitCmdStore, noLoginWaitStore WaitCmdStore) *cobra.Command {
+	var forState string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "wait",
+		DisableFlagsInUseLine: true,
+		Short:                 "Wait for a workspace to reach a state",
+		Long:                  waitLong,
+		Example:               waitExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginWaitStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := ParseState(forState)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunWait(t, loginWaitStore, args[0], state, timeout)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&forState, "for", string(Running), "state to wait for [running, stopped, deleted]")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultTimeout, "give up after this long, 0 waits forever")
+	err := cmd.RegisterFlagCompletionFunc("for", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		states := []string{}
+		for _, s := range States {
+			states = append(states, string(s))
+		}
+		return states, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(err)
+		t.Errprint(err, "cli err")
+	}
+
+	return cmd
+}
+
+func RunWait(t *terminal.Terminal, waitStore WaitCmdStore, workspaceName string, state State, timeout time.Duration) error {
+	workspace, err := resolver.NewWorkspaceResolver(t, waitStore).GetWorkspace(workspaceName)
+	if err != nil {
+		if _, ok := err.(resolver.WorkspaceNotFoundError); ok && state == Deleted {
+			t.Vprintf("workspace %s is %s\n", workspaceName, Deleted)
+			return nil
+		}
+		return breverrors.WrapAndTrace(err)
+	}
+
+	s := t.NewSpinner()
+	s.Suffix = "  workspace is " + strings.ToLower(workspace.Status)
+	s.Start()
+	_, err = NewWaiter(waitStore).WithTimeout(timeout).WithOnPoll(func(w *entity.Workspace) {
+		s.Suffix = "  workspace is " + strings.ToLower(w.Status)
+	}).WaitFor(workspace.ID, state)
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprint(t.Green("workspace %s is %s", workspace.Name, state))
+	return nil
+}
@@ -0,0 +1,214 @@
+package wait
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type State string
+
+const (
+	Running State = "running"
+	Stopped State = "stopped"
+	Deleted State = "deleted"
+)
+
+var States = []State{Running, Stopped, Deleted}
+
+func ParseState(s string) (State, error) {
+	for _, state := range States {
+		if strings.EqualFold(s, string(state)) {
+			return state, nil
+		}
+	}
+	return "", breverrors.NewValidationError(fmt.Sprintf("invalid state %q, must be one of running, stopped, deleted", s))
+}
+
+// status is the workspace status the api reports once the state is reached
+func (s State) status() string {
+	switch s {
+	case Running:
+		return entity.WorkspaceRunningStatus
+	case Stopped:
+		return entity.WorkspaceStoppedStatus
+	}
+	return ""
+}
+
+const (
+	DefaultInitialInterval = 2 * time.Second
+	DefaultMaxInterval     = 30 * time.Second
+	DefaultMultiplier      = 1.5
+	// DefaultJitter spreads polls by up to +/- 20% so bulk waits don't hit the api in lockstep
+	DefaultJitter = 0.2
+)
+
+type WaitStore interface {
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+type TimeoutError struct {
+	WorkspaceID string
+	State       State
+	LastStatus  string
+	Timeout     time.Duration
+}
+
+var _ breverrors.BrevError = TimeoutError{}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for workspace %s to be %s, last status was %s", e.Timeout, e.WorkspaceID, e.State, strings.ToLower(e.LastStatus))
+}
+
+func (e TimeoutError) Directive() string {
+	return "run 'brev ls' to check the workspace or wait again with a longer --timeout"
+}
+
+type FailureError struct {
+	WorkspaceID string
+	State       State
+}
+
+var _ breverrors.BrevError = FailureError{}
+
+func (e FailureError) Error() string {
+	return fmt.Sprintf("workspace %s failed while waiting for it to be %s", e.WorkspaceID, e.State)
+}
+
+func (e FailureError) Directive() string {
+	return "run 'brev reset' on the workspace or contact support"
+}
+
+// Waiter polls a workspace with exponential backoff until it reaches a state
+type Waiter struct {
+	store           WaitStore
+	Timeout         time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	// OnPoll is called with every workspace fetched, ex: to update a spinner
+	OnPoll func(workspace *entity.Workspace)
+}
+
+// NewWaiter has no timeout, set one with WithTimeout
+func NewWaiter(waitStore WaitStore) *Waiter {
+	return &Waiter{
+		store:           waitStore,
+		InitialInterval: DefaultInitialInterval,
+		MaxInterval:     DefaultMaxInterval,
+		Multiplier:      DefaultMultiplier,
+		Jitter:          DefaultJitter,
+	}
+}
+
+func (w *Waiter) WithTimeout(timeout time.Duration) *Waiter {
+	w.Timeout = timeout
+	return w
+}
+
+func (w *Waiter) WithOnPoll(onPoll func(workspace *entity.Workspace)) *Waiter {
+	w.OnPoll = onPoll
+	return w
+}
+
+// WaitFor returns the workspace once it is in state, for Deleted the
+// workspace is nil once the api no longer finds it
+func (w Waiter) WaitFor(workspaceID string, state State) (*entity.Workspace, error) {
+	var deadline time.Time
+	if w.Timeout > 0 {
+		deadline = time.Now().Add(w.Timeout)
+	}
+	interval := w.InitialInterval
+	lastStatus := ""
+	for {
+		workspace, done, err := w.poll(workspaceID, state)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if done {
+			return workspace, nil
+		}
+		lastStatus = workspace.Status
+
+		sleep := w.jitter(interval)
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return nil, TimeoutError{WorkspaceID: workspaceID, State: state, LastStatus: lastStatus, Timeout: w.Timeout}
+			}
+			if sleep > remaining {
+				sleep = remaining
+			}
+		}
+		time.Sleep(sleep)
+		interval = w.NextInterval(interval)
+	}
+}
+
+func (w Waiter) poll(workspaceID string, state State) (*entity.Workspace, bool, error) {
+	workspace, err := w.store.GetWorkspace(workspaceID)
+	if err != nil {
+		if state == Deleted && store.IsNetwork404Or403Error(err) {
+			return nil, true, nil
+		}
+		return nil, false, breverrors.WrapAndTrace(err)
+	}
+	if w.OnPoll != nil {
+		w.OnPoll(workspace)
+	}
+	if state != Deleted && workspace.Status == state.status() {
+		return workspace, true, nil
+	}
+	// a failed workspace can still be stopped or deleted so only a wait for
+	// running gives up early
+	if state == Running && workspace.Status == entity.WorkspaceFailureStatus {
+		return nil, false, FailureError{WorkspaceID: workspaceID, State: state}
+	}
+	return workspace, false, nil
+}
+
+// NextInterval grows the interval by Multiplier up to MaxInterval
+func (w Waiter) NextInterval(interval time.Duration) time.Duration {
+	next := time.Duration(float64(interval) * w.Multiplier)
+	if w.MaxInterval > 0 && next > w.MaxInterval {
+		return w.MaxInterval
+	}
+	return next
+}
+
+func (w Waiter) jitter(interval time.Duration) time.Duration {
+	if w.Jitter <= 0 {
+		return interval
+	}
+	delta := (rand.Float64()*2 - 1) * w.Jitter * float64(interval) // #nosec no need to by cryptographically secure
+	return interval + time.Duration(delta)
+}
+
+// PollUntil waits for a workspace with a spinner and no timeout, it is what
+// start and reset use after kicking off a workspace
+func PollUntil(t *terminal.Terminal, waitStore WaitStore, workspaceID string, state State, canSafelyExit bool) error {
+	s := t.NewSpinner()
+	if canSafelyExit {
+		t.Vprintf("You can safely ctrl+c to exit\n")
+	}
+	s.Suffix = " hang tight 🤙"
+	s.Start()
+	_, err := NewWaiter(waitStore).WithOnPoll(func(workspace *entity.Workspace) {
+		s.Suffix = "  workspace is " + strings.ToLower(workspace.Status)
+	}).WaitFor(workspaceID, state)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+	s.Suffix = "Workspace is ready!"
+	s.Stop()
+	return nil
+}
@@ -0,0 +1,76 @@
+package wait
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWaitStore struct {
+	statuses []string
+	calls    int
+}
+
+func (m *mockWaitStore) GetWorkspace(workspaceID string) (*entity.Workspace, error) {
+	i := m.calls
+	if i >= len(m.statuses) {
+		i = len(m.statuses) - 1
+	}
+	m.calls++
+	return &entity.Workspace{ID: workspaceID, Status: m.statuses[i]}, nil
+}
+
+func newTestWaiter(s WaitStore) *Waiter {
+	w := NewWaiter(s)
+	w.InitialInterval = time.Millisecond
+	w.MaxInterval = 5 * time.Millisecond
+	return w
+}
+
+func TestParseState(t *testing.T) {
+	s, err := ParseState("RUNNING")
+	assert.Nil(t, err)
+	assert.Equal(t, Running, s)
+
+	_, err = ParseState("paused")
+	assert.NotNil(t, err)
+}
+
+func TestWaitFor(t *testing.T) {
+	s := &mockWaitStore{statuses: []string{"STARTING", "DEPLOYING", "RUNNING"}}
+	w, err := newTestWaiter(s).WaitFor("abcd1234", Running)
+	assert.Nil(t, err)
+	assert.Equal(t, "RUNNING", w.Status)
+	assert.Equal(t, 3, s.calls)
+}
+
+func TestWaitForFailure(t *testing.T) {
+	s := &mockWaitStore{statuses: []string{"STARTING", "FAILURE"}}
+	_, err := newTestWaiter(s).WaitFor("abcd1234", Running)
+	assert.NotNil(t, err)
+
+	s = &mockWaitStore{statuses: []string{"FAILURE", "STOPPED"}}
+	_, err = newTestWaiter(s).WaitFor("abcd1234", Stopped)
+	assert.Nil(t, err)
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	s := &mockWaitStore{statuses: []string{"STOPPING"}}
+	_, err := newTestWaiter(s).WithTimeout(20*time.Millisecond).WaitFor("abcd1234", Stopped)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Contains(t, err.Error(), "stopping")
+}
+
+func TestNextInterval(t *testing.T) {
+	w := NewWaiter(nil)
+	assert.Equal(t, 3*time.Second, w.NextInterval(2*time.Second))
+	assert.Equal(t, DefaultMaxInterval, w.NextInterval(25*time.Second))
+
+	for i := 0; i < 100; i++ {
+		j := w.jitter(10 * time.Second)
+		assert.GreaterOrEqual(t, j, 8*time.Second)
+		assert.LessOrEqual(t, j, 12*time.Second)
+	}
+}
@@ -0,0 +1,70 @@
+// Package credentials keeps the serialized brev auth tokens in the OS keyring,
+// a passphrase encrypted file or, where neither can be used, a plaintext file
+package credentials
+
+import (
+	"errors"
+	"os"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/spf13/afero"
+)
+
+// ErrNotFound is returned by Get when no credentials are saved
+var ErrNotFound = errors.New("no saved credentials")
+
+type Backend interface {
+	Get() ([]byte, error)
+	Set(data []byte) error
+	// Delete is a noop when no credentials are saved
+	Delete() error
+	Name() string
+}
+
+// PlaintextFile is the credentials.json older versions and workspaces use
+type PlaintextFile struct {
+	fs   afero.Fs
+	path string
+}
+
+var _ Backend = PlaintextFile{}
+
+func NewPlaintextFile(fs afero.Fs, path string) PlaintextFile {
+	return PlaintextFile{fs: fs, path: path}
+}
+
+func (p PlaintextFile) Name() string {
+	return "file"
+}
+
+func (p PlaintextFile) Get() ([]byte, error) {
+	data, err := afero.ReadFile(p.fs, p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return data, nil
+}
+
+func (p PlaintextFile) Set(data []byte) error {
+	_, err := files.WriteFileAtomic(p.fs, p.path, data, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (p PlaintextFile) Delete() error {
+	return removeFile(p.fs, p.path)
+}
+
+func removeFile(fs afero.Fs, path string) error {
+	err := fs.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/spf13/afero"
+)
+
+const (
+	StoreEnv = "BREV_CREDENTIAL_STORE"
+
+	PlaintextFileName = "credentials.json"
+	EncryptedFileName = "credentials.enc"
+	// StoreFileName records the backend so every later run, including the
+	// ssh config daemon and ssh sessions without a session bus, reads the
+	// credentials from where they were saved
+	StoreFileName = "credential_store"
+)
+
+// Choose is the backend BREV_CREDENTIAL_STORE names (keyring, encrypted-file
+// or file), else the one recorded in ~/.brev/credential_store. Without either
+// it is the keyring when there is one, else the encrypted file, and the choice
+// is recorded
+func Choose(fs afero.Fs, brevDir string) (Backend, error) {
+	name := os.Getenv(StoreEnv)
+	if name != "" {
+		return newBackend(fs, brevDir, name, fmt.Sprintf("%s=%s", StoreEnv, name))
+	}
+	name, err := recordedStore(fs, brevDir)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if name != "" {
+		return newBackend(fs, brevDir, name, fmt.Sprintf("%s records %s", filepath.Join(brevDir, StoreFileName), name))
+	}
+	backend, err := newBackend(fs, brevDir, detectStore(), "")
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = Record(fs, brevDir, backend)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return backend, nil
+}
+
+// Record makes backend the one later runs use
+func Record(fs afero.Fs, brevDir string, backend Backend) error {
+	recorded, err := recordedStore(fs, brevDir)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if recorded == backend.Name() {
+		return nil
+	}
+	_, err = files.WriteFileAtomic(fs, filepath.Join(brevDir, StoreFileName), []byte(backend.Name()+"\n"), 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func recordedStore(fs afero.Fs, brevDir string) (string, error) {
+	data, err := afero.ReadFile(fs, filepath.Join(brevDir, StoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func detectStore() string {
+	if KeyringAvailable() {
+		return Keyring{}.Name()
+	}
+	return EncryptedFile{}.Name()
+}
+
+// newBackend is the backend called name, source says where the name came from
+func newBackend(fs afero.Fs, brevDir string, name string, source string) (Backend, error) {
+	switch name {
+	case "keyring":
+		if !KeyringAvailable() {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("%s but no Secret Service keyring is running on the session bus, run brev from your desktop session or set %s=encrypted-file and run brev login", source, StoreEnv))
+		}
+		return NewKeyring(brevDir), nil
+	case "encrypted-file":
+		return NewEncryptedFile(fs, filepath.Join(brevDir, EncryptedFileName), Passphrase), nil
+	case "file":
+		return NewPlaintextFile(fs, filepath.Join(brevDir, PlaintextFileName)), nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("%s, the credential store must be keyring, encrypted-file or file", source))
+	}
+}
+
+// GetMigrating is the credentials of backend, moving them there first from
+// legacy, the plaintext file older versions wrote. A run that can not read the
+// passphrase, like a daemon, leaves the move to the next one that can
+func GetMigrating(backend Backend, legacy PlaintextFile) ([]byte, error) {
+	data, err := backend.Get()
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if _, ok := backend.(PlaintextFile); ok {
+		return nil, ErrNotFound
+	}
+	data, err = legacy.Get()
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = backend.Set(data)
+	if errors.Is(err, ErrNoPassphrase) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, fmt.Sprintf("could not move the credentials to the %s store", backend.Name()))
+	}
+	err = legacy.Delete()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return data, nil
+}
+
+// SetReplacing saves the credentials to backend and removes the plaintext
+// ones left by older versions. Until they are moved, a run that can not read
+// the passphrase keeps updating the plaintext ones
+func SetReplacing(backend Backend, legacy PlaintextFile, data []byte) error {
+	err := backend.Set(data)
+	if errors.Is(err, ErrNoPassphrase) {
+		_, legacyErr := legacy.Get()
+		if legacyErr == nil {
+			err = legacy.Set(data)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		}
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if _, ok := backend.(PlaintextFile); ok {
+		return nil
+	}
+	err = legacy.Delete()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package credentials
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func staticPassphrase(p string) func(bool) (string, error) {
+	return func(bool) (string, error) { return p, nil }
+}
+
+func assertErrorContains(t *testing.T, err error, contains string) {
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), contains)
+	}
+}
+
+func testEncryptedFile(fs afero.Fs, passphrase string) EncryptedFile {
+	e := NewEncryptedFile(fs, "/home/me/.brev/credentials.enc", staticPassphrase(passphrase))
+	// keep the tests fast
+	e.logN = minScryptLogN
+	return e
+}
+
+func TestEncryptedFileRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	e := testEncryptedFile(fs, "hunter2")
+
+	_, err := e.Get()
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	tokens := []byte(`{"access_token":"at","refresh_token":"rt"}`)
+	assert.NoError(t, e.Set(tokens))
+	got, err := e.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, tokens, got)
+
+	raw, err := afero.ReadFile(fs, "/home/me/.brev/credentials.enc")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "refresh_token")
+	info, err := fs.Stat("/home/me/.brev/credentials.enc")
+	assert.NoError(t, err)
+	assert.Equal(t, "-rw-------", info.Mode().Perm().String())
+
+	assert.NoError(t, e.Delete())
+	_, err = e.Get()
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, e.Delete())
+}
+
+func TestEncryptedFileWrongPassphrase(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, testEncryptedFile(fs, "hunter2").Set([]byte("tokens")))
+
+	_, err := testEncryptedFile(fs, "hunter3").Get()
+	assertErrorContains(t, err, "wrong passphrase")
+
+	_, err = testEncryptedFile(fs, "").Get()
+	assertErrorContains(t, err, "can not be empty")
+}
+
+func TestEncryptedFileRejectsTampering(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	e := testEncryptedFile(fs, "hunter2")
+	assert.NoError(t, e.Set([]byte("tokens")))
+
+	raw, err := afero.ReadFile(fs, e.path)
+	assert.NoError(t, err)
+	var sealed encryptedFile
+	assert.NoError(t, json.Unmarshal(raw, &sealed))
+
+	slow := sealed
+	slow.LogN = 40
+	writeSealed(t, fs, e.path, slow)
+	_, err = e.Get()
+	assertErrorContains(t, err, "out of range")
+
+	flipped := sealed
+	flipped.Ciphertext = append([]byte{}, sealed.Ciphertext...)
+	flipped.Ciphertext[0] ^= 1
+	writeSealed(t, fs, e.path, flipped)
+	_, err = e.Get()
+	assertErrorContains(t, err, "corrupted")
+}
+
+func writeSealed(t *testing.T, fs afero.Fs, path string, sealed encryptedFile) {
+	data, err := json.Marshal(sealed)
+	assert.NoError(t, err)
+	assert.NoError(t, afero.WriteFile(fs, path, data, 0o600))
+}
+
+func TestGetMigratingMovesPlaintextCredentials(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	legacy := NewPlaintextFile(fs, "/home/me/.brev/credentials.json")
+	assert.NoError(t, afero.WriteFile(fs, legacy.path, []byte(`{"access_token":"at"}`), 0o644))
+	e := testEncryptedFile(fs, "hunter2")
+
+	data, err := GetMigrating(e, legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"at"}`, string(data))
+	exists, err := afero.Exists(fs, legacy.path)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	data, err = e.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"at"}`, string(data))
+}
+
+func TestGetMigratingKeepsPlaintextWhenTheBackendFails(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	legacy := NewPlaintextFile(fs, "/home/me/.brev/credentials.json")
+	assert.NoError(t, afero.WriteFile(fs, legacy.path, []byte(`{"access_token":"at"}`), 0o644))
+
+	_, err := GetMigrating(testEncryptedFile(fs, ""), legacy)
+	assert.Error(t, err)
+	exists, err := afero.Exists(fs, legacy.path)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestGetMigratingNothingSaved(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	legacy := NewPlaintextFile(fs, "/home/me/.brev/credentials.json")
+
+	_, err := GetMigrating(testEncryptedFile(fs, "hunter2"), legacy)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = GetMigrating(legacy, legacy)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSetReplacing(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	legacy := NewPlaintextFile(fs, "/home/me/.brev/credentials.json")
+	assert.NoError(t, afero.WriteFile(fs, legacy.path, []byte(`{"access_token":"old"}`), 0o644))
+
+	assert.NoError(t, SetReplacing(testEncryptedFile(fs, "hunter2"), legacy, []byte(`{"access_token":"new"}`)))
+	exists, err := afero.Exists(fs, legacy.path)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, SetReplacing(legacy, legacy, []byte(`{"access_token":"plain"}`)))
+	data, err := legacy.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"plain"}`, string(data))
+}
+
+func TestChoose(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path=/nonexistent/bus")
+
+	t.Setenv(StoreEnv, "file")
+	backend, err := Choose(fs, "/home/me/.brev")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", backend.Name())
+
+	t.Setenv(StoreEnv, "encrypted-file")
+	backend, err = Choose(fs, "/home/me/.brev")
+	assert.NoError(t, err)
+	assert.Equal(t, "encrypted-file", backend.Name())
+
+	t.Setenv(StoreEnv, "keyring")
+	_, err = Choose(fs, "/home/me/.brev")
+	assertErrorContains(t, err, "no Secret Service")
+
+	t.Setenv(StoreEnv, "vault")
+	_, err = Choose(fs, "/home/me/.brev")
+	assert.Error(t, err)
+
+	// the variable only picks the backend of this run
+	exists, err := afero.Exists(fs, "/home/me/.brev/credential_store")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the recorded backend is used even where another would be detected
+	t.Setenv(StoreEnv, "")
+	assert.NoError(t, Record(fs, "/home/me/.brev", NewPlaintextFile(fs, "/home/me/.brev/credentials.json")))
+	backend, err = Choose(fs, "/home/me/.brev")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", backend.Name())
+
+	assert.NoError(t, Record(fs, "/home/me/.brev", NewKeyring("/home/me/.brev")))
+	_, err = Choose(fs, "/home/me/.brev")
+	assertErrorContains(t, err, "credential_store records keyring but no Secret Service")
+}
+
+func TestChooseDetects(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path=/nonexistent/bus")
+	t.Setenv(StoreEnv, "")
+
+	fs := afero.NewMemMapFs()
+	backend, err := Choose(fs, "/home/me/.brev")
+	assert.NoError(t, err)
+	assert.Equal(t, "encrypted-file", backend.Name())
+	recorded, err := afero.ReadFile(fs, "/home/me/.brev/credential_store")
+	assert.NoError(t, err)
+	assert.Equal(t, "encrypted-file\n", string(recorded))
+}
+
+func TestEncryptedFileConfirmsWhenCreated(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var confirms []bool
+	e := NewEncryptedFile(fs, "/home/me/.brev/credentials.enc", func(confirm bool) (string, error) {
+		confirms = append(confirms, confirm)
+		return "hunter2", nil
+	})
+	e.logN = minScryptLogN
+
+	assert.NoError(t, e.Set([]byte("tokens")))
+	_, err := e.Get()
+	assert.NoError(t, err)
+	assert.NoError(t, e.Set([]byte("new tokens")))
+	assert.Equal(t, []bool{true, false, false}, confirms)
+}
+
+func TestGetMigratingWaitsForAPassphrase(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	legacy := NewPlaintextFile(fs, "/home/me/.brev/credentials.json")
+	assert.NoError(t, afero.WriteFile(fs, legacy.path, []byte(`{"access_token":"at"}`), 0o600))
+	e := NewEncryptedFile(fs, "/home/me/.brev/credentials.enc", func(bool) (string, error) {
+		return "", ErrNoPassphrase
+	})
+
+	data, err := GetMigrating(e, legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"at"}`, string(data))
+	exists, err := afero.Exists(fs, legacy.path)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// refreshed tokens stay where they can be read
+	assert.NoError(t, SetReplacing(e, legacy, []byte(`{"access_token":"new"}`)))
+	data, err = legacy.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"new"}`, string(data))
+
+	assert.NoError(t, legacy.Delete())
+	assert.ErrorIs(t, SetReplacing(e, legacy, []byte(`{"access_token":"new"}`)), ErrNoPassphrase)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "passphrase"), []byte("from file\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, SystemdCredentialName), []byte("from systemd"), 0o600))
+
+	t.Setenv(PassphraseFileEnv, filepath.Join(dir, "passphrase"))
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	p, err := readPassphrase(true)
+	assert.NoError(t, err)
+	assert.Equal(t, "from file", p)
+
+	t.Setenv(PassphraseFileEnv, "")
+	p, err = readPassphrase(true)
+	assert.NoError(t, err)
+	assert.Equal(t, "from systemd", p)
+
+	t.Setenv(PassphraseFileEnv, filepath.Join(dir, "missing"))
+	_, err = readPassphrase(false)
+	assert.Error(t, err)
+}
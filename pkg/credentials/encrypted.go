@@ -0,0 +1,163 @@
+package credentials
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// the file is JSON holding the XChaCha20-Poly1305 sealed credentials and the
+// scrypt parameters that derive the key from the passphrase
+const (
+	encryptedFileVersion = 1
+	encryptedFileKDF     = "scrypt"
+	defaultScryptLogN    = 15
+	// bounds for the work factor read from a file, so a tampered file can not
+	// make every command hang
+	minScryptLogN = 10
+	maxScryptLogN = 22
+	scryptSaltLen = 16
+)
+
+var encryptedFileAAD = []byte("brev-credentials-v1")
+
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	LogN       int    `json:"log_n"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFile is for machines without a keyring, the credentials are
+// encrypted with a key derived from a passphrase
+type EncryptedFile struct {
+	fs   afero.Fs
+	path string
+	// passphrase is asked to confirm it when the file is created
+	passphrase func(confirm bool) (string, error)
+	logN       int
+}
+
+var _ Backend = EncryptedFile{}
+
+func NewEncryptedFile(fs afero.Fs, path string, passphrase func(confirm bool) (string, error)) EncryptedFile {
+	return EncryptedFile{fs: fs, path: path, passphrase: passphrase, logN: defaultScryptLogN}
+}
+
+func (e EncryptedFile) Name() string {
+	return "encrypted-file"
+}
+
+func (e EncryptedFile) Exists() (bool, error) {
+	exists, err := afero.Exists(e.fs, e.path)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return exists, nil
+}
+
+func (e EncryptedFile) Get() ([]byte, error) {
+	data, err := afero.ReadFile(e.fs, e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var sealed encryptedFile
+	err = json.Unmarshal(data, &sealed)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, e.path)
+	}
+	if sealed.Version != encryptedFileVersion || sealed.KDF != encryptedFileKDF {
+		return nil, fmt.Errorf("%s: unsupported version %d with kdf %q", e.path, sealed.Version, sealed.KDF)
+	}
+	if sealed.LogN < minScryptLogN || sealed.LogN > maxScryptLogN {
+		return nil, fmt.Errorf("%s: scrypt work factor %d out of range", e.path, sealed.LogN)
+	}
+
+	aead, err := e.aead(sealed.Salt, sealed.LogN, false)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%s: bad nonce", e.path)
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, encryptedFileAAD)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("could not decrypt %s, wrong passphrase or the file is corrupted", e.path))
+	}
+	return plaintext, nil
+}
+
+func (e EncryptedFile) Set(data []byte) error {
+	exists, err := e.Exists()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	salt := make([]byte, scryptSaltLen)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	aead, err := e.aead(salt, e.logN, !exists)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	sealed, err := json.MarshalIndent(encryptedFile{
+		Version:    encryptedFileVersion,
+		KDF:        encryptedFileKDF,
+		LogN:       e.logN,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, data, encryptedFileAAD),
+	}, "", " ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(e.fs, e.path, sealed, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (e EncryptedFile) Delete() error {
+	return removeFile(e.fs, e.path)
+}
+
+func (e EncryptedFile) aead(salt []byte, logN int, confirm bool) (cipher.AEAD, error) {
+	passphrase, err := e.passphrase(confirm)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if passphrase == "" {
+		return nil, breverrors.NewValidationError("the credentials passphrase can not be empty")
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<logN, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,300 @@
+//go:build linux
+// +build linux
+
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/godbus/dbus/v5"
+)
+
+// the freedesktop Secret Service API, provided by gnome-keyring and KWallet
+const (
+	secretServiceName       = "org.freedesktop.secrets"
+	secretServicePath       = dbus.ObjectPath("/org/freedesktop/secrets")
+	defaultCollectionPath   = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretServiceInterface  = "org.freedesktop.Secret.Service"
+	secretCollectionIface   = "org.freedesktop.Secret.Collection"
+	secretItemInterface     = "org.freedesktop.Secret.Item"
+	secretPromptInterface   = "org.freedesktop.Secret.Prompt"
+	secretSessionInterface  = "org.freedesktop.Secret.Session"
+	noPrompt                = dbus.ObjectPath("/")
+	keyringPromptTimeout    = 2 * time.Minute
+	keyringItemLabel        = "brev credentials"
+	keyringItemContentType  = "application/json"
+	keyringApplicationValue = "brev-cli"
+)
+
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// Keyring keeps the credentials as an item of the default Secret Service
+// collection
+type Keyring struct {
+	attributes map[string]string
+	connect    func() (*dbus.Conn, error)
+}
+
+var _ Backend = Keyring{}
+
+// NewKeyring is the keyring item for account, the brev directory, so each
+// home brev is used with gets its own item
+func NewKeyring(account string) Keyring {
+	return Keyring{
+		attributes: map[string]string{
+			"application": keyringApplicationValue,
+			"account":     account,
+		},
+		connect: connectSessionBus,
+	}
+}
+
+// KeyringAvailable is whether a session bus is running with a Secret Service
+// on it or one that can be started
+func KeyringAvailable() bool {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return false
+	}
+	defer conn.Close() //nolint:errcheck // nothing was written
+	return hasSecretService(conn)
+}
+
+func hasSecretService(conn *dbus.Conn) bool {
+	var owned bool
+	err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, secretServiceName).Store(&owned)
+	if err == nil && owned {
+		return true
+	}
+	var activatable []string
+	err = conn.BusObject().Call("org.freedesktop.DBus.ListActivatableNames", 0).Store(&activatable)
+	if err != nil {
+		return false
+	}
+	for _, name := range activatable {
+		if name == secretServiceName {
+			return true
+		}
+	}
+	return false
+}
+
+// connectSessionBus never launches a bus, a headless box without one uses
+// another backend
+func connectSessionBus() (*dbus.Conn, error) {
+	conn, err := dbus.SessionBusPrivateNoAutoStartup()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = conn.Auth(nil)
+	if err == nil {
+		err = conn.Hello()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return conn, nil
+}
+
+func (k Keyring) Name() string {
+	return "keyring"
+}
+
+func (k Keyring) Get() ([]byte, error) {
+	var data []byte
+	err := k.withSession(func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		items, err := k.unlockedItems(conn)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if len(items) == 0 {
+			return ErrNotFound
+		}
+		var secret secretServiceSecret
+		err = conn.Object(secretServiceName, items[0]).Call(secretItemInterface+".GetSecret", 0, session).Store(&secret)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		data = secret.Value
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return data, nil
+}
+
+func (k Keyring) Set(data []byte) error {
+	err := k.withSession(func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		collection, err := k.defaultCollection(conn)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = k.unlock(conn, []dbus.ObjectPath{collection})
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		properties := map[string]dbus.Variant{
+			secretItemInterface + ".Label":      dbus.MakeVariant(keyringItemLabel),
+			secretItemInterface + ".Attributes": dbus.MakeVariant(k.attributes),
+		}
+		secret := secretServiceSecret{Session: session, Parameters: []byte{}, Value: data, ContentType: keyringItemContentType}
+		var item, prompt dbus.ObjectPath
+		// replace the item with the same attributes
+		err = conn.Object(secretServiceName, collection).Call(secretCollectionIface+".CreateItem", 0, properties, secret, true).Store(&item, &prompt)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = k.prompt(conn, prompt)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (k Keyring) Delete() error {
+	conn, err := k.connect()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer conn.Close() //nolint:errcheck // done with the bus
+	items, err := k.unlockedItems(conn)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		err = conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = k.prompt(conn, prompt)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+// withSession runs fn with a session secrets are passed in, plain since the
+// session bus only reaches the user's own processes
+func (k Keyring) withSession(fn func(conn *dbus.Conn, session dbus.ObjectPath) error) error {
+	conn, err := k.connect()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer conn.Close() //nolint:errcheck // done with the bus
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer conn.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0) //nolint:errcheck // closed with the connection anyway
+
+	return fn(conn, session)
+}
+
+// unlockedItems are the items with the brev attributes, unlocking the locked
+// ones, which can prompt for the keyring password
+func (k Keyring) unlockedItems(conn *dbus.Conn) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".SearchItems", 0, k.attributes).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(locked) == 0 {
+		return unlocked, nil
+	}
+	err = k.unlock(conn, locked)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return append(unlocked, locked...), nil
+}
+
+func (k Keyring) unlock(conn *dbus.Conn, objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = k.prompt(conn, prompt)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (k Keyring) defaultCollection(conn *dbus.Conn) (dbus.ObjectPath, error) {
+	var collection dbus.ObjectPath
+	err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".ReadAlias", 0, "default").Store(&collection)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if collection == noPrompt {
+		return defaultCollectionPath, nil
+	}
+	return collection, nil
+}
+
+// prompt shows a prompt of the keyring, like for its password, and waits for
+// the user to answer it
+func (k Keyring) prompt(conn *dbus.Conn, prompt dbus.ObjectPath) (dbus.Variant, error) {
+	if prompt == noPrompt || prompt == "" {
+		return dbus.Variant{}, nil
+	}
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	err := conn.AddMatchSignal(match...)
+	if err != nil {
+		return dbus.Variant{}, breverrors.WrapAndTrace(err)
+	}
+	defer conn.RemoveMatchSignal(match...) //nolint:errcheck // closed with the connection anyway
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	err = conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err
+	if err != nil {
+		return dbus.Variant{}, breverrors.WrapAndTrace(err)
+	}
+	timeout := time.After(keyringPromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != prompt || signal.Name != secretPromptInterface+".Completed" || len(signal.Body) < 2 {
+				continue
+			}
+			if dismissed, _ := signal.Body[0].(bool); dismissed {
+				return dbus.Variant{}, breverrors.NewValidationError("the keyring prompt was dismissed")
+			}
+			result, _ := signal.Body[1].(dbus.Variant)
+			return result, nil
+		case <-timeout:
+			return dbus.Variant{}, fmt.Errorf("no answer to the keyring prompt after %s", keyringPromptTimeout)
+		}
+	}
+}
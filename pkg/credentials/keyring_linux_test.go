@@ -0,0 +1,208 @@
+//go:build linux
+// +build linux
+
+package credentials
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSecretService is the part of the Secret Service API Keyring uses, with
+// items that start locked so the unlock path runs too
+type fakeSecretService struct {
+	conn   *dbus.Conn
+	mu     sync.Mutex
+	next   int
+	items  map[dbus.ObjectPath]*fakeItem
+	locked map[dbus.ObjectPath]bool
+}
+
+type fakeItem struct {
+	service    *fakeSecretService
+	path       dbus.ObjectPath
+	attributes map[string]string
+	value      []byte
+}
+
+type fakeCollection struct {
+	service *fakeSecretService
+}
+
+func (s *fakeSecretService) OpenSession(algorithm string, _ dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != "plain" {
+		return dbus.Variant{}, "", dbus.MakeFailedError(fmt.Errorf("unsupported algorithm %s", algorithm))
+	}
+	return dbus.MakeVariant(""), "/org/freedesktop/secrets/session/1", nil
+}
+
+func (s *fakeSecretService) SearchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlocked, locked := []dbus.ObjectPath{}, []dbus.ObjectPath{}
+	for path, item := range s.items {
+		if !matches(item.attributes, attributes) {
+			continue
+		}
+		if s.locked[path] {
+			locked = append(locked, path)
+		} else {
+			unlocked = append(unlocked, path)
+		}
+	}
+	return unlocked, locked, nil
+}
+
+func matches(have map[string]string, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *fakeSecretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range objects {
+		delete(s.locked, o)
+	}
+	return objects, "/", nil
+}
+
+func (s *fakeSecretService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	return "/org/freedesktop/secrets/collection/login", nil
+}
+
+func (c *fakeCollection) CreateItem(properties map[string]dbus.Variant, secret secretServiceSecret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := c.service
+	var attributes map[string]string
+	err := properties[secretItemInterface+".Attributes"].Store(&attributes)
+	if err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if replace {
+		for _, item := range s.items {
+			if matches(item.attributes, attributes) && matches(attributes, item.attributes) {
+				item.value = secret.Value
+				return item.path, "/", nil
+			}
+		}
+	}
+	s.next++
+	item := &fakeItem{
+		service:    s,
+		path:       dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/collection/login/%d", s.next)),
+		attributes: attributes,
+		value:      secret.Value,
+	}
+	s.items[item.path] = item
+	err = s.conn.Export(item, item.path, secretItemInterface)
+	if err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+	return item.path, "/", nil
+}
+
+func (i *fakeItem) GetSecret(session dbus.ObjectPath) (secretServiceSecret, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+	if i.service.locked[i.path] {
+		return secretServiceSecret{}, dbus.NewError("org.freedesktop.Secret.Error.IsLocked", nil)
+	}
+	return secretServiceSecret{Session: session, Parameters: []byte{}, Value: i.value, ContentType: "text/plain"}, nil
+}
+
+func (i *fakeItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+	delete(i.service.items, i.path)
+	_ = i.service.conn.Export(nil, i.path, secretItemInterface)
+	return "/", nil
+}
+
+func startFakeSecretService(t *testing.T) (string, *fakeSecretService) {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address=1", "--address=unix:tmpdir="+t.TempDir()) //nolint:gosec // test bus
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	if !assert.NoError(t, cmd.Start()) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	address = strings.TrimSpace(address)
+
+	conn, err := dbus.Connect(address)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	service := &fakeSecretService{conn: conn, items: map[dbus.ObjectPath]*fakeItem{}, locked: map[dbus.ObjectPath]bool{}}
+	assert.NoError(t, conn.Export(service, secretServicePath, secretServiceInterface))
+	assert.NoError(t, conn.Export(&fakeCollection{service: service}, "/org/freedesktop/secrets/collection/login", secretCollectionIface))
+	reply, err := conn.RequestName(secretServiceName, dbus.NameFlagDoNotQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+	return address, service
+}
+
+func TestKeyring(t *testing.T) {
+	address, service := startFakeSecretService(t)
+	connect := func() (*dbus.Conn, error) {
+		return dbus.Connect(address)
+	}
+	k := NewKeyring("/home/me/.brev")
+	k.connect = connect
+	other := NewKeyring("/home/other/.brev")
+	other.connect = connect
+
+	conn, err := connect()
+	assert.NoError(t, err)
+	assert.True(t, hasSecretService(conn))
+	_ = conn.Close()
+
+	_, err = k.Get()
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, k.Set([]byte(`{"access_token":"at"}`)))
+	assert.NoError(t, other.Set([]byte(`{"access_token":"other"}`)))
+	assert.NoError(t, k.Set([]byte(`{"access_token":"new"}`)))
+	// a locked keyring is unlocked before reading
+	service.mu.Lock()
+	assert.Len(t, service.items, 2)
+	for path := range service.items {
+		service.locked[path] = true
+	}
+	service.mu.Unlock()
+	data, err := k.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"new"}`, string(data))
+
+	assert.NoError(t, k.Delete())
+	_, err = k.Get()
+	assert.ErrorIs(t, err, ErrNotFound)
+	data, err = other.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"access_token":"other"}`, string(data))
+	assert.NoError(t, k.Delete())
+}
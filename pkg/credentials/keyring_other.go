@@ -0,0 +1,42 @@
+//go:build !linux
+// +build !linux
+
+package credentials
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Keyring is only implemented with the Secret Service on linux
+type Keyring struct{}
+
+var _ Backend = Keyring{}
+
+func NewKeyring(_ string) Keyring {
+	return Keyring{}
+}
+
+func KeyringAvailable() bool {
+	return false
+}
+
+func (k Keyring) Name() string {
+	return "keyring"
+}
+
+func (k Keyring) Get() ([]byte, error) {
+	return nil, errKeyringUnsupported()
+}
+
+func (k Keyring) Set(_ []byte) error {
+	return errKeyringUnsupported()
+}
+
+func (k Keyring) Delete() error {
+	return errKeyringUnsupported()
+}
+
+func errKeyringUnsupported() error {
+	return fmt.Errorf("the keyring credential store is not supported on %s", runtime.GOOS)
+}
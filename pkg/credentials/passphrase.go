@@ -0,0 +1,100 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"golang.org/x/term"
+)
+
+const (
+	// PassphraseFileEnv names a file to read the passphrase from, a
+	// /dev/fd/N path hands it over on a descriptor
+	PassphraseFileEnv = "BREV_CREDENTIAL_PASSPHRASE_FILE"
+	// SystemdCredentialName is read from $CREDENTIALS_DIRECTORY, so a unit
+	// with LoadCredential=brev-credentials-passphrase:PATH can unlock the file
+	SystemdCredentialName = "brev-credentials-passphrase"
+)
+
+// ErrNoPassphrase is returned when the passphrase can not be read without a
+// terminal
+var ErrNoPassphrase = errors.New("no passphrase for the encrypted brev credentials")
+
+var (
+	passphraseMu     sync.Mutex
+	cachedPassphrase string
+)
+
+// Passphrase is the passphrase of the encrypted credentials file, read once
+// per run from BREV_CREDENTIAL_PASSPHRASE_FILE, a systemd credential or the
+// terminal. The passphrase itself is never read from the environment. confirm
+// asks for it twice on the terminal, for a file that is about to be created
+func Passphrase(confirm bool) (string, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+	p, err := readPassphrase(confirm)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	cachedPassphrase = p
+	return cachedPassphrase, nil
+}
+
+func readPassphrase(confirm bool) (string, error) {
+	if path := os.Getenv(PassphraseFileEnv); path != "" {
+		return readPassphraseFile(path)
+	}
+	if dir := os.Getenv("CREDENTIALS_DIRECTORY"); dir != "" {
+		p, err := readPassphraseFile(filepath.Join(dir, SystemdCredentialName))
+		if !errors.Is(err, os.ErrNotExist) {
+			return p, err
+		}
+	}
+	stdin := int(os.Stdin.Fd()) //nolint:gosec // fd fits in an int
+	if !term.IsTerminal(stdin) {
+		return "", breverrors.WrapAndTrace(ErrNoPassphrase, fmt.Sprintf("set %s to a file holding it, load it as the %s systemd credential or run brev on a terminal", PassphraseFileEnv, SystemdCredentialName))
+	}
+	p, err := promptPassphrase(stdin, "Passphrase for brev credentials: ")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if !confirm {
+		return p, nil
+	}
+	again, err := promptPassphrase(stdin, "Confirm passphrase: ")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if p != again {
+		return "", breverrors.NewValidationError("the passphrases do not match")
+	}
+	return p, nil
+}
+
+func promptPassphrase(stdin int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(stdin)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(p), nil
+}
+
+// readPassphraseFile is the first line of path, so echo and editors can write it
+func readPassphraseFile(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the user names the file
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	p, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSuffix(p, "\r"), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type AuthTokens struct {
@@ -37,6 +38,30 @@ type UserKeys struct {
 	WorkspaceGroups []WorkspaceGroupKeys `json:"workspaceGroups"`
 }
 
+// UpdateUserKeys replaces the keypair the server gives every machine and
+// workspace of the user
+type UpdateUserKeys struct {
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+}
+
+// SSHKeyRotation is the last time brev ssh-key rotate replaced the private
+// key, workspaces that were running then still only know the previous key
+type SSHKeyRotation struct {
+	PublicKey           string    `json:"publicKey"`
+	RotatedAt           time.Time `json:"rotatedAt"`
+	PendingWorkspaceIDs []string  `json:"pendingWorkspaceIds,omitempty"`
+}
+
+func (r SSHKeyRotation) IsPending(workspaceID string) bool {
+	for _, id := range r.PendingWorkspaceIDs {
+		if id == workspaceID {
+			return true
+		}
+	}
+	return false
+}
+
 type WorkspaceGroupKeys struct {
 	GroupID string `json:"groupId"`
 	Cert    string `json:"cert"`
@@ -111,6 +136,8 @@ const (
 	WorkspaceStartingStatus = "STARTING"
 	WorkspaceStoppingStatus = "STOPPING"
 	WorkspaceDeletingStatus = "DELETING"
+	WorkspaceStoppedStatus  = "STOPPED"
+	WorkspaceFailureStatus  = "FAILURE"
 )
 
 type Workspace struct {
@@ -149,6 +176,15 @@ type WorkspaceTemplate struct {
 	Port        int    `json:"port"`
 }
 
+// WorkspaceClass is the resources a workspace runs with, the ID is cpu x memory like "4x16"
+type WorkspaceClass struct {
+	ID           string  `json:"id"`
+	CPU          int     `json:"cpu"`
+	MemoryGB     int     `json:"memoryGb"`
+	PricePerHour float64 `json:"pricePerHour"`
+	GPU          string  `json:"gpu,omitempty"`
+}
+
 const featureSimpleNames = false
 
 func (w Workspace) GetLocalIdentifier() WorkspaceLocalID {
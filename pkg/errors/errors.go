@@ -119,6 +119,18 @@ type DeclineToLoginError struct{}
 func (d *DeclineToLoginError) Error() string     { return "declined to login" }
 func (d *DeclineToLoginError) Directive() string { return "log in to run this command" }
 
+// ExitCodeError ends brev with the exit code of a command it ran, without
+// printing or reporting an error
+type ExitCodeError struct {
+	Code int
+}
+
+var _ error = ExitCodeError{}
+
+func (e ExitCodeError) Error() string {
+	return fmt.Sprintf("exited with code %d", e.Code)
+}
+
 func WrapAndTrace(err error, messages ...string) error {
 	message := ""
 	for _, m := range messages {
@@ -0,0 +1,115 @@
+package files
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// WriteFileAtomic writes data to a temp file next to path and renames it over
+// path so a reader never sees a partial file. Nothing is written and false is
+// returned when path already has the same content.
+func WriteFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) (bool, error) {
+	path, err := resolveSymlink(fs, path)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return writeFileAtomic(fs, path, data, perm, nil)
+}
+
+// WriteFileAtomicAs is WriteFileAtomic with prepare run on the temp file
+// before the rename, like a chown, so path never has the wrong owner. A
+// symlink at path is replaced instead of followed.
+func WriteFileAtomicAs(fs afero.Fs, path string, data []byte, perm os.FileMode, prepare func(tmpPath string) error) (bool, error) {
+	return writeFileAtomic(fs, path, data, perm, prepare)
+}
+
+func writeFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode, prepare func(tmpPath string) error) (bool, error) {
+	unchanged, err := HasContent(fs, path, data)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	if unchanged {
+		return false, nil
+	}
+	// keep the mode of the file being replaced
+	info, err := fs.Stat(path)
+	if err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	err = fs.MkdirAll(dir, 0o755)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	tmp, err := afero.TempFile(fs, dir, "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	tmpPath := tmp.Name()
+	err = writeAndSync(tmp, data)
+	if err == nil {
+		err = fs.Chmod(tmpPath, perm)
+	}
+	if err == nil && prepare != nil {
+		err = prepare(tmpPath)
+	}
+	if err == nil {
+		err = fs.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = fs.Remove(tmpPath)
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return true, nil
+}
+
+func writeAndSync(f afero.File, data []byte) error {
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if closeErr != nil {
+		return breverrors.WrapAndTrace(closeErr)
+	}
+	return nil
+}
+
+// HasContent compares content hashes, a missing file has no content
+func HasContent(fs afero.Fs, path string, data []byte) (bool, error) {
+	existing, err := afero.ReadFile(fs, path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	existingSum := sha256.Sum256(existing)
+	dataSum := sha256.Sum256(data)
+	return bytes.Equal(existingSum[:], dataSum[:]), nil
+}
+
+// resolveSymlink makes the rename replace the target of a symlinked config,
+// like one managed by a dotfiles repo, instead of the link itself
+func resolveSymlink(fs afero.Fs, path string) (string, error) {
+	if _, ok := fs.(*afero.OsFs); !ok {
+		return path, nil
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		return path, nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,126 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/home/brev/.brev/ssh_config"
+
+	changed, err := WriteFileAtomic(fs, path, []byte("Host a\n"), 0o600)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	changed, err = WriteFileAtomic(fs, path, []byte("Host a\n"), 0o644)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	changed, err = WriteFileAtomic(fs, path, []byte("Host b\n"), 0o644)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	data, err := afero.ReadFile(fs, path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Host b\n", string(data))
+	info, err := fs.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := afero.ReadDir(fs, filepath.Dir(path))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileAtomicFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "config")
+	link := filepath.Join(dir, "config")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	assert.Nil(t, os.WriteFile(target, []byte("old"), 0o600))
+	assert.Nil(t, os.Symlink(target, link))
+
+	_, err := WriteFileAtomic(afero.NewOsFs(), link, []byte("new"), 0o644)
+	assert.Nil(t, err)
+
+	info, err := os.Lstat(link)
+	assert.Nil(t, err)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0)
+	data, err := os.ReadFile(target)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestLockFile(t *testing.T) {
+	fs := afero.NewOsFs()
+	path := filepath.Join(t.TempDir(), "ssh_config.lock")
+
+	unlock, err := LockFile(fs, path)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var mu sync.Mutex
+	order := []string{}
+	done := make(chan struct{})
+	go func() {
+		unlock2, err := LockFile(fs, path)
+		assert.Nil(t, err)
+		mu.Lock()
+		order = append(order, "second")
+		mu.Unlock()
+		assert.Nil(t, unlock2())
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	order = append(order, "first")
+	mu.Unlock()
+	assert.Nil(t, unlock())
+	<-done
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestWriteFileAtomicAs(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "secret")
+	assert.Nil(t, os.WriteFile(target, []byte("old"), 0o600))
+	assert.Nil(t, os.Symlink(target, link))
+
+	prepared := ""
+	changed, err := WriteFileAtomicAs(afero.NewOsFs(), link, []byte("new"), 0o600, func(tmpPath string) error {
+		prepared = tmpPath
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, dir, filepath.Dir(prepared))
+
+	// the link is replaced, its target is untouched
+	info, err := os.Lstat(link)
+	assert.Nil(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	data, err := os.ReadFile(target)
+	assert.Nil(t, err)
+	assert.Equal(t, "old", string(data))
+
+	_, err = WriteFileAtomicAs(afero.NewOsFs(), link, []byte("newer"), 0o600, func(string) error {
+		return os.ErrPermission
+	})
+	assert.NotNil(t, err)
+	data, err = os.ReadFile(link)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", string(data))
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
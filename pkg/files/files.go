@@ -12,7 +12,6 @@ import (
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"golang.org/x/text/encoding/charmap"
 
-	"github.com/google/uuid"
 	"github.com/spf13/afero"
 )
 
@@ -22,11 +21,26 @@ const (
 	activeOrgFile      = "active_org.json"
 	orgCacheFile       = "org_cache.json"
 	workspaceCacheFile = "workspace_cache.json"
-	// WIP: This will be used to let people "brev open" with editors other than VS Code
+	schedulesFile      = "schedules.json"
+	snapshotsDirectory = "snapshots"
+	// short lived ssh certificates and the key they are issued for
+	sshCertificatesDirectory = "certs"
+	// local preferences, for now the extra ssh config targets to write to
 	personalSettingsCache         = "personal_settings.json"
 	kubeCertFileName              = "brev.crt"
 	sshPrivateKeyFileName         = "brev.pem"
-	backupSSHConfigFileNamePrefix = "config.bak"
+	backupSSHConfigFileName       = "config.bak"
+	sshConfigLockFileName         = "ssh_config.lock"
+	trampConfigFileName           = "brev-tramp.el"
+	vscodeHostsFileName           = "vscode_hosts"
+	sshOverridesFileName          = "ssh_overrides.yaml"
+	previousSSHPrivateKeyFileName = "brev-previous.pem"
+	sshKeyRotationFileName        = "ssh_key_rotation.json"
+	forwardProfilesFileName       = "forwards.yaml"
+	sshAllStatusFileName          = "sshall_status"
+	applyStateFileName            = "apply_state.json"
+	applyStateKeyFileName         = "apply_state.key"
+	knownHostsFileName            = "known_hosts"
 	tailscaleOutFileName          = "tailscale_out.log"
 	sshPrivateKeyFilePermissions  = 0o600
 	defaultFilePermission         = 0o770
@@ -54,6 +68,10 @@ func GetWorkspaceCacheFile() string {
 	return workspaceCacheFile
 }
 
+func GetSchedulesFile() string {
+	return schedulesFile
+}
+
 func GetKubeCertFileName() string {
 	return kubeCertFileName
 }
@@ -66,10 +84,6 @@ func GetTailScaleOutFileName() string {
 	return tailscaleOutFileName
 }
 
-func GetNewBackupSSHConfigFileName() string {
-	return fmt.Sprintf("%s.%s", backupSSHConfigFileNamePrefix, uuid.New())
-}
-
 func BuildBrevHome(fs afero.Fs, userHome string) error {
 	brevHome, err := GetBrevHome(userHome)
 	if err != nil {
@@ -103,6 +117,22 @@ func GetActiveOrgsPath(home string) (string, error) {
 	return *fpath, nil
 }
 
+func GetSchedulesPath(home string) (string, error) {
+	fpath, err := makeBrevFilePath(schedulesFile, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fpath, nil
+}
+
+func GetSnapshotsPath(home string) (string, error) {
+	fpath, err := makeBrevFilePath(snapshotsDirectory, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fpath, nil
+}
+
 func GetPersonalSettingsCachePath(home string) (string, error) {
 	fpath, err := makeBrevFilePath(personalSettingsCache, home)
 	if err != nil {
@@ -133,14 +163,116 @@ func GetBrevSSHConfigPath(home string) (string, error) {
 	return brevSSHConfigPath, nil
 }
 
-func GetNewBackupSSHConfigFilePath(home string) (*string, error) {
-	fp, err := makeBrevFilePath(GetNewBackupSSHConfigFileName(), home)
+// GetSSHConfigBackupPath is the copy of ~/.ssh/config taken before brev last changed it
+func GetSSHConfigBackupPath(home string) (*string, error) {
+	fp, err := makeBrevFilePath(backupSSHConfigFileName, home)
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
 	return fp, nil
 }
 
+// GetSSHConfigLockPath is the lock every writer of generated ssh config holds
+func GetSSHConfigLockPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(sshConfigLockFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetSSHOverridesPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(sshOverridesFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+// GetPreviousSSHPrivateKeyPath is the key replaced by the last key rotation
+func GetPreviousSSHPrivateKeyPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(previousSSHPrivateKeyFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetSSHKeyRotationPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(sshKeyRotationFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetForwardProfilesPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(forwardProfilesFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetApplyStatePath(home string) (string, error) {
+	fp, err := makeBrevFilePath(applyStateFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+// GetApplyStateKeyPath is the key the secret hashes in apply_state.json are
+// made with
+func GetApplyStateKeyPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(applyStateKeyFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetSSHAllStatusPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(sshAllStatusFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetKnownHostsPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(knownHostsFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+func GetSSHCertificatesPath(home string) (string, error) {
+	fpath, err := makeBrevFilePath(sshCertificatesDirectory, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fpath, nil
+}
+
+func GetTrampConfigPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(trampConfigFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
+// GetVSCodeHostsPath lists the hosts brev added to the VS Code settings
+func GetVSCodeHostsPath(home string) (string, error) {
+	fp, err := makeBrevFilePath(vscodeHostsFileName, home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return *fp, nil
+}
+
 func GetTailScaleOutFilePath(home string) (*string, error) {
 	fp, err := makeBrevFilePath(GetTailScaleOutFileName(), home)
 	if err != nil {
@@ -271,7 +403,12 @@ func WriteSSHPrivateKey(fs afero.Fs, data string, home string) error {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = afero.WriteFile(fs, pkPath, []byte(data), sshPrivateKeyFilePermissions)
+	_, err = WriteFileAtomic(fs, pkPath, []byte(data), sshPrivateKeyFilePermissions)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// the mode of an existing file is kept
+	err = fs.Chmod(pkPath, sshPrivateKeyFilePermissions)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
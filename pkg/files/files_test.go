@@ -23,9 +23,9 @@ func (s *filesTestSuite) TestGetUserSSHConfigPath() {
 	s.Nil(err)
 }
 
-func (s *filesTestSuite) TestGetNewBackupSSHConfigFilePath() {
+func (s *filesTestSuite) TestGetSSHConfigBackupPath() {
 	home, _ := os.UserHomeDir()
-	_, err := GetNewBackupSSHConfigFilePath(home)
+	_, err := GetSSHConfigBackupPath(home)
 	s.Nil(err)
 }
 
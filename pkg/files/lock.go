@@ -0,0 +1,57 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+var processLocks sync.Map // path -> *sync.Mutex
+
+// LockFile takes an advisory lock on path, blocking until any other brev
+// process holding it lets go. The lock is also held within this process so
+// goroutines and in memory file systems are serialized too. Call the
+// returned func to unlock.
+func LockFile(fs afero.Fs, path string) (func() error, error) {
+	mu, _ := processLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+
+	err := fs.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		mu.(*sync.Mutex).Unlock()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	f, err := fs.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		mu.(*sync.Mutex).Unlock()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	osFile, isOSFile := f.(*os.File)
+	if isOSFile {
+		err = lockOSFile(osFile)
+		if err != nil {
+			_ = f.Close()
+			mu.(*sync.Mutex).Unlock()
+			return nil, breverrors.WrapAndTrace(err)
+		}
+	}
+
+	return func() error {
+		defer mu.(*sync.Mutex).Unlock()
+		var unlockErr error
+		if isOSFile {
+			unlockErr = unlockOSFile(osFile)
+		}
+		closeErr := f.Close()
+		if unlockErr != nil {
+			return breverrors.WrapAndTrace(unlockErr)
+		}
+		if closeErr != nil {
+			return breverrors.WrapAndTrace(closeErr)
+		}
+		return nil
+	}, nil
+}
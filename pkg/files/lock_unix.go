@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package files
+
+import (
+	"os"
+	"syscall"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+func lockOSFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func unlockOSFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
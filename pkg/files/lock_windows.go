@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package files
+
+import "os"
+
+// ssh config syncing is not supported on windows yet so only the in process
+// lock is used there
+func lockOSFile(_ *os.File) error {
+	return nil
+}
+
+func unlockOSFile(_ *os.File) error {
+	return nil
+}
@@ -0,0 +1,119 @@
+package huproxyclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/gorilla/websocket"
+)
+
+// Dial connects to the proxy of a workspace like Run, returning the
+// connection instead of copying it to stdin and stdout
+func Dial(url string, store HubProxyStore) (net.Conn, error) {
+	conn, resp, err := dial(url, store)
+	if err != nil {
+		if resp != nil {
+			body, _ := ioutil.ReadAll(resp.Body)
+			return nil, fmt.Errorf("could not connect to the workspace proxy: %s %s", resp.Status, body)
+		}
+		return nil, errors.WrapAndTrace(err)
+	}
+	return &wsConn{conn: conn}, nil
+}
+
+// wsConn is a net.Conn over the binary messages of a websocket
+type wsConn struct {
+	conn   *websocket.Conn
+	reader io.Reader
+
+	writeMu sync.Mutex
+}
+
+var _ net.Conn = &wsConn{}
+
+func (c *wsConn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			mt, r, err := c.conn.NextReader()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return 0, io.EOF
+			}
+			if err != nil {
+				return 0, errors.WrapAndTrace(err)
+			}
+			if mt != websocket.BinaryMessage {
+				return 0, fmt.Errorf("non-binary websocket message received")
+			}
+			c.reader = r
+		}
+		n, err := c.reader.Read(b)
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err //nolint:wrapcheck // io errors are returned as is
+	}
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	err := c.conn.WriteMessage(websocket.BinaryMessage, b)
+	if err != nil {
+		return 0, errors.WrapAndTrace(err)
+	}
+	return len(b), nil
+}
+
+func (c *wsConn) Close() error {
+	c.writeMu.Lock()
+	_ = c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(writeTimeout))
+	c.writeMu.Unlock()
+	err := c.conn.Close()
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	err := c.SetReadDeadline(t)
+	if err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	err := c.conn.SetReadDeadline(t)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	err := c.conn.SetWriteDeadline(t)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package huproxyclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenStore struct{}
+
+func (tokenStore) GetAuthTokens() (*entity.AuthTokens, error) {
+	return &entity.AuthTokens{AccessToken: "token"}, nil
+}
+
+func (tokenStore) GetCurrentWorkspaceGroupID() (string, error) {
+	return "", nil
+}
+
+func TestDial(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer conn.Close() //nolint:errcheck // test
+		// echo each message back split in two
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			half := len(data) / 2
+			_ = conn.WriteMessage(websocket.BinaryMessage, data[:half])
+			_ = conn.WriteMessage(websocket.BinaryMessage, data[half:])
+		}
+	}))
+	defer server.Close()
+
+	conn, err := Dial("ws"+strings.TrimPrefix(server.URL, "http"), tokenStore{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = conn.Write([]byte("SSH-2.0-test\r\n"))
+	assert.Nil(t, err)
+	buf := make([]byte, len("SSH-2.0-test\r\n"))
+	_, err = io.ReadFull(conn, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "SSH-2.0-test\r\n", string(buf))
+	assert.Nil(t, conn.Close())
+}
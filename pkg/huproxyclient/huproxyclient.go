@@ -5,7 +5,6 @@ package huproxyclient
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -45,19 +44,46 @@ func Run(url string, store HubProxyStore) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	dialer := websocket.Dialer{}
-	dialer.TLSClientConfig = new(tls.Config)
+	head, err := makeHeaders(store)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	conn, resp, err := dialWithHeaders(url, head)
+	if err != nil {
+		dialError(url, resp, err)
+	}
+	defer conn.Close() //nolint:errcheck // lazy to refactor
 
-	head := map[string][]string{}
+	RunProxy(ctx, conn, cancel)
+
+	if ctx.Err() != nil {
+		return errors.WrapAndTrace(ctx.Err())
+	}
+	return nil
+}
+
+func dial(url string, store HubProxyStore) (*websocket.Conn, *http.Response, error) {
+	head, err := makeHeaders(store)
+	if err != nil {
+		return nil, nil, errors.WrapAndTrace(err)
+	}
+	return dialWithHeaders(url, head)
+}
+
+// makeHeaders authenticates to the proxy, its errors are from getting the
+// auth tokens and not from reaching the workspace
+func makeHeaders(store HubProxyStore) (http.Header, error) {
+	head := http.Header{}
 
 	token, err := store.GetAuthTokens()
 	if err != nil {
-		return errors.WrapAndTrace(err)
+		return nil, errors.WrapAndTrace(err)
 	}
 
 	workspaceGroupID, err := store.GetCurrentWorkspaceGroupID()
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		// stdout is the ssh connection when run by brev proxy
+		log.Warningf("%v", err)
 	}
 	if workspaceGroupID != "" {
 		head["X-Workspace-Group-ID"] = []string{workspaceGroupID}
@@ -66,19 +92,18 @@ func Run(url string, store HubProxyStore) error {
 	head["Authorization"] = []string{
 		"Bearer " + token.AccessToken,
 	}
+	return head, nil
+}
+
+func dialWithHeaders(url string, head http.Header) (*websocket.Conn, *http.Response, error) {
+	dialer := websocket.Dialer{}
+	dialer.TLSClientConfig = new(tls.Config)
 
 	conn, resp, err := dialer.Dial(url, head)
 	if err != nil {
-		dialError(url, resp, err)
+		return nil, resp, errors.WrapAndTrace(err)
 	}
-	defer conn.Close() //nolint:errcheck // lazy to refactor
-
-	RunProxy(ctx, conn, cancel)
-
-	if ctx.Err() != nil {
-		return errors.WrapAndTrace(ctx.Err())
-	}
-	return nil
+	return conn, resp, nil
 }
 
 func RunProxy(ctx context.Context, conn *websocket.Conn, cancel context.CancelFunc) {
@@ -1 +1,26 @@
 package huproxyclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type noTokenStore struct {
+	tokenStore
+}
+
+func (noTokenStore) GetAuthTokens() (*entity.AuthTokens, error) {
+	return nil, fmt.Errorf("not logged in")
+}
+
+func TestRunReturnsTokenError(t *testing.T) {
+	// not logged in is returned to the caller instead of exiting as a dial
+	// failure
+	err := Run("wss://example.invalid", noTokenStore{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not logged in")
+	}
+}
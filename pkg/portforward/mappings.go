@@ -0,0 +1,130 @@
+package portforward
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// PortMapping is a port on this machine and a port on the workspace
+type PortMapping struct {
+	Local  string
+	Remote string
+}
+
+func (m PortMapping) String() string {
+	return fmt.Sprintf("%s:%s", m.Local, m.Remote)
+}
+
+// ParsePortMapping parses local_port:remote_port, a single port is used on
+// both ends
+func ParsePortMapping(spec string) (PortMapping, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 || !isPort(parts[0]) || !isPort(parts[1]) {
+		return PortMapping{}, breverrors.NewValidationError(fmt.Sprintf("invalid port %q, use local_port:remote_port", spec))
+	}
+	return PortMapping{Local: parts[0], Remote: parts[1]}, nil
+}
+
+func ParsePortMappings(specs []string) ([]PortMapping, error) {
+	mappings := []PortMapping{}
+	for _, spec := range specs {
+		m, err := ParsePortMapping(spec)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+func isPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port < 65536
+}
+
+// ForwardProfile is a named set of forwards from ~/.brev/forwards.yaml, ex:
+//
+//	web:
+//	  workspace: my-workspace
+//	  ports: ["3000", "8080:80"]
+//	  reverse: ["5432"]
+type ForwardProfile struct {
+	Workspace string   `json:"workspace,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+	Reverse   []string `json:"reverse,omitempty"`
+}
+
+type ForwardProfiles map[string]ForwardProfile
+
+// ParseForwardProfiles parses and validates a profiles file, an empty file
+// has no profiles
+func ParseForwardProfiles(data string) (ForwardProfiles, error) {
+	profiles := ForwardProfiles{}
+	err := yaml.UnmarshalStrict([]byte(data), &profiles)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for name, profile := range profiles {
+		_, err = ParsePortMappings(append(append([]string{}, profile.Ports...), profile.Reverse...))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(fmt.Errorf("profile %s: %w", name, err))
+		}
+	}
+	return profiles, nil
+}
+
+type ReverseForwarder interface {
+	// ForwardReverse returns when stop is closed or the connection drops,
+	// connected is whether the forwards were up before that
+	ForwardReverse(sshName string, mappings []PortMapping, stop <-chan struct{}) (bool, error)
+}
+
+// reverseConnectedAfter is how long ssh has to stay up to count as connected,
+// ExitOnForwardFailure makes it exit right away when it can not forward
+const reverseConnectedAfter = 5 * time.Second
+
+type SSHReverseForwarder struct{}
+
+var _ ReverseForwarder = SSHReverseForwarder{}
+
+func (SSHReverseForwarder) ForwardReverse(sshName string, mappings []PortMapping, stop <-chan struct{}) (bool, error) {
+	args := []string{"-N", "-T", "-o", "ExitOnForwardFailure=yes", "-o", "ServerAliveInterval=15", "-o", "ServerAliveCountMax=2"}
+	for _, m := range mappings {
+		args = append(args, "-R", fmt.Sprintf("%s:127.0.0.1:%s", m.Remote, m.Local))
+	}
+	args = append(args, sshName)
+	cmd := exec.Command("ssh", args...) //nolint:gosec // ports are validated and the host is from the brev ssh config
+	cmd.Stderr = os.Stderr
+	started := time.Now()
+	err := cmd.Start()
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case <-stop:
+		_ = cmd.Process.Kill()
+		<-done
+		return true, nil
+	case err = <-done:
+		connected := time.Since(started) > reverseConnectedAfter
+		if err != nil {
+			return connected, breverrors.WrapAndTrace(err)
+		}
+		return connected, nil
+	}
+}
@@ -2,12 +2,16 @@ package portforward
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/hashicorp/go-multierror"
 
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/k8s"
@@ -17,8 +21,18 @@ import (
 	toolsportforward "k8s.io/client-go/tools/portforward"
 )
 
+const (
+	defaultReconnectDelay       = 2 * time.Second
+	defaultMaxReconnectAttempts = 5
+	maxReconnectDelay           = 30 * time.Second
+	// stableConnection is how long a connection has to stay up for its drop
+	// to not count as a failure
+	stableConnection = time.Minute
+)
+
 type PortForwardOptions struct {
 	PortForwarder              PortForwarder
+	ReverseForwarder           ReverseForwarder
 	WorkspaceGroupClientMapper k8s.WorkspaceGroupClientMapper
 
 	K8sClient k8s.K8sClient
@@ -31,6 +45,19 @@ type PortForwardOptions struct {
 	Ports        []string
 	StopChannel  chan struct{}
 	ReadyChannel chan struct{}
+
+	// SSHName is the host of the workspace in the brev ssh config, reverse
+	// forwards go over ssh since the k8s api only forwards to the pod
+	SSHName string
+	Reverse []PortMapping
+
+	// ReconnectDelay is the wait before connecting again, doubled on each
+	// failure in a row, after MaxReconnectAttempts failures in a row the
+	// forward gives up
+	ReconnectDelay       time.Duration
+	MaxReconnectAttempts int
+
+	stopOnce *sync.Once
 }
 
 type PortForwarder interface {
@@ -40,12 +67,16 @@ type PortForwarder interface {
 func NewPortForwardOptions(workspaceGroupClientMapper k8s.WorkspaceGroupClientMapper, portforwarder PortForwarder) *PortForwardOptions {
 	p := &PortForwardOptions{
 		PortForwarder:              portforwarder,
+		ReverseForwarder:           SSHReverseForwarder{},
 		WorkspaceGroupClientMapper: workspaceGroupClientMapper,
 	}
 
 	p.Address = []string{"localhost"}
 	p.StopChannel = make(chan struct{}, 1)
 	p.ReadyChannel = make(chan struct{})
+	p.ReconnectDelay = defaultReconnectDelay
+	p.MaxReconnectAttempts = defaultMaxReconnectAttempts
+	p.stopOnce = &sync.Once{}
 
 	return p
 }
@@ -53,6 +84,7 @@ func NewPortForwardOptions(workspaceGroupClientMapper k8s.WorkspaceGroupClientMa
 func (o *PortForwardOptions) WithWorkspace(workspace entity.WorkspaceWithMeta) (*PortForwardOptions, error) {
 	o.Namespace = workspace.GetNamespaceName()
 	o.PodName = workspace.GetPodName()
+	o.SSHName = string(workspace.GetLocalIdentifier())
 
 	k8sAPIURL, err := o.WorkspaceGroupClientMapper.GetK8sAPIURL(workspace.WorkspaceGroupID)
 	if err != nil {
@@ -80,33 +112,178 @@ func (o *PortForwardOptions) WithPort(port string) *PortForwardOptions {
 	return o
 }
 
+// WithPorts forwards each local port to its port on the workspace
+func (o *PortForwardOptions) WithPorts(mappings []PortMapping) *PortForwardOptions {
+	o.Ports = []string{}
+	for _, m := range mappings {
+		o.Ports = append(o.Ports, m.String())
+	}
+
+	return o
+}
+
+// WithReverse makes each local port reachable on its port on the workspace
+func (o *PortForwardOptions) WithReverse(mappings []PortMapping) *PortForwardOptions {
+	o.Reverse = mappings
+
+	return o
+}
+
+// RunPortforward forwards until interrupted, a forward that drops is
+// connected again
 func (o PortForwardOptions) RunPortforward() error {
 	// cmd := portforward.NewCmdPortForward(tf, streams) // This command is useful to have around to go to def of kubectl cmd
 
+	if o.stopOnce == nil {
+		o.stopOnce = &sync.Once{}
+	}
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
 	defer signal.Stop(signals)
 
 	go func() {
 		<-signals
-		if o.StopChannel != nil {
-			close(o.StopChannel)
-		}
+		o.stop()
 	}()
 
+	errs := make(chan error, 2)
+	running := 0
+	if len(o.Ports) > 0 {
+		running++
+		go func() {
+			errs <- o.runPortForward()
+		}()
+	}
+	if len(o.Reverse) > 0 {
+		running++
+		go func() {
+			errs <- o.runReverseForward()
+		}()
+	}
+
+	var res error
+	for i := 0; i < running; i++ {
+		err := <-errs
+		if err != nil {
+			res = multierror.Append(res, err)
+			// the others stop too rather than leave half the forwards up
+			o.stop()
+		}
+	}
+	if res != nil {
+		return breverrors.WrapAndTrace(res)
+	}
+	return nil
+}
+
+func (o PortForwardOptions) runPortForward() error {
 	urlStr := fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/portforward", o.K8sAPIURL, o.Namespace, o.PodName)
 
 	url, err := url.Parse(urlStr)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = o.PortForwarder.ForwardPorts("POST", url, o)
+	err = o.reconnect("port forward", func() (bool, error) {
+		attempt := o
+		attempt.ReadyChannel = make(chan struct{})
+		err := o.PortForwarder.ForwardPorts("POST", url, attempt)
+		return isClosed(attempt.ReadyChannel), err
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
+func (o PortForwardOptions) runReverseForward() error {
+	if o.SSHName == "" {
+		return fmt.Errorf("reverse forwards need the ssh host of the workspace")
+	}
+	err := o.reconnect("reverse forward", func() (bool, error) {
+		connected, err := o.ReverseForwarder.ForwardReverse(o.SSHName, o.Reverse, o.StopChannel)
+		return connected, breverrors.WrapAndTrace(err)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// reconnect calls connect until the forward is stopped. After a connection
+// drops or can not be made it waits ReconnectDelay, doubled on each failure
+// in a row up to maxReconnectDelay, and gives up after MaxReconnectAttempts
+// failures in a row. A drop only starts the count over when the connection
+// was up for stableConnection, so one that drops right away can not spin.
+func (o PortForwardOptions) reconnect(name string, connect func() (bool, error)) error {
+	failures := 0
+	for {
+		start := time.Now()
+		connected, err := connect()
+		if isClosed(o.StopChannel) {
+			return nil
+		}
+		if connected && time.Since(start) >= stableConnection {
+			failures = 0
+		}
+		failures++
+		if failures > o.MaxReconnectAttempts {
+			if err == nil && connected {
+				err = fmt.Errorf("%s keeps losing its connection", name)
+			} else if err == nil {
+				err = fmt.Errorf("%s could not connect", name)
+			}
+			return breverrors.WrapAndTrace(err)
+		}
+		delay := reconnectDelay(o.ReconnectDelay, failures)
+		if connected {
+			fmt.Fprintf(os.Stderr, "%s lost its connection, reconnecting in %s...\n", name, delay)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s could not connect, retrying in %s...\n", name, delay)
+		}
+		select {
+		case <-o.StopChannel:
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+func reconnectDelay(base time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxReconnectDelay {
+		return maxReconnectDelay
+	}
+	return delay
+}
+
+// Stop ends RunPortforward
+func (o PortForwardOptions) Stop() {
+	o.stop()
+}
+
+func (o PortForwardOptions) stop() {
+	o.stopOnce.Do(func() {
+		if o.StopChannel != nil {
+			close(o.StopChannel)
+		}
+	})
+}
+
+func isClosed(c chan struct{}) bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 type DefaultPortForwarder struct {
 	genericclioptions.IOStreams
 }
@@ -117,6 +294,15 @@ func NewDefaultPortForwarder() *DefaultPortForwarder {
 	}
 }
 
+// NewQuietPortForwarder does not print each forwarded connection
+func NewQuietPortForwarder() *DefaultPortForwarder {
+	return &DefaultPortForwarder{
+		IOStreams: genericclioptions.IOStreams{In: os.Stdin, Out: ioutil.Discard, ErrOut: ioutil.Discard},
+	}
+}
+
+// ForwardPorts returns when the stop channel is closed or the stream to the
+// pod drops, the ready channel is closed once the ports are listening
 func (f *DefaultPortForwarder) ForwardPorts(method string, url *url.URL, opts PortForwardOptions) error {
 	transport, upgrader, err := spdy.RoundTripperFor(opts.K8sClient.GetK8sRestConfig())
 	if err != nil {
@@ -1 +1,152 @@
 package portforward
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortMapping(t *testing.T) {
+	m, err := ParsePortMapping("8080:80")
+	assert.Nil(t, err)
+	assert.Equal(t, PortMapping{Local: "8080", Remote: "80"}, m)
+
+	m, err = ParsePortMapping("3000")
+	assert.Nil(t, err)
+	assert.Equal(t, "3000:3000", m.String())
+
+	for _, spec := range []string{"", "a:80", "80:", "1:2:3", "0", "70000"} {
+		_, err = ParsePortMapping(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestParseForwardProfiles(t *testing.T) {
+	profiles, err := ParseForwardProfiles(`
+web:
+  workspace: my-ws
+  ports: ["3000", "8080:80"]
+  reverse: ["5432"]
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, ForwardProfiles{"web": {Workspace: "my-ws", Ports: []string{"3000", "8080:80"}, Reverse: []string{"5432"}}}, profiles)
+
+	profiles, err = ParseForwardProfiles("")
+	assert.Nil(t, err)
+	assert.Empty(t, profiles)
+
+	_, err = ParseForwardProfiles("web:\n  ports: [nope]\n")
+	assert.NotNil(t, err)
+	_, err = ParseForwardProfiles("web:\n  port: [80]\n")
+	assert.NotNil(t, err)
+}
+
+// droppingPortForwarder connects and drops drops times, then stops
+type droppingPortForwarder struct {
+	mu    sync.Mutex
+	calls int
+	drops int
+	fail  bool
+}
+
+func (f *droppingPortForwarder) ForwardPorts(_ string, _ *url.URL, opts PortForwardOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail {
+		return errors.New("error upgrading connection")
+	}
+	close(opts.ReadyChannel)
+	if f.calls > f.drops {
+		opts.stop()
+	}
+	return nil
+}
+
+func TestRunPortforwardReconnects(t *testing.T) {
+	pf := &droppingPortForwarder{drops: 2}
+	o := NewPortForwardOptions(nil, pf)
+	o.ReconnectDelay = time.Millisecond
+	o.WithPorts([]PortMapping{{Local: "8080", Remote: "80"}, {Local: "3000", Remote: "3000"}})
+	assert.Equal(t, []string{"8080:80", "3000:3000"}, o.Ports)
+
+	err := o.RunPortforward()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pf.calls)
+}
+
+func TestRunPortforwardGivesUp(t *testing.T) {
+	pf := &droppingPortForwarder{fail: true}
+	o := NewPortForwardOptions(nil, pf)
+	o.ReconnectDelay = 0
+	o.MaxReconnectAttempts = 2
+	o.WithPort("8080:80")
+
+	err := o.RunPortforward()
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, pf.calls)
+}
+
+func TestRunPortforwardGivesUpOnDrops(t *testing.T) {
+	// connections that drop right away count as failures instead of being
+	// made again in a loop
+	pf := &droppingPortForwarder{drops: 100}
+	o := NewPortForwardOptions(nil, pf)
+	o.ReconnectDelay = time.Millisecond
+	o.MaxReconnectAttempts = 2
+	o.WithPort("8080:80")
+
+	err := o.RunPortforward()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "keeps losing its connection")
+	}
+	assert.Equal(t, 3, pf.calls)
+}
+
+func TestReconnectDelay(t *testing.T) {
+	assert.Equal(t, 2*time.Second, reconnectDelay(2*time.Second, 1))
+	assert.Equal(t, 8*time.Second, reconnectDelay(2*time.Second, 3))
+	assert.Equal(t, maxReconnectDelay, reconnectDelay(2*time.Second, 10))
+	assert.Equal(t, time.Duration(0), reconnectDelay(0, 3))
+}
+
+type fakeReverseForwarder struct {
+	calls    int
+	mappings []PortMapping
+}
+
+func (f *fakeReverseForwarder) ForwardReverse(sshName string, mappings []PortMapping, stop <-chan struct{}) (bool, error) {
+	f.calls++
+	f.mappings = mappings
+	return false, errors.New("connection refused")
+}
+
+func TestRunPortforwardStopsOtherForwardsOnFailure(t *testing.T) {
+	rf := &fakeReverseForwarder{}
+	blocking := blockingPortForwarder{}
+	o := NewPortForwardOptions(nil, blocking)
+	o.ReverseForwarder = rf
+	o.ReconnectDelay = 0
+	o.MaxReconnectAttempts = 1
+	o.SSHName = "my-ws"
+	o.WithPort("8080:80")
+	o.WithReverse([]PortMapping{{Local: "5432", Remote: "5432"}})
+
+	err := o.RunPortforward()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, rf.calls)
+	assert.Equal(t, []PortMapping{{Local: "5432", Remote: "5432"}}, rf.mappings)
+}
+
+// blockingPortForwarder stays connected until stopped
+type blockingPortForwarder struct{}
+
+func (blockingPortForwarder) ForwardPorts(_ string, _ *url.URL, opts PortForwardOptions) error {
+	close(opts.ReadyChannel)
+	<-opts.StopChannel
+	return nil
+}
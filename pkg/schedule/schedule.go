@@ -0,0 +1,228 @@
+// Package schedule starts and stops workspaces on local cron schedules
+package schedule
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+var dayAliases = map[string]string{
+	"daily":    "*",
+	"everyday": "*",
+	"weekdays": "1-5",
+	"weekends": "0,6",
+}
+
+// BuildCron turns a time of day like 08:30 and days like weekdays or
+// mon,wed,fri into a standard cron expression
+func BuildCron(at string, days string) (string, error) {
+	parts := strings.Split(at, ":")
+	if len(parts) != 2 {
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid time %q, expected HH:MM", at))
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid hour in %q", at))
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid minute in %q", at))
+	}
+	dow := strings.ToLower(strings.TrimSpace(days))
+	if dow == "" {
+		dow = "*"
+	}
+	if alias, ok := dayAliases[dow]; ok {
+		dow = alias
+	}
+	expr := fmt.Sprintf("%d %d * * %s", minute, hour, dow)
+	_, err = cron.ParseStandard(expr)
+	if err != nil {
+		return "", breverrors.NewValidationError(fmt.Sprintf("invalid days %q: %v", days, err))
+	}
+	return expr, nil
+}
+
+func parse(expr string, timezone string) (cron.Schedule, error) {
+	if timezone != "" {
+		expr = fmt.Sprintf("CRON_TZ=%s %s", timezone, expr)
+	}
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return sched, nil
+}
+
+func Validate(s store.Schedule) error {
+	if s.Start == "" && s.Stop == "" {
+		return breverrors.NewValidationError("a schedule needs a start or a stop time")
+	}
+	if s.Timezone != "" {
+		_, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid timezone %q", s.Timezone))
+		}
+	}
+	for _, expr := range []string{s.Start, s.Stop} {
+		if expr == "" {
+			continue
+		}
+		_, err := parse(expr, s.Timezone)
+		if err != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid cron expression %q", expr))
+		}
+	}
+	return nil
+}
+
+type Action string
+
+const (
+	NoAction    Action = ""
+	StartAction Action = "start"
+	StopAction  Action = "stop"
+)
+
+// Due returns the action that fired in (since, now], when both fired the
+// later one wins
+func Due(s store.Schedule, since time.Time, now time.Time) (Action, error) {
+	action := NoAction
+	var at time.Time
+	for _, c := range []struct {
+		expr   string
+		action Action
+	}{{s.Start, StartAction}, {s.Stop, StopAction}} {
+		if c.expr == "" {
+			continue
+		}
+		sched, err := parse(c.expr, s.Timezone)
+		if err != nil {
+			return NoAction, breverrors.WrapAndTrace(err)
+		}
+		// walk forward to the last time this expression fired in the window
+		var last time.Time
+		for next := sched.Next(since); !next.After(now); next = sched.Next(next) {
+			last = next
+		}
+		if !last.IsZero() && !last.Before(at) {
+			action = c.action
+			at = last
+		}
+	}
+	return action, nil
+}
+
+// NextTransition is used to show when a schedule acts next
+func NextTransition(s store.Schedule, now time.Time) (Action, time.Time, error) {
+	action := NoAction
+	var at time.Time
+	for _, c := range []struct {
+		expr   string
+		action Action
+	}{{s.Start, StartAction}, {s.Stop, StopAction}} {
+		if c.expr == "" {
+			continue
+		}
+		sched, err := parse(c.expr, s.Timezone)
+		if err != nil {
+			return NoAction, time.Time{}, breverrors.WrapAndTrace(err)
+		}
+		next := sched.Next(now)
+		if at.IsZero() || next.Before(at) {
+			action = c.action
+			at = next
+		}
+	}
+	return action, at, nil
+}
+
+type SchedulerStore interface {
+	GetSchedules() ([]store.Schedule, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	StartWorkspace(workspaceID string) (*entity.Workspace, error)
+	StopWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+// SchedulerTask re-reads the schedules every minute so edits made with
+// brev schedule apply without restarting the daemon
+type SchedulerTask struct {
+	Store   SchedulerStore
+	lastRun time.Time
+	now     func() time.Time
+}
+
+var _ tasks.Task = &SchedulerTask{}
+
+func NewSchedulerTask(schedulerStore SchedulerStore) *SchedulerTask {
+	return &SchedulerTask{
+		Store:   schedulerStore,
+		lastRun: time.Now(),
+		now:     time.Now,
+	}
+}
+
+func (s SchedulerTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+func (s SchedulerTask) Configure() error {
+	return nil
+}
+
+func (s *SchedulerTask) Run() error {
+	now := s.now()
+	since := s.lastRun
+	s.lastRun = now
+
+	schedules, err := s.Store.GetSchedules()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, sched := range schedules {
+		action, err := Due(sched, since, now)
+		if err != nil {
+			log.Printf("schedule for %s: %v", sched.WorkspaceName, err)
+			continue
+		}
+		if action == NoAction {
+			continue
+		}
+		err = s.transition(sched, action)
+		if err != nil {
+			log.Printf("schedule for %s: failed to %s: %v", sched.WorkspaceName, action, err)
+		}
+	}
+	return nil
+}
+
+func (s SchedulerTask) transition(sched store.Schedule, action Action) error {
+	workspace, err := s.Store.GetWorkspace(sched.WorkspaceID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	switch {
+	case action == StartAction && workspace.Status == entity.WorkspaceStoppedStatus:
+		_, err = s.Store.StartWorkspace(workspace.ID)
+	case action == StopAction && workspace.Status == entity.WorkspaceRunningStatus:
+		_, err = s.Store.StopWorkspace(workspace.ID)
+	default:
+		log.Printf("schedule for %s: skipping %s, workspace is %s", workspace.Name, action, strings.ToLower(workspace.Status))
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	log.Printf("schedule for %s: %s, workspace was %s", workspace.Name, action, strings.ToLower(workspace.Status))
+	return nil
+}
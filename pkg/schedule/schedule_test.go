@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCron(t *testing.T) {
+	tests := []struct {
+		at       string
+		days     string
+		expected string
+		wantErr  bool
+	}{
+		{"08:30", "weekdays", "30 8 * * 1-5", false},
+		{"19:00", "", "0 19 * * *", false},
+		{"7:05", "weekends", "5 7 * * 0,6", false},
+		{"07:00", "mon,wed,fri", "0 7 * * mon,wed,fri", false},
+		{"24:00", "daily", "", true},
+		{"0830", "daily", "", true},
+		{"08:30", "someday", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.at+" "+tt.days, func(t *testing.T) {
+			expr, err := BuildCron(tt.at, tt.days)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NotNil(t, Validate(store.Schedule{}))
+	assert.NotNil(t, Validate(store.Schedule{Start: "0 8 * * *", Timezone: "Mars/Olympus"}))
+	assert.NotNil(t, Validate(store.Schedule{Stop: "not cron"}))
+	assert.Nil(t, Validate(store.Schedule{Start: "0 8 * * *", Stop: "0 19 * * *", Timezone: "America/New_York"}))
+}
+
+func TestDue(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := store.Schedule{Start: "30 8 * * 1-5", Stop: "0 19 * * 1-5", Timezone: "America/New_York"}
+	// a monday
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, loc)
+
+	action, err := Due(s, monday.Add(8*time.Hour), monday.Add(8*time.Hour+29*time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, NoAction, action)
+
+	action, err = Due(s, monday.Add(8*time.Hour+29*time.Minute), monday.Add(8*time.Hour+30*time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, StartAction, action)
+
+	// both fired while the daemon was asleep, the later one wins
+	action, err = Due(s, monday, monday.Add(20*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, StopAction, action)
+
+	saturday := monday.AddDate(0, 0, 5)
+	action, err = Due(s, saturday, saturday.Add(24*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, NoAction, action)
+
+	action, at, err := NextTransition(s, saturday)
+	assert.Nil(t, err)
+	assert.Equal(t, StartAction, action)
+	assert.Equal(t, monday.AddDate(0, 0, 7).Add(8*time.Hour+30*time.Minute).Unix(), at.Unix())
+}
+
+type mockSchedulerStore struct {
+	schedules []store.Schedule
+	status    string
+	started   []string
+	stopped   []string
+}
+
+func (m *mockSchedulerStore) GetSchedules() ([]store.Schedule, error) {
+	return m.schedules, nil
+}
+
+func (m *mockSchedulerStore) GetWorkspace(workspaceID string) (*entity.Workspace, error) {
+	return &entity.Workspace{ID: workspaceID, Name: "ws", Status: m.status}, nil
+}
+
+func (m *mockSchedulerStore) StartWorkspace(workspaceID string) (*entity.Workspace, error) {
+	m.started = append(m.started, workspaceID)
+	return &entity.Workspace{ID: workspaceID}, nil
+}
+
+func (m *mockSchedulerStore) StopWorkspace(workspaceID string) (*entity.Workspace, error) {
+	m.stopped = append(m.stopped, workspaceID)
+	return &entity.Workspace{ID: workspaceID}, nil
+}
+
+func TestSchedulerTaskRun(t *testing.T) {
+	s := &mockSchedulerStore{
+		schedules: []store.Schedule{{WorkspaceID: "abcd1234", Start: "0 8 * * *", Stop: "0 19 * * *", Timezone: "UTC"}},
+		status:    entity.WorkspaceStoppedStatus,
+	}
+	now := time.Date(2026, 10, 19, 7, 59, 30, 0, time.UTC)
+	task := &SchedulerTask{Store: s, lastRun: now, now: func() time.Time { return now }}
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, task.Run())
+	assert.Equal(t, []string{"abcd1234"}, s.started)
+
+	// already running, the next minute does nothing
+	s.status = entity.WorkspaceRunningStatus
+	now = now.Add(time.Minute)
+	assert.Nil(t, task.Run())
+	assert.Len(t, s.started, 1)
+	assert.Empty(t, s.stopped)
+
+	now = time.Date(2026, 10, 19, 19, 0, 10, 0, time.UTC)
+	assert.Nil(t, task.Run())
+	assert.Equal(t, []string{"abcd1234"}, s.stopped)
+}
@@ -0,0 +1,305 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+const (
+	// SSHCertificateValidFor is how long the certificates brev asks for last,
+	// they are tied to a key that is replaced on every renewal
+	SSHCertificateValidFor = time.Hour
+	// SSHCertificateRenewBefore is how long before expiry certificates are renewed
+	SSHCertificateRenewBefore = 20 * time.Minute
+
+	sshCertificateKeyBits = 2048
+)
+
+type SSHCertificateStore interface {
+	GetContextWorkspaces() ([]entity.Workspace, error)
+	GetCurrentUserKeys() (*entity.UserKeys, error)
+	GetSSHCertificate(workspaceGroupID string) (string, error)
+	SignSSHCertificate(workspaceGroupID string, publicKey string, validFor time.Duration) (string, error)
+	WriteSSHCertificates(privateKey string, certificates map[string]string) error
+}
+
+// ParseSSHCertificate parses an OpenSSH user certificate in authorized_keys
+// format
+func ParseSSHCertificate(certificate string) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate)) //nolint:dogsled // only the key is needed
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a certificate", pub.Type())
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("not a user certificate")
+	}
+	return cert, nil
+}
+
+// IsSSHCertificateValidFor is whether cert can be used from now until d from now
+func IsSSHCertificateValidFor(cert *ssh.Certificate, now time.Time, d time.Duration) bool {
+	unix := uint64(now.Unix())
+	if unix < cert.ValidAfter {
+		return false
+	}
+	if cert.ValidBefore == ssh.CertTimeInfinity {
+		return true
+	}
+	return uint64(now.Add(d).Unix()) < cert.ValidBefore
+}
+
+// GetValidSSHCertificate returns the certificate of a workspace group when
+// there is one that has not expired
+func GetValidSSHCertificate(certificate string, now time.Time) (*ssh.Certificate, bool) {
+	if strings.TrimSpace(certificate) == "" {
+		return nil, false
+	}
+	cert, err := ParseSSHCertificate(certificate)
+	if err != nil {
+		return nil, false
+	}
+	return cert, IsSSHCertificateValidFor(cert, now, 0)
+}
+
+// SSHCertificateRenewer keeps a short lived certificate for every workspace
+// group the user has running workspaces in. All certificates are issued for
+// the same key, so when one needs renewing a new key is made and all are
+// renewed together. Only groups that publish an ssh CA in the user keys issue
+// certificates, for every other group nothing is asked of the server and the
+// brev key keeps being used on its own.
+type SSHCertificateRenewer struct {
+	Store       SSHCertificateStore
+	Now         func() time.Time
+	ValidFor    time.Duration
+	RenewBefore time.Duration
+}
+
+var _ tasks.Task = SSHCertificateRenewer{}
+
+func NewSSHCertificateRenewer(store SSHCertificateStore) SSHCertificateRenewer {
+	return SSHCertificateRenewer{
+		Store:       store,
+		Now:         time.Now,
+		ValidFor:    SSHCertificateValidFor,
+		RenewBefore: SSHCertificateRenewBefore,
+	}
+}
+
+func (r SSHCertificateRenewer) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+func (r SSHCertificateRenewer) Configure() error {
+	return nil
+}
+
+func (r SSHCertificateRenewer) Run() error {
+	workspaceGroupIDs, err := r.getWorkspaceGroupIDs()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(workspaceGroupIDs) == 0 {
+		return nil
+	}
+	cas, err := r.getWorkspaceGroupCAs(workspaceGroupIDs)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(cas) == 0 {
+		return nil
+	}
+	needsRenewal, err := r.needsRenewal(cas)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !needsRenewal {
+		return nil
+	}
+	err = r.Renew(cas)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r SSHCertificateRenewer) getWorkspaceGroupIDs() ([]string, error) {
+	workspaces, err := r.Store.GetContextWorkspaces()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, w := range workspaces {
+		if w.Status != entity.WorkspaceRunningStatus || w.WorkspaceGroupID == "" || seen[w.WorkspaceGroupID] {
+			continue
+		}
+		seen[w.WorkspaceGroupID] = true
+		ids = append(ids, w.WorkspaceGroupID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// getWorkspaceGroupCAs are the ssh CAs of the groups that issue certificates
+func (r SSHCertificateRenewer) getWorkspaceGroupCAs(workspaceGroupIDs []string) (map[string]ssh.PublicKey, error) {
+	keys, err := r.Store.GetCurrentUserKeys()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	cas := map[string]ssh.PublicKey{}
+	for _, id := range workspaceGroupIDs {
+		groupKeys, err := keys.GetWorkspaceGroupKeysByGroupID(id)
+		if err != nil {
+			continue
+		}
+		ca, err := ParseSSHCA(groupKeys.CA)
+		if err != nil {
+			continue
+		}
+		cas[id] = ca
+	}
+	return cas, nil
+}
+
+// ParseSSHCA parses the CA of a workspace group, groups that do not issue
+// ssh certificates have none or a CA of another kind
+func ParseSSHCA(ca string) (ssh.PublicKey, error) {
+	if strings.TrimSpace(ca) == "" {
+		return nil, fmt.Errorf("no ssh CA")
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(ca)) //nolint:dogsled // only the key is needed
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "not an ssh CA")
+	}
+	return key, nil
+}
+
+// needsRenewal is true when a certificate is missing, about to expire or
+// was issued for a different key than the others
+func (r SSHCertificateRenewer) needsRenewal(cas map[string]ssh.PublicKey) (bool, error) {
+	var key []byte
+	for _, id := range sortedGroupIDs(cas) {
+		certificate, err := r.Store.GetSSHCertificate(id)
+		if err != nil {
+			return false, breverrors.WrapAndTrace(err)
+		}
+		if strings.TrimSpace(certificate) == "" {
+			return true, nil
+		}
+		cert, err := ParseSSHCertificate(certificate)
+		if err != nil {
+			return true, nil //nolint:nilerr // a broken certificate is replaced
+		}
+		if !IsSSHCertificateValidFor(cert, r.Now(), r.RenewBefore) {
+			return true, nil
+		}
+		if key != nil && !bytes.Equal(key, cert.Key.Marshal()) {
+			return true, nil
+		}
+		key = cert.Key.Marshal()
+	}
+	return false, nil
+}
+
+// Renew makes a new key and has the CA of every workspace group sign it
+func (r SSHCertificateRenewer) Renew(cas map[string]ssh.PublicKey) error {
+	privateKey, publicKey, err := GenerateSSHKey()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+
+	certificates := map[string]string{}
+	for _, id := range sortedGroupIDs(cas) {
+		certificate, err := r.Store.SignSSHCertificate(id, authorizedKey, r.ValidFor)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = VerifySSHCertificate(certificate, publicKey, cas[id], r.Now())
+		if err != nil {
+			return breverrors.WrapAndTrace(fmt.Errorf("certificate for workspace group %s: %w", id, err))
+		}
+		certificates[id] = strings.TrimSpace(certificate) + "\n"
+	}
+
+	err = r.Store.WriteSSHCertificates(privateKey, certificates)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func sortedGroupIDs(cas map[string]ssh.PublicKey) []string {
+	ids := []string{}
+	for id := range cas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// VerifySSHCertificate checks a newly issued certificate is for our key, is
+// valid now and was signed by the CA of the workspace group
+func VerifySSHCertificate(certificate string, publicKey ssh.PublicKey, ca ssh.PublicKey, now time.Time) error {
+	cert, err := ParseSSHCertificate(certificate)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !bytes.Equal(cert.Key.Marshal(), publicKey.Marshal()) {
+		return fmt.Errorf("issued for a different key")
+	}
+	if !IsSSHCertificateValidFor(cert, now, 0) {
+		return fmt.Errorf("expired or not valid yet")
+	}
+	if ca == nil || !bytes.Equal(ca.Marshal(), cert.SignatureKey.Marshal()) {
+		return fmt.Errorf("not signed by the workspace group CA")
+	}
+	// naming the CA as the signer is not enough, the signature has to verify.
+	// Principals and critical options are for the server to enforce.
+	checker := ssh.CertChecker{
+		Clock: func() time.Time { return now },
+	}
+	for option := range cert.CriticalOptions {
+		checker.SupportedCriticalOptions = append(checker.SupportedCriticalOptions, option)
+	}
+	principal := ""
+	if len(cert.ValidPrincipals) > 0 {
+		principal = cert.ValidPrincipals[0]
+	}
+	err = checker.CheckCert(principal, cert)
+	if err != nil {
+		return fmt.Errorf("not signed by the workspace group CA: %w", err)
+	}
+	return nil
+}
+
+// GenerateSSHKey makes a pem encoded private key like the brev private key
+func GenerateSSHKey() (string, ssh.PublicKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, sshCertificateKeyBits)
+	if err != nil {
+		return "", nil, breverrors.WrapAndTrace(err)
+	}
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", nil, breverrors.WrapAndTrace(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(privateKey), publicKey, nil
+}
@@ -0,0 +1,281 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+type testCA struct {
+	signer ssh.Signer
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return testCA{signer: signer}
+}
+
+func (c testCA) authorizedKey() string {
+	return string(ssh.MarshalAuthorizedKey(c.signer.PublicKey()))
+}
+
+func (c testCA) sign(t *testing.T, publicKey string, validAfter time.Time, validFor time.Duration) string {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey)) //nolint:dogsled // only the key is needed
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"brev"},
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validAfter.Add(validFor).Unix()),
+	}
+	err = cert.SignCert(rand.Reader, c.signer)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return string(ssh.MarshalAuthorizedKey(cert))
+}
+
+type certificateStore struct {
+	t            *testing.T
+	now          time.Time
+	workspaces   []entity.Workspace
+	cas          map[string]testCA
+	keys         entity.UserKeys
+	certificates map[string]string
+	privateKey   string
+	signed       []string
+}
+
+func (s *certificateStore) GetContextWorkspaces() ([]entity.Workspace, error) {
+	return s.workspaces, nil
+}
+
+func (s *certificateStore) GetCurrentUserKeys() (*entity.UserKeys, error) {
+	return &s.keys, nil
+}
+
+func (s *certificateStore) GetSSHCertificate(workspaceGroupID string) (string, error) {
+	return s.certificates[workspaceGroupID], nil
+}
+
+func (s *certificateStore) SignSSHCertificate(workspaceGroupID string, publicKey string, validFor time.Duration) (string, error) {
+	ca, ok := s.cas[workspaceGroupID]
+	if !ok {
+		return "", fmt.Errorf("no CA for %s", workspaceGroupID)
+	}
+	s.signed = append(s.signed, workspaceGroupID)
+	return ca.sign(s.t, publicKey, s.now.Add(-time.Minute), validFor), nil
+}
+
+func (s *certificateStore) WriteSSHCertificates(privateKey string, certificates map[string]string) error {
+	s.privateKey = privateKey
+	s.certificates = certificates
+	return nil
+}
+
+func newCertificateStore(t *testing.T) *certificateStore {
+	now := time.Now()
+	ca := newTestCA(t)
+	ca2 := newTestCA(t)
+	return &certificateStore{
+		t:   t,
+		now: now,
+		workspaces: []entity.Workspace{
+			{ID: "1", WorkspaceGroupID: "wg-1", Status: entity.WorkspaceRunningStatus},
+			{ID: "2", WorkspaceGroupID: "wg-1", Status: entity.WorkspaceRunningStatus},
+			{ID: "3", WorkspaceGroupID: "wg-2", Status: entity.WorkspaceRunningStatus},
+			{ID: "4", WorkspaceGroupID: "wg-3", Status: entity.WorkspaceStoppedStatus},
+		},
+		cas: map[string]testCA{"wg-1": ca, "wg-2": ca2},
+		keys: entity.UserKeys{WorkspaceGroups: []entity.WorkspaceGroupKeys{
+			{GroupID: "wg-1", CA: ca.authorizedKey()},
+			{GroupID: "wg-2", CA: ca2.authorizedKey()},
+		}},
+		certificates: map[string]string{},
+	}
+}
+
+func newTestRenewer(s *certificateStore) SSHCertificateRenewer {
+	r := NewSSHCertificateRenewer(s)
+	r.Now = func() time.Time { return s.now }
+	return r
+}
+
+func TestSSHCertificateRenewerIssuesForRunningGroups(t *testing.T) {
+	s := newCertificateStore(t)
+	err := newTestRenewer(s).Run()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"wg-1", "wg-2"}, s.signed)
+	assert.Contains(t, s.privateKey, "RSA PRIVATE KEY")
+	keyOf := map[string]string{}
+	for _, id := range []string{"wg-1", "wg-2"} {
+		cert, ok := GetValidSSHCertificate(s.certificates[id], s.now)
+		assert.True(t, ok)
+		keyOf[id] = string(cert.Key.Marshal())
+	}
+	assert.Equal(t, keyOf["wg-1"], keyOf["wg-2"])
+
+	// nothing to do while the certificates are far from expiring
+	s.signed = nil
+	err = newTestRenewer(s).Run()
+	assert.Nil(t, err)
+	assert.Empty(t, s.signed)
+}
+
+func TestSSHCertificateRenewerRenewsBeforeExpiry(t *testing.T) {
+	s := newCertificateStore(t)
+	err := newTestRenewer(s).Run()
+	if !assert.Nil(t, err) {
+		return
+	}
+	oldKey := s.privateKey
+	s.signed = nil
+	s.now = s.now.Add(SSHCertificateValidFor - SSHCertificateRenewBefore + time.Minute)
+	err = newTestRenewer(s).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wg-1", "wg-2"}, s.signed)
+	assert.NotEqual(t, oldKey, s.privateKey)
+}
+
+func TestSSHCertificateRenewerRenewsWhenMissing(t *testing.T) {
+	s := newCertificateStore(t)
+	err := newTestRenewer(s).Run()
+	if !assert.Nil(t, err) {
+		return
+	}
+	s.signed = nil
+	s.workspaces = append(s.workspaces, entity.Workspace{ID: "5", WorkspaceGroupID: "wg-4", Status: entity.WorkspaceRunningStatus})
+	s.cas["wg-4"] = newTestCA(t)
+	s.keys.WorkspaceGroups = append(s.keys.WorkspaceGroups, entity.WorkspaceGroupKeys{GroupID: "wg-4", CA: s.cas["wg-4"].authorizedKey()})
+	err = newTestRenewer(s).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wg-1", "wg-2", "wg-4"}, s.signed)
+}
+
+func TestSSHCertificateRenewerRejectsWrongCA(t *testing.T) {
+	s := newCertificateStore(t)
+	s.keys.WorkspaceGroups[0].CA = newTestCA(t).authorizedKey()
+	err := newTestRenewer(s).Run()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not signed by the workspace group CA")
+	}
+	assert.Empty(t, s.privateKey)
+}
+
+func TestSSHCertificateRenewerSkipsGroupsWithoutSSHCA(t *testing.T) {
+	s := newCertificateStore(t)
+	s.keys.WorkspaceGroups[0].CA = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	err := newTestRenewer(s).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wg-2"}, s.signed)
+	assert.Empty(t, s.certificates["wg-1"])
+
+	// no group issues certificates, the server is never asked
+	s = newCertificateStore(t)
+	s.keys.WorkspaceGroups = nil
+	err = newTestRenewer(s).Run()
+	assert.Nil(t, err)
+	assert.Empty(t, s.signed)
+	assert.Empty(t, s.privateKey)
+}
+
+func TestVerifySSHCertificateRejectsOtherKey(t *testing.T) {
+	ca := newTestCA(t)
+	_, publicKey, err := GenerateSSHKey()
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, otherKey, err := GenerateSSHKey()
+	if !assert.Nil(t, err) {
+		return
+	}
+	now := time.Now()
+	certificate := ca.sign(t, string(ssh.MarshalAuthorizedKey(otherKey)), now.Add(-time.Minute), time.Hour)
+	err = VerifySSHCertificate(certificate, publicKey, ca.signer.PublicKey(), now)
+	assert.NotNil(t, err)
+	err = VerifySSHCertificate(certificate, otherKey, ca.signer.PublicKey(), now)
+	assert.Nil(t, err)
+	// without a CA to check against the certificate is not trusted
+	err = VerifySSHCertificate(certificate, otherKey, nil, now)
+	assert.NotNil(t, err)
+}
+
+func TestVerifySSHCertificateRejectsForgedSignature(t *testing.T) {
+	ca := newTestCA(t)
+	forger := newTestCA(t)
+	_, publicKey, err := GenerateSSHKey()
+	if !assert.Nil(t, err) {
+		return
+	}
+	now := time.Now()
+	forged, err := ParseSSHCertificate(forger.sign(t, string(ssh.MarshalAuthorizedKey(publicKey)), now.Add(-time.Minute), time.Hour))
+	if !assert.Nil(t, err) {
+		return
+	}
+	// claims the CA signed it but the signature is the forger's
+	forged.SignatureKey = ca.signer.PublicKey()
+	err = VerifySSHCertificate(string(ssh.MarshalAuthorizedKey(forged)), publicKey, ca.signer.PublicKey(), now)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not signed by the workspace group CA")
+	}
+}
+
+type certificateSSHConfigurerV2Store struct {
+	DummySSHConfigurerV2Store
+	certificates map[string]string
+}
+
+func (c certificateSSHConfigurerV2Store) GetSSHCertificate(workspaceGroupID string) (string, error) {
+	return c.certificates[workspaceGroupID], nil
+}
+
+func TestCreateNewSSHConfigWithCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	_, publicKey, err := GenerateSSHKey()
+	if !assert.Nil(t, err) {
+		return
+	}
+	now := time.Now()
+	certificate := ca.sign(t, string(ssh.MarshalAuthorizedKey(publicKey)), now.Add(-time.Minute), time.Hour)
+	c := NewSSHConfigurerV2(certificateSSHConfigurerV2Store{certificates: map[string]string{"wgi": certificate}})
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces[:1])
+	assert.Nil(t, err)
+	correct := fmt.Sprintf(`# included in /my/user/config
+Host %s
+  IdentityFile /my/brev/certs/brev-session.pem
+  CertificateFile /my/brev/certs/wgi-cert.pub
+  IdentityFile /my/priv/key.pem
+  User brev
+  ProxyCommand brev proxy test-id-1
+  ServerAliveInterval 30
+
+`, somePlainWorkspaces[0].GetLocalIdentifier())
+	assert.Equal(t, correct, cStr)
+
+	// an expired certificate is left out
+	expired := ca.sign(t, string(ssh.MarshalAuthorizedKey(publicKey)), now.Add(-2*time.Hour), time.Hour)
+	c = NewSSHConfigurerV2(certificateSSHConfigurerV2Store{certificates: map[string]string{"wgi": expired}})
+	cStr, err = c.CreateNewSSHConfig(somePlainWorkspaces[:1])
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(cStr, "CertificateFile"))
+}
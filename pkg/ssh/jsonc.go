@@ -0,0 +1,225 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/tailscale/hujson"
+)
+
+// settingsFile is an editor settings file. These are json with comments and
+// trailing commas, so instead of round tripping them through encoding/json,
+// which drops comments and sorts keys, only the values brev owns are edited
+// and everything else is written back byte for byte.
+type settingsFile struct {
+	path   string
+	root   hujson.Value
+	indent string
+}
+
+func parseSettingsFile(path string, existing string, indent string) (*settingsFile, error) {
+	if strings.TrimSpace(existing) == "" {
+		existing = "{}\n"
+	}
+	root, err := hujson.Parse([]byte(existing))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if _, ok := root.Value.(*hujson.Object); !ok {
+		return nil, fmt.Errorf("could not parse %s: settings are not a json object", path)
+	}
+	return &settingsFile{
+		path:   path,
+		root:   root,
+		indent: indent,
+	}, nil
+}
+
+func (f settingsFile) String() string {
+	return string(f.root.Pack())
+}
+
+func (f settingsFile) object() *hujson.Object {
+	return f.root.Value.(*hujson.Object)
+}
+
+// get returns the value of a top level key, nil when it is not set
+func (f settingsFile) get(key string) *hujson.Value {
+	i := memberIndex(f.object(), key)
+	if i < 0 {
+		return nil
+	}
+	return &f.object().Members[i].Value
+}
+
+// set adds a top level key or replaces its value
+func (f *settingsFile) set(key string, v interface{}) error {
+	err := setMember(f.object(), key, v, 0, f.indent)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// decode reads a value ignoring its comments and trailing commas
+func (f settingsFile) decode(key string, value *hujson.Value, v interface{}) error {
+	standard := value.Clone()
+	standard.Standardize()
+	err := json.Unmarshal(standard.Pack(), v)
+	if err != nil {
+		return fmt.Errorf("could not parse %s in %s: %w", key, f.path, err)
+	}
+	return nil
+}
+
+func memberIndex(obj *hujson.Object, name string) int {
+	for i, m := range obj.Members {
+		if lit, ok := m.Name.Value.(hujson.Literal); ok && lit.String() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// setMember replaces the value of name keeping the comments around it, or
+// appends name indented like the other members of obj
+func setMember(obj *hujson.Object, name string, v interface{}, depth int, indent string) error {
+	if i := memberIndex(obj, name); i >= 0 {
+		m := &obj.Members[i]
+		value, err := marshalValue(v, leadingSpace(m.Name.BeforeExtra), indent)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		m.Value.Value = value.Value
+		return nil
+	}
+
+	var last *hujson.Value
+	if n := len(obj.Members); n > 0 {
+		last = &obj.Members[n-1].Name
+	}
+	space := appendSpace(last, &obj.AfterExtra, depth, indent)
+	value, err := marshalValue(v, space, indent)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	value.BeforeExtra = hujson.Extra(" ")
+	value.AfterExtra = nil
+	if n := len(obj.Members); n > 0 {
+		// the last member holds the trailing comma, if any
+		value.AfterExtra = obj.Members[n-1].Value.AfterExtra
+		obj.Members[n-1].Value.AfterExtra = nil
+	}
+	obj.Members = append(obj.Members, hujson.ObjectMember{
+		Name:  hujson.Value{BeforeExtra: hujson.Extra(space), Value: hujson.String(name)},
+		Value: value,
+	})
+	return nil
+}
+
+func removeMember(obj *hujson.Object, i int) {
+	n := len(obj.Members)
+	if i < n-1 {
+		keepLineComment(obj.Members[i].Name.BeforeExtra, &obj.Members[i+1].Name.BeforeExtra)
+	} else {
+		keepLineComment(obj.Members[i].Name.BeforeExtra, &obj.AfterExtra)
+		if i > 0 {
+			obj.Members[i-1].Value.AfterExtra = obj.Members[i].Value.AfterExtra
+		}
+	}
+	obj.Members = append(obj.Members[:i], obj.Members[i+1:]...)
+}
+
+// appendElement adds v at the end of arr indented like the other elements
+func appendElement(arr *hujson.Array, v interface{}, depth int, indent string) error {
+	var last *hujson.Value
+	if n := len(arr.Elements); n > 0 {
+		last = &arr.Elements[n-1]
+	}
+	space := appendSpace(last, &arr.AfterExtra, depth, indent)
+	value, err := marshalValue(v, space, indent)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	value.BeforeExtra = hujson.Extra(space)
+	value.AfterExtra = nil
+	if last != nil {
+		value.AfterExtra = last.AfterExtra
+		last.AfterExtra = nil
+	}
+	arr.Elements = append(arr.Elements, value)
+	return nil
+}
+
+func removeElement(arr *hujson.Array, i int) {
+	n := len(arr.Elements)
+	if i < n-1 {
+		keepLineComment(arr.Elements[i].BeforeExtra, &arr.Elements[i+1].BeforeExtra)
+	} else {
+		keepLineComment(arr.Elements[i].BeforeExtra, &arr.AfterExtra)
+		if i > 0 {
+			arr.Elements[i-1].AfterExtra = arr.Elements[i].AfterExtra
+		}
+	}
+	arr.Elements = append(arr.Elements[:i], arr.Elements[i+1:]...)
+}
+
+// keepLineComment moves a comment at the end of the previous line, which
+// belongs to the value being removed, in front of the value that follows
+func keepLineComment(removed hujson.Extra, next *hujson.Extra) {
+	i := bytes.IndexByte(removed, '\n')
+	if i < 0 || len(bytes.TrimSpace(removed[:i])) == 0 {
+		return
+	}
+	*next = hujson.Extra(string(removed[:i]) + string(*next))
+}
+
+// appendSpace is the whitespace before a value appended after last, comments
+// on the line of last stay on that line
+func appendSpace(last *hujson.Value, closing *hujson.Extra, depth int, indent string) string {
+	if last == nil {
+		if !bytes.Contains(*closing, []byte("\n")) {
+			*closing = hujson.Extra("\n" + strings.Repeat(indent, depth))
+		}
+		return "\n" + strings.Repeat(indent, depth+1)
+	}
+	space := leadingSpace(last.BeforeExtra)
+	i := bytes.LastIndexByte(*closing, '\n')
+	if i < 0 {
+		return space
+	}
+	head := string((*closing)[:i])
+	*closing = hujson.Extra(string((*closing)[i:]))
+	return head + space
+}
+
+// leadingSpace is the newline and indentation before a value, or just the
+// spaces before it when it shares a line with other values
+func leadingSpace(before hujson.Extra) string {
+	if i := bytes.LastIndexByte(before, '\n'); i >= 0 {
+		return string(before[i:])
+	}
+	return string(before[len(bytes.TrimRight(before, " \t")):])
+}
+
+// marshalValue indents v to continue from the line space ends on
+func marshalValue(v interface{}, space string, indent string) (hujson.Value, error) {
+	var data []byte
+	var err error
+	if i := strings.LastIndexByte(space, '\n'); i >= 0 {
+		data, err = json.MarshalIndent(v, space[i+1:], indent)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return hujson.Value{}, breverrors.WrapAndTrace(err)
+	}
+	value, err := hujson.Parse(data)
+	if err != nil {
+		return hujson.Value{}, breverrors.WrapAndTrace(err)
+	}
+	return value, nil
+}
@@ -0,0 +1,216 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// SSHOverrides are ssh options merged into the generated host entries so
+// they survive the next sync, read from ~/.brev/ssh_overrides.yaml, ex:
+//
+//	defaults:
+//	  ForwardAgent: yes
+//	  ServerAliveInterval: 60
+//	workspaces:
+//	  my-workspace: # name, id or host of the workspace
+//	    aliases: [api]
+//	    options:
+//	      LocalForward:
+//	        - 8080 localhost:8080
+//	        - 5432 localhost:5432
+type SSHOverrides struct {
+	Defaults   SSHOptions                      `json:"defaults,omitempty"`
+	Workspaces map[string]WorkspaceSSHOverride `json:"workspaces,omitempty"`
+}
+
+type WorkspaceSSHOverride struct {
+	Aliases []string   `json:"aliases,omitempty"`
+	Options SSHOptions `json:"options,omitempty"`
+}
+
+// SSHOptions maps an ssh_config keyword to its values, options like
+// LocalForward can be given more than once
+type SSHOptions map[string]SSHOptionValues
+
+type SSHOptionValues []string
+
+// UnmarshalJSON takes a single value or a list, yaml booleans are written
+// as yes and no
+func (v *SSHOptionValues) UnmarshalJSON(data []byte) error {
+	var list []interface{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		var single interface{}
+		err = json.Unmarshal(data, &single)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		list = []interface{}{single}
+	}
+	values := SSHOptionValues{}
+	for _, item := range list {
+		switch i := item.(type) {
+		case string:
+			values = append(values, i)
+		case bool:
+			if i {
+				values = append(values, "yes")
+			} else {
+				values = append(values, "no")
+			}
+		case float64:
+			values = append(values, strconv.FormatFloat(i, 'f', -1, 64))
+		default:
+			return fmt.Errorf("invalid ssh option value %v", item)
+		}
+	}
+	*v = values
+	return nil
+}
+
+type SSHOption struct {
+	Key   string
+	Value string
+}
+
+// reservedSSHOptions are set by brev for the proxy and key to work, and Host
+// and Match would start a new block
+var reservedSSHOptions = map[string]bool{
+	"host":            true,
+	"match":           true,
+	"include":         true,
+	"hostname":        true,
+	"port":            true,
+	"user":            true,
+	"identityfile":    true,
+	"certificatefile": true,
+	"proxycommand":    true,
+	"proxyjump":       true,
+	"proxyusefdpass":  true,
+}
+
+var (
+	sshOptionKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	sshAliasPattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// ParseSSHOverrides parses and validates an overrides file, an empty file
+// has no overrides
+func ParseSSHOverrides(data string) (*SSHOverrides, error) {
+	overrides := &SSHOverrides{}
+	err := yaml.UnmarshalStrict([]byte(data), overrides)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = overrides.Validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return overrides, nil
+}
+
+func (o SSHOverrides) Validate() error {
+	err := validateSSHOptions(o.Defaults)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	aliases := map[string]string{}
+	for workspace, override := range o.Workspaces {
+		err = validateSSHOptions(override.Options)
+		if err != nil {
+			return breverrors.WrapAndTrace(fmt.Errorf("%s: %w", workspace, err))
+		}
+		for _, alias := range override.Aliases {
+			if !sshAliasPattern.MatchString(alias) {
+				return breverrors.NewValidationError(fmt.Sprintf("%s: invalid alias %q, use letters, numbers, '.', '_' and '-'", workspace, alias))
+			}
+			if other, ok := aliases[alias]; ok && other != workspace {
+				return breverrors.NewValidationError(fmt.Sprintf("alias %s is used by both %s and %s", alias, other, workspace))
+			}
+			aliases[alias] = workspace
+		}
+	}
+	return nil
+}
+
+func validateSSHOptions(options SSHOptions) error {
+	for key, values := range options {
+		if !sshOptionKeyPattern.MatchString(key) {
+			return breverrors.NewValidationError(fmt.Sprintf("invalid ssh option %q", key))
+		}
+		if reservedSSHOptions[strings.ToLower(key)] {
+			return breverrors.NewValidationError(fmt.Sprintf("%s can not be overridden, brev sets it to reach the workspace", key))
+		}
+		for _, value := range values {
+			if strings.ContainsAny(value, "\r\n") || strings.TrimSpace(value) == "" {
+				return breverrors.NewValidationError(fmt.Sprintf("invalid value %q for ssh option %s", value, key))
+			}
+		}
+	}
+	return nil
+}
+
+// ForWorkspace is the defaults with the options of the workspace on top, the
+// workspace is matched by name, id or host
+func (o SSHOverrides) ForWorkspace(w entity.Workspace) WorkspaceSSHOverride {
+	result := WorkspaceSSHOverride{Options: SSHOptions{}}
+	for key, values := range o.Defaults {
+		result.Options[key] = values
+	}
+	seen := map[string]bool{}
+	for _, id := range []string{w.Name, w.ID, string(w.GetLocalIdentifier())} {
+		override, ok := o.Workspaces[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result.Aliases = append(result.Aliases, override.Aliases...)
+		for key, values := range override.Options {
+			setSSHOption(result.Options, key, values)
+		}
+	}
+	return result
+}
+
+// setSSHOption replaces any value of key regardless of case, ssh_config
+// keywords are case insensitive
+func setSSHOption(options SSHOptions, key string, values SSHOptionValues) {
+	for existing := range options {
+		if strings.EqualFold(existing, key) {
+			delete(options, existing)
+		}
+	}
+	options[key] = values
+}
+
+// MergeSSHOptions puts the overrides on top of the options brev generates,
+// sorted so the entry only changes when the options do
+func MergeSSHOptions(generated SSHOptions, overrides SSHOptions) []SSHOption {
+	merged := SSHOptions{}
+	for key, values := range generated {
+		merged[key] = values
+	}
+	for key, values := range overrides {
+		setSSHOption(merged, key, values)
+	}
+	keys := []string{}
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	options := []SSHOption{}
+	for _, key := range keys {
+		for _, value := range merged[key] {
+			options = append(options, SSHOption{Key: key, Value: value})
+		}
+	}
+	return options
+}
@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type overridesSSHConfigurerV2Store struct {
+	DummySSHConfigurerV2Store
+	overrides string
+}
+
+func (o overridesSSHConfigurerV2Store) GetSSHOverrides() (string, error) {
+	return o.overrides, nil
+}
+
+const someSSHOverrides = `
+defaults:
+  ForwardAgent: yes
+  ServerAliveInterval: 60
+workspaces:
+  testName1:
+    aliases: [api, testname2-id-2]
+    options:
+      LocalForward:
+        - 8080 localhost:8080
+        - 5432 localhost:5432
+      forwardagent: no
+`
+
+func TestCreateNewSSHConfigWithOverrides(t *testing.T) {
+	c := NewSSHConfigurerV2(overridesSSHConfigurerV2Store{overrides: someSSHOverrides})
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+	// testname2-id-2 is the host of the other workspace so it is not an alias
+	correct := fmt.Sprintf(`# included in /my/user/config
+Host %s api
+  IdentityFile /my/priv/key.pem
+  User brev
+  ProxyCommand brev proxy test-id-1
+  LocalForward 8080 localhost:8080
+  LocalForward 5432 localhost:5432
+  ServerAliveInterval 60
+  forwardagent no
+
+Host %s
+  IdentityFile /my/priv/key.pem
+  User brev
+  ProxyCommand brev proxy test-id-2
+  ForwardAgent yes
+  ServerAliveInterval 60
+
+`, somePlainWorkspaces[0].GetLocalIdentifier(),
+		somePlainWorkspaces[1].GetLocalIdentifier())
+	assert.Equal(t, correct, cStr)
+}
+
+func TestCreateNewServiceMeshSSHConfigWithOverrides(t *testing.T) {
+	c := NewSSHConfigurerServiceMesh(overridesSSHConfigurerV2Store{overrides: "defaults:\n  ForwardAgent: yes\n"})
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces[:1])
+	assert.Nil(t, err)
+	correct := fmt.Sprintf(`# included in /my/user/config
+Host %s
+  HostName %s
+  IdentityFile /my/priv/key.pem
+  User brev
+  Port 22
+  ForwardAgent yes
+  ServerAliveInterval 30
+
+`, somePlainWorkspaces[0].GetLocalIdentifier(), somePlainWorkspaces[0].GetNodeIdentifierForVPN())
+	assert.Equal(t, correct, cStr)
+}
+
+func TestParseSSHOverridesValidation(t *testing.T) {
+	o, err := ParseSSHOverrides("")
+	assert.Nil(t, err)
+	assert.Empty(t, o.ForWorkspace(somePlainWorkspaces[0]).Options)
+
+	invalid := []string{
+		"defaults:\n  ProxyCommand: nc %h %p\n",
+		"defaults:\n  hostname: 1.2.3.4\n",
+		"workspaces:\n  testName1:\n    options:\n      User: root\n",
+		"defaults:\n  \"Forward Agent\": yes\n",
+		"workspaces:\n  testName1:\n    aliases: [\"*\"]\n",
+		"workspaces:\n  a:\n    aliases: [x]\n  b:\n    aliases: [x]\n",
+		"defaults:\n  ForwardAgent: [{a: b}]\n",
+		"default:\n  ForwardAgent: yes\n",
+	}
+	for _, data := range invalid {
+		_, err = ParseSSHOverrides(data)
+		assert.NotNil(t, err, data)
+	}
+
+	// an invalid file is ignored instead of breaking every workspace
+	c := NewSSHConfigurerV2(overridesSSHConfigurerV2Store{overrides: invalid[0]})
+	cStr, err := c.CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+	withoutOverrides, err := NewSSHConfigurerV2(overridesSSHConfigurerV2Store{}).CreateNewSSHConfig(somePlainWorkspaces)
+	assert.Nil(t, err)
+	assert.Equal(t, withoutOverrides, cStr)
+}
@@ -355,6 +355,11 @@ func NewSSHConfigurer(workspaces []entity.WorkspaceWithMeta, reader Reader, writ
 	}
 }
 
+// SetWorkspaces changes the workspaces the next Sync configures
+func (sshConfigurer *SSHConfigurer) SetWorkspaces(workspaces []entity.WorkspaceWithMeta) {
+	sshConfigurer.workspaces = workspaces
+}
+
 func (sshConfigurer SSHConfigurer) GetPrivateKeyPath() (string, error) {
 	return sshConfigurer.privateKey, nil
 }
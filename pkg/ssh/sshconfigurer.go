@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/autostartconf"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/hashicorp/go-multierror"
 )
 
@@ -20,6 +23,7 @@ type ConfigUpdaterStore interface {
 	autostartconf.AutoStartStore
 	GetContextWorkspaces() ([]entity.Workspace, error)
 	WritePrivateKey(pem string) error
+	PruneSSHKeyRotation(runningWorkspaceIDs []string) error
 }
 
 type Config interface {
@@ -44,11 +48,18 @@ func (c ConfigUpdater) Run() error {
 		return breverrors.WrapAndTrace(err)
 	}
 	var runningWorkspaces []entity.Workspace
+	runningWorkspaceIDs := []string{}
 	for _, workspace := range workspaces {
 		if workspace.Status == "RUNNING" {
 			runningWorkspaces = append(runningWorkspaces, workspace)
+			runningWorkspaceIDs = append(runningWorkspaceIDs, workspace.ID)
 		}
 	}
+	// workspaces that stopped since the last key rotation get the new key on start
+	err = c.Store.PruneSSHKeyRotation(runningWorkspaceIDs)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 
 	var res error
 	for _, c := range c.Configs {
@@ -105,6 +116,12 @@ type SSHConfigurerV2Store interface {
 	GetJetBrainsConfig() (string, error)
 	WriteJetBrainsConfig(config string) error
 	DoesJetbrainsFilePathExist() (bool, error)
+	GetSSHOverrides() (string, error)
+	GetSSHCertificate(workspaceGroupID string) (string, error)
+	GetSSHCertificatePath(workspaceGroupID string) (string, error)
+	GetSSHCertificateKeyPath() (string, error)
+	GetSSHKeyRotation() (*entity.SSHKeyRotation, error)
+	GetPreviousPrivateKeyPath() (string, error)
 }
 
 var _ Config = SSHConfigurerV2{}
@@ -142,13 +159,32 @@ func (s SSHConfigurerV2) CreateNewSSHConfig(workspaces []entity.Workspace) (stri
 		return "", breverrors.WrapAndTrace(err)
 	}
 
+	overrides, err := getSSHOverrides(s.store)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	rotation, err := s.store.GetSSHKeyRotation()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+
 	sshConfig := fmt.Sprintf("# included in %s\n", configPath)
 	for _, w := range workspaces {
 		pk, err := s.store.GetPrivateKeyPath()
 		if err != nil {
 			return "", breverrors.WrapAndTrace(err)
 		}
-		entry, err := makeSSHConfigEntryV2(string(w.GetLocalIdentifier()), w.ID, pk)
+		override := overrides.ForWorkspace(w)
+		alias := makeHostPatterns(w, override.Aliases, workspaces)
+		certificate, err := getSSHCertificateFiles(s.store, w)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		previousPK, err := getPreviousPrivateKeyPath(s.store, rotation, w)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		entry, err := makeSSHConfigEntryV2(alias, w.ID, pk, previousPK, certificate, override.Options)
 		if err != nil {
 			return "", breverrors.WrapAndTrace(err)
 		}
@@ -160,27 +196,45 @@ func (s SSHConfigurerV2) CreateNewSSHConfig(workspaces []entity.Workspace) (stri
 }
 
 const SSHConfigEntryTemplateV2 = `Host {{ .Alias }}
+{{- if .Certificate }}
+  IdentityFile {{ .Certificate.KeyFile }}
+  CertificateFile {{ .Certificate.CertificateFile }}
+{{- end }}
   IdentityFile {{ .IdentityFile }}
+{{- if .PreviousIdentityFile }}
+  IdentityFile {{ .PreviousIdentityFile }}
+{{- end }}
   User {{ .User }}
   ProxyCommand {{ .ProxyCommand }}
-  ServerAliveInterval 30
+{{- range .Options }}
+  {{ .Key }} {{ .Value }}
+{{- end }}
 
 `
 
 type SSHConfigEntryV2 struct {
-	Alias        string
-	IdentityFile string
-	User         string
-	ProxyCommand string
+	Alias                string
+	IdentityFile         string
+	PreviousIdentityFile string
+	Certificate          *SSHCertificateFiles
+	User                 string
+	ProxyCommand         string
+	Options              []SSHOption
 }
 
-func makeSSHConfigEntryV2(alias string, workspaceID string, privateKeyPath string) (string, error) {
+// defaultSSHOptions are generated for every host, the overrides can change them
+var defaultSSHOptions = SSHOptions{"ServerAliveInterval": {"30"}}
+
+func makeSSHConfigEntryV2(alias string, workspaceID string, privateKeyPath string, previousPrivateKeyPath string, certificate *SSHCertificateFiles, options SSHOptions) (string, error) {
 	proxyCommand := makeProxyCommand(workspaceID)
 	entry := SSHConfigEntryV2{
-		Alias:        alias,
-		IdentityFile: privateKeyPath,
-		User:         "brev",
-		ProxyCommand: proxyCommand,
+		Alias:                alias,
+		IdentityFile:         privateKeyPath,
+		PreviousIdentityFile: previousPrivateKeyPath,
+		Certificate:          certificate,
+		User:                 "brev",
+		ProxyCommand:         proxyCommand,
+		Options:              MergeSSHOptions(defaultSSHOptions, options),
 	}
 
 	tmpl, err := template.New(alias).Parse(SSHConfigEntryTemplateV2)
@@ -201,6 +255,99 @@ func makeProxyCommand(workspaceID string) string {
 	return fmt.Sprintf("%s %s", huproxyExec, workspaceID)
 }
 
+// getSSHOverrides is empty when ~/.brev/ssh_overrides.yaml is invalid so a
+// mistake in it does not stop the config of every workspace from updating
+func getSSHOverrides(store SSHConfigurerV2Store) (*SSHOverrides, error) {
+	data, err := store.GetSSHOverrides()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	overrides, err := ParseSSHOverrides(data)
+	if err != nil {
+		warnOnce(fmt.Sprintf("ignoring invalid ~/.brev/ssh_overrides.yaml: %v", err))
+		return ParseSSHOverrides("")
+	}
+	return overrides, nil
+}
+
+var (
+	warnTerminal = terminal.New()
+	warned       sync.Map
+)
+
+// warnOnce prints to stderr, the ssh config is regenerated every few seconds
+// by the daemon so each warning is only printed once per process
+func warnOnce(msg string) {
+	if _, seen := warned.LoadOrStore(msg, true); seen {
+		return
+	}
+	warnTerminal.Eprint(warnTerminal.Yellow(msg))
+}
+
+// SSHCertificateFiles are tried before the brev private key when the
+// workspace group has issued a certificate that has not expired. The brev
+// key stays in the config after them, workspaces still authorize it and it
+// is what is used when a renewal fails.
+type SSHCertificateFiles struct {
+	KeyFile         string
+	CertificateFile string
+}
+
+func getSSHCertificateFiles(store SSHConfigurerV2Store, w entity.Workspace) (*SSHCertificateFiles, error) {
+	if w.WorkspaceGroupID == "" {
+		return nil, nil
+	}
+	certificate, err := store.GetSSHCertificate(w.WorkspaceGroupID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if _, ok := GetValidSSHCertificate(certificate, time.Now()); !ok {
+		return nil, nil
+	}
+	keyPath, err := store.GetSSHCertificateKeyPath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	certificatePath, err := store.GetSSHCertificatePath(w.WorkspaceGroupID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &SSHCertificateFiles{KeyFile: keyPath, CertificateFile: certificatePath}, nil
+}
+
+// getPreviousPrivateKeyPath is set for workspaces that were running when the
+// key was rotated, they only know the previous key until they restart
+func getPreviousPrivateKeyPath(store SSHConfigurerV2Store, rotation *entity.SSHKeyRotation, w entity.Workspace) (string, error) {
+	if rotation == nil || !rotation.IsPending(w.ID) {
+		return "", nil
+	}
+	path, err := store.GetPreviousPrivateKeyPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// makeHostPatterns is the Host line of a workspace, its host followed by
+// the aliases from the overrides that do not clash with another workspace
+func makeHostPatterns(w entity.Workspace, aliases []string, workspaces []entity.Workspace) string {
+	taken := map[string]bool{}
+	for _, other := range workspaces {
+		taken[string(other.GetLocalIdentifier())] = true
+	}
+	host := string(w.GetLocalIdentifier())
+	patterns := []string{host}
+	for _, alias := range aliases {
+		if taken[alias] {
+			warnOnce(fmt.Sprintf("ignoring ssh alias %s of %s, it is the host of a workspace", alias, host))
+			continue
+		}
+		taken[alias] = true
+		patterns = append(patterns, alias)
+	}
+	return strings.Join(patterns, " ")
+}
+
 func (s SSHConfigurerV2) EnsureConfigHasInclude() error {
 	// openssh-7.3
 	log.Print("ensuring has include")
@@ -213,7 +360,7 @@ func (s SSHConfigurerV2) EnsureConfigHasInclude() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if !doesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+	if !DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
 		newConf, err := AddIncludeToUserConfig(conf, brevConfigPath)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
@@ -236,7 +383,7 @@ func makeIncludeBrevStr(brevSSHConfigPath string) string {
 	return fmt.Sprintf("Include %s\n", brevSSHConfigPath)
 }
 
-func doesUserSSHConfigIncludeBrevConfig(conf string, brevConfigPath string) bool {
+func DoesUserSSHConfigIncludeBrevConfig(conf string, brevConfigPath string) bool {
 	return strings.Contains(conf, makeIncludeBrevStr(brevConfigPath))
 }
 
@@ -283,7 +430,7 @@ func (s SSHConfigurerServiceMesh) EnsureConfigHasInclude() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if !doesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+	if !DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
 		newConf, err := AddIncludeToUserConfig(conf, brevConfigPath)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
@@ -305,13 +452,32 @@ func (s SSHConfigurerServiceMesh) CreateNewSSHConfig(workspaces []entity.Workspa
 		return "", breverrors.WrapAndTrace(err)
 	}
 
+	overrides, err := getSSHOverrides(s.store)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	rotation, err := s.store.GetSSHKeyRotation()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+
 	sshConfig := fmt.Sprintf("# included in %s\n", configPath)
 	for _, w := range workspaces {
 		pk, err := s.store.GetPrivateKeyPath()
 		if err != nil {
 			return "", breverrors.WrapAndTrace(err)
 		}
-		entry, err := makeSSHConfigServiceMeshEntry(string(w.GetLocalIdentifier()), w.GetNodeIdentifierForVPN(), pk)
+		override := overrides.ForWorkspace(w)
+		alias := makeHostPatterns(w, override.Aliases, workspaces)
+		certificate, err := getSSHCertificateFiles(s.store, w)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		previousPK, err := getPreviousPrivateKeyPath(s.store, rotation, w)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		entry, err := makeSSHConfigServiceMeshEntry(alias, w.GetNodeIdentifierForVPN(), pk, previousPK, certificate, override.Options)
 		if err != nil {
 			return "", breverrors.WrapAndTrace(err)
 		}
@@ -324,28 +490,43 @@ func (s SSHConfigurerServiceMesh) CreateNewSSHConfig(workspaces []entity.Workspa
 
 const SSHConfigEntryTemplateServiceMesh = `Host {{ .Alias }}
   HostName {{ .Host }}
+{{- if .Certificate }}
+  IdentityFile {{ .Certificate.KeyFile }}
+  CertificateFile {{ .Certificate.CertificateFile }}
+{{- end }}
   IdentityFile {{ .IdentityFile }}
+{{- if .PreviousIdentityFile }}
+  IdentityFile {{ .PreviousIdentityFile }}
+{{- end }}
   User {{ .User }}
   Port {{ .Port }}
-  ServerAliveInterval 30
+{{- range .Options }}
+  {{ .Key }} {{ .Value }}
+{{- end }}
 
 `
 
 type SSHConfigEntryServiceMesh struct {
-	Alias        string
-	Host         string
-	IdentityFile string
-	User         string
-	Port         string
+	Alias                string
+	Host                 string
+	IdentityFile         string
+	PreviousIdentityFile string
+	Certificate          *SSHCertificateFiles
+	User                 string
+	Port                 string
+	Options              []SSHOption
 }
 
-func makeSSHConfigServiceMeshEntry(alias string, host string, privateKeyPath string) (string, error) {
+func makeSSHConfigServiceMeshEntry(alias string, host string, privateKeyPath string, previousPrivateKeyPath string, certificate *SSHCertificateFiles, options SSHOptions) (string, error) {
 	entry := SSHConfigEntryServiceMesh{
-		Alias:        alias,
-		Host:         host,
-		IdentityFile: privateKeyPath,
-		User:         "brev",
-		Port:         "22",
+		Alias:                alias,
+		Host:                 host,
+		IdentityFile:         privateKeyPath,
+		PreviousIdentityFile: previousPrivateKeyPath,
+		Certificate:          certificate,
+		User:                 "brev",
+		Port:                 "22",
+		Options:              MergeSSHOptions(defaultSSHOptions, options),
 	}
 
 	tmpl, err := template.New(host).Parse(SSHConfigEntryTemplateServiceMesh)
@@ -131,16 +131,16 @@ func TestDoesUserSSHConfigIncludeBrevConfig(t *testing.T) {
 	}
 
 	userConf := ``
-	assert.False(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.False(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 
 	userConf = `Include /my/brev/config
 `
-	assert.True(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.True(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 
 	userConf = `# blahdlkfadlfa
 Include /my/brev/config
 # baldfhaldjf`
-	assert.True(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.True(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 }
 
 func TestAddIncludeToUserConfig(t *testing.T) {
@@ -170,3 +170,27 @@ blaksdf;asdf;
 ` + userConf
 	assert.Equal(t, correct, newConf)
 }
+
+func (d DummySSHConfigurerV2Store) GetSSHOverrides() (string, error) {
+	return "", nil
+}
+
+func (d DummySSHConfigurerV2Store) GetSSHCertificate(_ string) (string, error) {
+	return "", nil
+}
+
+func (d DummySSHConfigurerV2Store) GetSSHCertificatePath(workspaceGroupID string) (string, error) {
+	return "/my/brev/certs/" + workspaceGroupID + "-cert.pub", nil
+}
+
+func (d DummySSHConfigurerV2Store) GetSSHCertificateKeyPath() (string, error) {
+	return "/my/brev/certs/brev-session.pem", nil
+}
+
+func (d DummySSHConfigurerV2Store) GetSSHKeyRotation() (*entity.SSHKeyRotation, error) {
+	return nil, nil
+}
+
+func (d DummySSHConfigurerV2Store) GetPreviousPrivateKeyPath() (string, error) {
+	return "/my/priv/previous.pem", nil
+}
@@ -0,0 +1,403 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/tailscale/hujson"
+)
+
+// SSHConfigTargetStore is what the optional ssh config targets read and write
+type SSHConfigTargetStore interface {
+	GetSSHConfigTargets() ([]string, error)
+	GetBrevSSHConfigPath() (string, error)
+	GetVSCodeSettingsPath() (string, error)
+	GetVSCodeHostsPath() (string, error)
+	GetZedSettingsPath() (string, error)
+	GetTrampConfigPath() (string, error)
+	GetSSHTargetConfig(path string) (string, error)
+	WriteSSHTargetConfig(path string, config string) error
+}
+
+// ConfigTargetFactory makes the Config for an ssh config target
+type ConfigTargetFactory func(store SSHConfigTargetStore) (Config, error)
+
+// configTargets are the targets users can turn on by adding their name to
+// sshConfigTargets in ~/.brev/personal_settings.json
+var configTargets = map[string]ConfigTargetFactory{}
+
+func init() {
+	RegisterConfigTarget("vscode", func(store SSHConfigTargetStore) (Config, error) {
+		return NewSSHConfigurerVSCode(store), nil
+	})
+	RegisterConfigTarget("zed", func(store SSHConfigTargetStore) (Config, error) {
+		return NewSSHConfigurerZed(store), nil
+	})
+	RegisterConfigTarget("tramp", func(store SSHConfigTargetStore) (Config, error) {
+		return NewSSHConfigurerTramp(store), nil
+	})
+}
+
+func RegisterConfigTarget(name string, factory ConfigTargetFactory) {
+	configTargets[name] = factory
+}
+
+func GetConfigTargetNames() []string {
+	names := []string{}
+	for name := range configTargets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func NewConfigTarget(name string, store SSHConfigTargetStore) (Config, error) {
+	factory, ok := configTargets[name]
+	if !ok {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unknown ssh config target %s, available targets: %s", name, strings.Join(GetConfigTargetNames(), ", ")))
+	}
+	config, err := factory(store)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return config, nil
+}
+
+// GetEnabledConfigTargets makes the Configs for the targets in the personal
+// settings
+func GetEnabledConfigTargets(store SSHConfigTargetStore) ([]Config, error) {
+	names, err := store.GetSSHConfigTargets()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	configs := []Config{}
+	for _, name := range names {
+		config, err := NewConfigTarget(name, store)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// updateTargetFile regenerates the brev part of a file also edited by the
+// user or another tool, the file is left alone when nothing changed
+func updateTargetFile(store SSHConfigTargetStore, path string, create func(existing string) (string, error)) error {
+	existing, err := store.GetSSHTargetConfig(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	newConfig, err := create(existing)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if newConfig == existing {
+		return nil
+	}
+	err = store.WriteSSHTargetConfig(path, newConfig)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+const vscodeRemotePlatformKey = "remote.SSH.remotePlatform"
+
+// SSHConfigurerVSCode tells VS Code Remote-SSH workspace hosts are linux so
+// it does not ask on every connect
+type SSHConfigurerVSCode struct {
+	store SSHConfigTargetStore
+}
+
+var _ Config = SSHConfigurerVSCode{}
+
+func NewSSHConfigurerVSCode(store SSHConfigTargetStore) *SSHConfigurerVSCode {
+	return &SSHConfigurerVSCode{
+		store: store,
+	}
+}
+
+func (s SSHConfigurerVSCode) Update(workspaces []entity.Workspace) error {
+	path, err := s.store.GetVSCodeSettingsPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	hostsPath, err := s.store.GetVSCodeHostsPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	brevHosts, err := s.store.GetSSHTargetConfig(hostsPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = updateTargetFile(s.store, path, func(existing string) (string, error) {
+		return s.CreateNewSSHConfig(path, existing, strings.Fields(brevHosts), workspaces)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = updateTargetFile(s.store, hostsPath, func(_ string) (string, error) {
+		hosts := ""
+		for _, w := range workspaces {
+			hosts += string(w.GetLocalIdentifier()) + "\n"
+		}
+		return hosts, nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// CreateNewSSHConfig adds workspace hosts to remote.SSH.remotePlatform and
+// removes brevHosts, the hosts brev added before, whose workspaces are gone.
+// Hosts added by the user are kept.
+func (s SSHConfigurerVSCode) CreateNewSSHConfig(path string, existing string, brevHosts []string, workspaces []entity.Workspace) (string, error) {
+	settings, err := parseSettingsFile(path, existing, "    ")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	aliases := []string{}
+	current := map[string]bool{}
+	for _, w := range workspaces {
+		alias := string(w.GetLocalIdentifier())
+		aliases = append(aliases, alias)
+		current[alias] = true
+	}
+
+	value := settings.get(vscodeRemotePlatformKey)
+	if value == nil {
+		if len(aliases) == 0 {
+			return existing, nil
+		}
+		platforms := map[string]string{}
+		for _, alias := range aliases {
+			platforms[alias] = "linux"
+		}
+		err = settings.set(vscodeRemotePlatformKey, platforms)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return settings.String(), nil
+	}
+	platforms, ok := value.Value.(*hujson.Object)
+	if !ok {
+		return "", fmt.Errorf("could not parse %s in %s: not a json object", vscodeRemotePlatformKey, path)
+	}
+
+	changed := false
+	for _, host := range brevHosts {
+		if i := memberIndex(platforms, host); i >= 0 && !current[host] {
+			removeMember(platforms, i)
+			changed = true
+		}
+	}
+	for _, alias := range aliases {
+		if i := memberIndex(platforms, alias); i >= 0 {
+			if platform, ok := platforms.Members[i].Value.Value.(hujson.Literal); ok && platform.String() == "linux" {
+				continue
+			}
+		}
+		err = setMember(platforms, alias, "linux", 1, settings.indent)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		changed = true
+	}
+	if !changed {
+		return existing, nil
+	}
+	return settings.String(), nil
+}
+
+const zedSSHConnectionsKey = "ssh_connections"
+
+// SSHConfigurerZed adds workspace hosts to the Zed remote projects list
+type SSHConfigurerZed struct {
+	store SSHConfigTargetStore
+}
+
+var _ Config = SSHConfigurerZed{}
+
+func NewSSHConfigurerZed(store SSHConfigTargetStore) *SSHConfigurerZed {
+	return &SSHConfigurerZed{
+		store: store,
+	}
+}
+
+type ZedSSHProject struct {
+	Paths []string `json:"paths"`
+}
+
+type ZedSSHConnection struct {
+	Host     string          `json:"host"`
+	Username string          `json:"username,omitempty"`
+	Nickname string          `json:"nickname,omitempty"`
+	Projects []ZedSSHProject `json:"projects,omitempty"`
+}
+
+func (s SSHConfigurerZed) Update(workspaces []entity.Workspace) error {
+	path, err := s.store.GetZedSettingsPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = updateTargetFile(s.store, path, func(existing string) (string, error) {
+		return s.CreateNewSSHConfig(path, existing, workspaces)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// CreateNewSSHConfig replaces the connections brev wrote, the ones with the
+// brev user, and keeps every other connection as is
+func (s SSHConfigurerZed) CreateNewSSHConfig(path string, existing string, workspaces []entity.Workspace) (string, error) {
+	settings, err := parseSettingsFile(path, existing, "  ")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	brevConnections := []ZedSSHConnection{}
+	for _, w := range workspaces {
+		brevConnections = append(brevConnections, ZedSSHConnection{
+			Host:     string(w.GetLocalIdentifier()),
+			Username: "brev",
+			Nickname: w.Name,
+			Projects: []ZedSSHProject{{Paths: []string{"/home/brev/workspace"}}},
+		})
+	}
+
+	value := settings.get(zedSSHConnectionsKey)
+	if value == nil {
+		if len(brevConnections) == 0 {
+			return existing, nil
+		}
+		err = settings.set(zedSSHConnectionsKey, brevConnections)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+		return settings.String(), nil
+	}
+	connections, ok := value.Value.(*hujson.Array)
+	if !ok {
+		return "", fmt.Errorf("could not parse %s in %s: not a json array", zedSSHConnectionsKey, path)
+	}
+
+	oldBrevConnections := []ZedSSHConnection{}
+	oldBrevIndexes := []int{}
+	for i := range connections.Elements {
+		var c ZedSSHConnection
+		err = settings.decode(zedSSHConnectionsKey, &connections.Elements[i], &c)
+		if err == nil && c.Username == "brev" {
+			oldBrevConnections = append(oldBrevConnections, c)
+			oldBrevIndexes = append(oldBrevIndexes, i)
+		}
+	}
+	if sameZedConnections(oldBrevConnections, brevConnections) {
+		return existing, nil
+	}
+	for i := len(oldBrevIndexes) - 1; i >= 0; i-- {
+		removeElement(connections, oldBrevIndexes[i])
+	}
+	for _, c := range brevConnections {
+		err = appendElement(connections, c, 1, settings.indent)
+		if err != nil {
+			return "", breverrors.WrapAndTrace(err)
+		}
+	}
+	return settings.String(), nil
+}
+
+func sameZedConnections(a, b []ZedSSHConnection) bool {
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aj, bj)
+}
+
+// SSHConfigurerTramp writes ~/.brev/brev-tramp.el which adds a brev TRAMP
+// method, load it from your emacs init to open /brev:<host>:/home/brev/workspace
+type SSHConfigurerTramp struct {
+	store SSHConfigTargetStore
+}
+
+var _ Config = SSHConfigurerTramp{}
+
+func NewSSHConfigurerTramp(store SSHConfigTargetStore) *SSHConfigurerTramp {
+	return &SSHConfigurerTramp{
+		store: store,
+	}
+}
+
+func (s SSHConfigurerTramp) Update(workspaces []entity.Workspace) error {
+	path, err := s.store.GetTrampConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	brevConfigPath, err := s.store.GetBrevSSHConfigPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = updateTargetFile(s.store, path, func(_ string) (string, error) {
+		return s.CreateNewSSHConfig(brevConfigPath, workspaces)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+const trampConfigTemplate = `;;; brev-tramp.el --- generated by brev, edits are overwritten
+;;
+;; (load "~/.brev/brev-tramp.el") in your init file, then open
+;; /brev:<host>:/home/brev/workspace
+;;
+;; hosts:
+{{- range . }}
+;;   {{ . }}
+{{- end }}
+
+(with-eval-after-load 'tramp
+  (require 'tramp-sh)
+  (add-to-list 'tramp-methods (cons "brev" (cdr (assoc "ssh" tramp-methods))))
+  (add-to-list 'tramp-default-user-alist '("brev" nil "brev"))
+  (tramp-set-completion-function "brev" '((tramp-parse-sconfig {{ elispString configPath }}))))
+`
+
+// elispString quotes s as an elisp string literal, inside one only a
+// backslash and a double quote are special
+func elispString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (s SSHConfigurerTramp) CreateNewSSHConfig(brevConfigPath string, workspaces []entity.Workspace) (string, error) {
+	hosts := []string{}
+	for _, w := range workspaces {
+		hosts = append(hosts, string(w.GetLocalIdentifier()))
+	}
+	tmpl, err := template.New("tramp").Funcs(template.FuncMap{
+		"configPath":  func() string { return brevConfigPath },
+		"elispString": elispString,
+	}).Parse(trampConfigTemplate)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, hosts)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func assertGolden(t *testing.T, name string, actual string) {
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		err := ioutil.WriteFile(path, []byte(actual), 0o644) //nolint:gosec // test data
+		assert.Nil(t, err)
+	}
+	expected, err := ioutil.ReadFile(path) //nolint:gosec // test data
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, string(expected), actual)
+}
+
+const vscodeSettings = `{
+    "editor.fontSize": 14,
+    "remote.SSH.remotePlatform": {
+        "my-box": "linux"
+    }
+}
+`
+
+func TestCreateNewVSCodeConfig(t *testing.T) {
+	c := NewSSHConfigurerVSCode(nil)
+	config, err := c.CreateNewSSHConfig("settings.json", vscodeSettings, nil, somePlainWorkspaces)
+	assert.Nil(t, err)
+	assertGolden(t, "vscode_settings.json.golden", config)
+
+	// already up to date, the file is not reformatted
+	again, err := c.CreateNewSSHConfig("settings.json", config, nil, somePlainWorkspaces)
+	assert.Nil(t, err)
+	assert.Equal(t, config, again)
+
+	config, err = c.CreateNewSSHConfig("settings.json", "", nil, somePlainWorkspaces[:1])
+	assert.Nil(t, err)
+	assertGolden(t, "vscode_settings_empty.json.golden", config)
+}
+
+const vscodeSettingsJSONC = `{
+    // keep me
+    "remote.SSH.remotePlatform": {
+        "my-box": "linux", // my own box
+        "testname1-id-1": "linux",
+        "gone-ws-1234": "linux",
+    },
+    "editor.fontSize": 14,
+}
+`
+
+func TestCreateNewVSCodeConfigJSONC(t *testing.T) {
+	c := NewSSHConfigurerVSCode(nil)
+	config, err := c.CreateNewSSHConfig("settings.json", vscodeSettingsJSONC, []string{"testname1-id-1", "gone-ws-1234"}, somePlainWorkspaces[1:])
+	assert.Nil(t, err)
+	// comments, trailing commas and key order are kept, only brev hosts of
+	// workspaces that are gone are pruned and the user's own box stays
+	assert.Equal(t, `{
+    // keep me
+    "remote.SSH.remotePlatform": {
+        "my-box": "linux", // my own box
+        "testname2-id-2": "linux",
+    },
+    "editor.fontSize": 14,
+}
+`, config)
+
+	_, err = c.CreateNewSSHConfig("settings.json", "{\n  \"a\": 1,,\n}", nil, somePlainWorkspaces)
+	assert.NotNil(t, err)
+}
+
+const zedSettings = `{
+  // zed allows comments too
+  "theme": "One Dark",
+  "ssh_connections": [
+    {"host": "my-box", "projects": [{"paths": ["~/code"]}]},
+    {"host": "old-ws-1234", "username": "brev", "projects": [{"paths": ["/home/brev/workspace"]}]}
+  ]
+}
+`
+
+func TestCreateNewZedConfig(t *testing.T) {
+	c := NewSSHConfigurerZed(nil)
+	config, err := c.CreateNewSSHConfig("settings.json", zedSettings, somePlainWorkspaces)
+	assert.Nil(t, err)
+	assertGolden(t, "zed_settings.json.golden", config)
+
+	again, err := c.CreateNewSSHConfig("settings.json", config, somePlainWorkspaces)
+	assert.Nil(t, err)
+	assert.Equal(t, config, again)
+
+	config, err = c.CreateNewSSHConfig("settings.json", config, nil)
+	assert.Nil(t, err)
+	assert.NotContains(t, config, `"brev"`)
+	assert.Contains(t, config, "my-box")
+}
+
+func TestCreateNewTrampConfig(t *testing.T) {
+	c := NewSSHConfigurerTramp(nil)
+	config, err := c.CreateNewSSHConfig("/my/brev/config", somePlainWorkspaces)
+	assert.Nil(t, err)
+	assertGolden(t, "brev-tramp.el.golden", config)
+
+	config, err = c.CreateNewSSHConfig(`C:\Users\a"b\.brev\ssh_config`, nil)
+	assert.Nil(t, err)
+	assert.Contains(t, config, `(tramp-parse-sconfig "C:\\Users\\a\"b\\.brev\\ssh_config")`)
+}
+
+func TestNewConfigTarget(t *testing.T) {
+	assert.Equal(t, []string{"tramp", "vscode", "zed"}, GetConfigTargetNames())
+	c, err := NewConfigTarget("zed", nil)
+	assert.Nil(t, err)
+	assert.IsType(t, &SSHConfigurerZed{}, c)
+	_, err = NewConfigTarget("notepad", nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "tramp, vscode, zed")
+	}
+}
@@ -1,6 +1,8 @@
 package ssh
 
 import (
+	"log"
+
 	"github.com/brevdev/brev-cli/pkg/autostartconf"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -11,6 +13,7 @@ import (
 type SSHConfigurerTaskStore interface {
 	ConfigUpdaterStore
 	SSHConfigurerV2Store
+	SSHConfigTargetStore
 	GetCurrentUser() (*entity.User, error)
 }
 
@@ -76,5 +79,12 @@ func GetSSHConfigs(store SSHConfigurerTaskStore) ([]Config, error) {
 	if err == nil && jetbrainsConfigurer != nil {
 		configs = append(configs, jetbrainsConfigurer)
 	}
+	// same for the targets turned on in the personal settings
+	targets, err := GetEnabledConfigTargets(store)
+	if err != nil {
+		log.Print(err)
+	} else {
+		configs = append(configs, targets...)
+	}
 	return configs, nil
 }
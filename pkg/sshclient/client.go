@@ -0,0 +1,335 @@
+// Package sshclient connects to workspaces over ssh without the ssh binary
+package sshclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	brevssh "github.com/brevdev/brev-cli/pkg/ssh"
+)
+
+const (
+	workspaceUser    = "brev"
+	handshakeTimeout = 30 * time.Second
+	defaultTerm      = "xterm-256color"
+	// signalExitCode is what ssh exits with when the command was killed by a
+	// signal
+	signalExitCode = 255
+)
+
+type AuthStore interface {
+	GetPrivateKey() (string, error)
+	GetPreviousPrivateKey() (string, error)
+	GetSSHCertificate(workspaceGroupID string) (string, error)
+	GetSSHCertificateKey() (string, error)
+}
+
+type KnownHostsStore interface {
+	GetKnownHosts() (string, error)
+	AppendKnownHost(line string) error
+}
+
+type ClientStore interface {
+	AuthStore
+	KnownHostsStore
+}
+
+// GetSigners is the keys the ssh config offers for a workspace in the same
+// order, the certificate of its group, the brev key and the key from before
+// the last rotation
+func GetSigners(store AuthStore, workspace entity.Workspace) ([]ssh.Signer, error) {
+	signers := []ssh.Signer{}
+	certificateSigner, err := getCertificateSigner(store, workspace)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if certificateSigner != nil {
+		signers = append(signers, certificateSigner)
+	}
+
+	privateKey, err := store.GetPrivateKey()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if privateKey == "" {
+		return nil, fmt.Errorf("no brev private key found, run brev refresh")
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "unable to parse private key")
+	}
+	signers = append(signers, signer)
+
+	previousPrivateKey, err := store.GetPreviousPrivateKey()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if previousPrivateKey != "" {
+		previousSigner, err := ssh.ParsePrivateKey([]byte(previousPrivateKey))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err, "unable to parse previous private key")
+		}
+		signers = append(signers, previousSigner)
+	}
+	return signers, nil
+}
+
+func getCertificateSigner(store AuthStore, workspace entity.Workspace) (ssh.Signer, error) {
+	if workspace.WorkspaceGroupID == "" {
+		return nil, nil
+	}
+	certificate, err := store.GetSSHCertificate(workspace.WorkspaceGroupID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	cert, ok := brevssh.GetValidSSHCertificate(certificate, time.Now())
+	if !ok {
+		return nil, nil
+	}
+	key, err := store.GetSSHCertificateKey()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if key == "" {
+		return nil, nil
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(key))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err, "unable to parse certificate key")
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		// a certificate for an older key, the renewal will replace it
+		return nil, nil //nolint:nilerr // fall back to the brev key
+	}
+	return certSigner, nil
+}
+
+// KnownHostsCallback trusts the key a workspace presents the first time and
+// errors when it changes after
+func KnownHostsCallback(store KnownHostsStore) ssh.HostKeyCallback {
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		knownHosts, err := store.GetKnownHosts()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		known, err := isKnownHost(knownHosts, hostname, key)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if known {
+			return nil
+		}
+		err = store.AppendKnownHost(knownhosts.Line([]string{hostname}, key))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+}
+
+// isKnownHost is whether key is the known key of hostname, it errors when
+// hostname has other keys
+func isKnownHost(knownHosts string, hostname string, key ssh.PublicKey) (bool, error) {
+	rest := []byte(knownHosts)
+	seen := false
+	for len(bytes.TrimSpace(rest)) > 0 {
+		_, hosts, knownKey, _, next, err := ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, breverrors.WrapAndTrace(err, "invalid ~/.brev/known_hosts")
+		}
+		rest = next
+		for _, h := range hosts {
+			if h != hostname || knownKey.Type() != key.Type() {
+				continue
+			}
+			if bytes.Equal(knownKey.Marshal(), key.Marshal()) {
+				return true, nil
+			}
+			seen = true
+		}
+	}
+	if seen {
+		return false, fmt.Errorf("the host key of %s changed, remove it from ~/.brev/known_hosts if the workspace was recreated", hostname)
+	}
+	return false, nil
+}
+
+// NewClient starts an ssh connection to a workspace over conn, host is the
+// name its host key is known by
+func NewClient(conn net.Conn, host string, signers []ssh.Signer, hostKeyCallback ssh.HostKeyCallback) (*ssh.Client, error) {
+	config := &ssh.ClientConfig{
+		User:            workspaceUser,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         handshakeTimeout,
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, host, config)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+type SessionOptions struct {
+	// Command is run by the shell of the workspace, an interactive login
+	// shell is started when it is empty
+	Command string
+	// TTY allocates a pseudo terminal sized like the local one
+	TTY bool
+	// Env is sent as KEY=VALUE, ones the server does not accept are set on
+	// the command instead
+	Env []string
+	// ForwardEnv is sent as KEY=VALUE when the server accepts it, like the
+	// SendEnv of ssh
+	ForwardEnv []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run runs a session until it exits, a non zero exit is returned as a
+// breverrors.ExitCodeError
+func Run(client *ssh.Client, opts SessionOptions) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer session.Close() //nolint:errcheck // closed on exit already
+
+	for _, kv := range opts.ForwardEnv {
+		key, value := splitEnv(kv)
+		_ = session.Setenv(key, value)
+	}
+	rejected := []string{}
+	for _, kv := range opts.Env {
+		key, value := splitEnv(kv)
+		err := session.Setenv(key, value)
+		if err != nil {
+			rejected = append(rejected, kv)
+		}
+	}
+	command := withEnv(opts.Command, rejected)
+
+	session.Stdout = opts.Stdout
+	session.Stderr = opts.Stderr
+	// Wait would block on a stdin that is not closed, like a terminal, so
+	// stdin is copied outside the session
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	go func() {
+		_, _ = io.Copy(stdin, opts.Stdin)
+		_ = stdin.Close()
+	}()
+
+	if opts.TTY {
+		restore, err := startTTY(session)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		defer restore()
+	}
+
+	if command == "" {
+		err = session.Shell()
+	} else {
+		err = session.Start(command)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return exitError(session.Wait())
+}
+
+// startTTY makes the local terminal raw and keeps the size of the remote one
+// in sync with it
+func startTTY(session *ssh.Session) (func(), error) {
+	fd := int(os.Stdin.Fd())
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	termName := os.Getenv("TERM")
+	if termName == "" {
+		termName = defaultTerm
+	}
+	err = session.RequestPty(termName, height, width, ssh.TerminalModes{ssh.ECHO: 1})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	stop := make(chan struct{})
+	go watchWindowSize(int(os.Stdout.Fd()), func(width, height int) {
+		_ = session.WindowChange(height, width)
+	}, stop)
+	return func() {
+		close(stop)
+		_ = term.Restore(fd, state)
+	}, nil
+}
+
+func exitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *ssh.ExitError:
+		if e.Signal() != "" {
+			return breverrors.ExitCodeError{Code: signalExitCode}
+		}
+		return breverrors.ExitCodeError{Code: e.ExitStatus()}
+	case *ssh.ExitMissingError:
+		return fmt.Errorf("the command exited without an exit status, the connection may have dropped")
+	default:
+		return breverrors.WrapAndTrace(err)
+	}
+}
+
+func splitEnv(kv string) (string, string) {
+	parts := strings.SplitN(kv, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// withEnv sets env on the command with env(1), an interactive session gets a
+// login shell
+func withEnv(command string, env []string) string {
+	if len(env) == 0 {
+		return command
+	}
+	if command == "" {
+		command = `exec "${SHELL:-/bin/sh}" -l`
+	}
+	quoted := []string{}
+	for _, kv := range env {
+		quoted = append(quoted, shellQuote(kv))
+	}
+	return fmt.Sprintf("env %s sh -c %s", strings.Join(quoted, " "), shellQuote(command))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,213 @@
+package sshclient
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+func makeKey(t *testing.T) (string, ssh.Signer) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	signer, err := ssh.ParsePrivateKey([]byte(keyPEM))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return keyPEM, signer
+}
+
+type memStore struct {
+	privateKey         string
+	previousPrivateKey string
+	knownHosts         string
+}
+
+func (m *memStore) GetPrivateKey() (string, error)         { return m.privateKey, nil }
+func (m *memStore) GetPreviousPrivateKey() (string, error) { return m.previousPrivateKey, nil }
+func (m *memStore) GetSSHCertificate(string) (string, error) {
+	return "", nil
+}
+func (m *memStore) GetSSHCertificateKey() (string, error) { return "", nil }
+func (m *memStore) GetKnownHosts() (string, error)        { return m.knownHosts, nil }
+func (m *memStore) AppendKnownHost(line string) error {
+	m.knownHosts += line + "\n"
+	return nil
+}
+
+func TestGetSigners(t *testing.T) {
+	key, signer := makeKey(t)
+	previousKey, previousSigner := makeKey(t)
+	store := &memStore{privateKey: key, previousPrivateKey: previousKey}
+
+	signers, err := GetSigners(store, entity.Workspace{WorkspaceGroupID: "wg-1"})
+	assert.Nil(t, err)
+	if assert.Len(t, signers, 2) {
+		assert.Equal(t, signer.PublicKey().Marshal(), signers[0].PublicKey().Marshal())
+		assert.Equal(t, previousSigner.PublicKey().Marshal(), signers[1].PublicKey().Marshal())
+	}
+
+	_, err = GetSigners(&memStore{}, entity.Workspace{})
+	assert.NotNil(t, err)
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	_, hostKey := makeKey(t)
+	_, otherKey := makeKey(t)
+	store := &memStore{}
+	callback := KnownHostsCallback(store)
+
+	err := callback("ws-1", nil, hostKey.PublicKey())
+	assert.Nil(t, err)
+	assert.Contains(t, store.knownHosts, "ws-1 ssh-rsa ")
+
+	// trusted from now on
+	err = callback("ws-1", nil, hostKey.PublicKey())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(store.knownHosts, "\n"))
+
+	err = callback("ws-1", nil, otherKey.PublicKey())
+	assert.NotNil(t, err)
+
+	err = callback("ws-2", nil, otherKey.PublicKey())
+	assert.Nil(t, err)
+}
+
+// serveSSH accepts one connection, runs exec requests by echoing the
+// command and the env it got, then exits with the code in the command
+func serveSSH(t *testing.T, conn net.Conn, hostKey ssh.Signer, userKey ssh.PublicKey) {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), userKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if !assert.Nil(t, err) {
+			return
+		}
+		go func() {
+			env := []string{}
+			for req := range requests {
+				switch req.Type {
+				case "env":
+					var kv struct{ Key, Value string }
+					_ = ssh.Unmarshal(req.Payload, &kv)
+					// like the AcceptEnv of sshd
+					accepted := strings.HasPrefix(kv.Key, "LANG")
+					if accepted {
+						env = append(env, kv.Key+"="+kv.Value)
+					}
+					_ = req.Reply(accepted, nil)
+				case "exec":
+					var command struct{ Command string }
+					_ = ssh.Unmarshal(req.Payload, &command)
+					_ = req.Reply(true, nil)
+					sort.Strings(env)
+					fmt.Fprintf(channel, "%s|%s", command.Command, strings.Join(env, ","))
+					code := uint32(0)
+					if strings.Contains(command.Command, "exit 3") {
+						code = 3
+					}
+					status := make([]byte, 4)
+					binary.BigEndian.PutUint32(status, code)
+					_, _ = channel.SendRequest("exit-status", false, status)
+					_ = channel.Close()
+				default:
+					_ = req.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+func runOnTestServer(t *testing.T, opts SessionOptions) (string, error) {
+	_, hostKey := makeKey(t)
+	key, userKey := makeKey(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer listener.Close() //nolint:errcheck // test
+	go func() {
+		serverConn, err := listener.Accept()
+		if err == nil {
+			serveSSH(t, serverConn, hostKey, userKey.PublicKey())
+		}
+	}()
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	signers, err := GetSigners(&memStore{privateKey: key}, entity.Workspace{})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	client, err := NewClient(clientConn, "ws-1", signers, ssh.FixedHostKey(hostKey.PublicKey()))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer client.Close() //nolint:errcheck // test
+
+	out := &bytes.Buffer{}
+	opts.Stdin = &bytes.Buffer{}
+	opts.Stdout = out
+	opts.Stderr = &bytes.Buffer{}
+	err = Run(client, opts)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	out, err := runOnTestServer(t, SessionOptions{
+		Command:    "echo hi",
+		ForwardEnv: []string{"LANG=C.UTF-8", "LC_ALL=C"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "echo hi|LANG=C.UTF-8", out)
+}
+
+func TestRunExitCode(t *testing.T) {
+	out, err := runOnTestServer(t, SessionOptions{Command: "exit 3"})
+	assert.Equal(t, "exit 3|", out)
+	assert.Equal(t, breverrors.ExitCodeError{Code: 3}, err)
+}
+
+func TestRunSetsRejectedEnvOnTheCommand(t *testing.T) {
+	out, err := runOnTestServer(t, SessionOptions{
+		Command: "echo $TOKEN",
+		Env:     []string{"LANG=C", "TOKEN=it's"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `env 'TOKEN=it'\''s' sh -c 'echo $TOKEN'|LANG=C`, out)
+}
+
+func TestWithEnv(t *testing.T) {
+	assert.Equal(t, "ls", withEnv("ls", nil))
+	assert.Equal(t, `env 'A=1' sh -c 'exec "${SHELL:-/bin/sh}" -l'`, withEnv("", []string{"A=1"}))
+}
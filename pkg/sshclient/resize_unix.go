@@ -0,0 +1,31 @@
+//go:build !windows
+// +build !windows
+
+package sshclient
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// watchWindowSize calls onChange with the size of the terminal each time it
+// is resized until stop is closed
+func watchWindowSize(fd int, onChange func(width, height int), stop <-chan struct{}) {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-resized:
+			width, height, err := term.GetSize(fd)
+			if err == nil {
+				onChange(width, height)
+			}
+		}
+	}
+}
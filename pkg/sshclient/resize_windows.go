@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package sshclient
+
+import (
+	"time"
+
+	"golang.org/x/term"
+)
+
+const windowSizePollInterval = 500 * time.Millisecond
+
+// watchWindowSize calls onChange with the size of the terminal each time it
+// is resized until stop is closed, windows has no SIGWINCH so it is polled
+func watchWindowSize(fd int, onChange func(width, height int), stop <-chan struct{}) {
+	width, height, _ := term.GetSize(fd)
+	ticker := time.NewTicker(windowSizePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w, h, err := term.GetSize(fd)
+			if err != nil || (w == width && h == height) {
+				continue
+			}
+			width, height = w, h
+			onChange(width, height)
+		}
+	}
+}
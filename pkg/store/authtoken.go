@@ -1,20 +1,21 @@
 package store
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"path"
 
+	"github.com/brevdev/brev-cli/pkg/credentials"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
-	"github.com/brevdev/brev-cli/pkg/files"
-	"github.com/spf13/afero"
 )
 
 // TODO 1 test cov
 
 const (
-	brevCredentialsFile = "credentials.json"
+	brevCredentialsFile = credentials.PlaintextFileName
 	brevDirectory       = ".brev"
 )
 
@@ -26,15 +27,27 @@ func (f FileStore) SaveAuthTokens(token entity.AuthTokens) error {
 	if token.AccessToken == "" {
 		return fmt.Errorf("access token is empty")
 	}
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, legacy, err := f.getCredentialBackend()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = files.OverwriteJSON(f.fs, *brevCredentialsFile, token)
+	data, err := json.MarshalIndent(token, "", " ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = credentials.SetReplacing(backend, legacy, data)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	brevDir, err := f.getCredentialsDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// a login with BREV_CREDENTIAL_STORE set moves the credentials for good
+	err = credentials.Record(f.fs, brevDir, backend)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-
 	return nil
 }
 
@@ -56,21 +69,20 @@ func (f FileStore) GetAuthTokens() (*entity.AuthTokens, error) {
 		}
 	}
 
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, legacy, err := f.getCredentialBackend()
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
-
-	exists, err := afero.Exists(f.fs, *brevCredentialsFile)
+	data, err := credentials.GetMigrating(backend, legacy)
+	if errors.Is(err, credentials.ErrNotFound) {
+		return nil, &breverrors.CredentialsFileNotFound{}
+	}
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
-	if !exists {
-		return nil, &breverrors.CredentialsFileNotFound{}
-	}
 
 	var token entity.AuthTokens
-	err = files.ReadJSON(f.fs, *brevCredentialsFile, &token)
+	err = json.Unmarshal(data, &token)
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
 	}
@@ -96,22 +108,39 @@ func getServiceTokenFilePath() string {
 }
 
 func (f FileStore) DeleteAuthTokens() error {
-	brevCredentialsFile, err := f.getBrevCredentialsFile()
+	backend, legacy, err := f.getCredentialBackend()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = files.DeleteFile(f.fs, *brevCredentialsFile)
+	err = backend.Delete()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = legacy.Delete()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func (f FileStore) getBrevCredentialsFile() (*string, error) {
+// getCredentialBackend is where the tokens are kept, and the plaintext file
+// older versions kept them in
+func (f FileStore) getCredentialBackend() (credentials.Backend, credentials.PlaintextFile, error) {
+	brevDir, err := f.getCredentialsDir()
+	if err != nil {
+		return nil, credentials.PlaintextFile{}, breverrors.WrapAndTrace(err)
+	}
+	backend, err := credentials.Choose(f.fs, brevDir)
+	if err != nil {
+		return nil, credentials.PlaintextFile{}, breverrors.WrapAndTrace(err)
+	}
+	return backend, credentials.NewPlaintextFile(f.fs, path.Join(brevDir, brevCredentialsFile)), nil
+}
+
+func (f FileStore) getCredentialsDir() (string, error) {
 	home, err := f.UserHomeDir()
 	if err != nil {
-		return nil, breverrors.WrapAndTrace(err)
+		return "", breverrors.WrapAndTrace(err)
 	}
-	brevCredentialsFile := path.Join(home, brevDirectory, brevCredentialsFile)
-	return &brevCredentialsFile, nil
+	return path.Join(home, brevDirectory), nil
 }
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+var (
+	workspaceClassOrgPathPattern = "api/organizations/%s/workspace_classes"
+	workspaceClassOrgPath        = fmt.Sprintf(workspaceClassOrgPathPattern, fmt.Sprintf("{%s}", orgIDParamName))
+)
+
+func (s AuthHTTPStore) GetWorkspaceClasses(organizationID string) ([]entity.WorkspaceClass, error) {
+	var result []entity.WorkspaceClass
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(orgIDParamName, organizationID).
+		SetResult(&result).
+		Get(workspaceClassOrgPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return result, nil
+}
+
+type UpdateWorkspaceOptions struct {
+	WorkspaceClassID string `json:"workspaceClassId,omitempty"`
+}
+
+func (s AuthHTTPStore) UpdateWorkspace(workspaceID string, options *UpdateWorkspaceOptions) (*entity.Workspace, error) {
+	if options == nil {
+		return nil, fmt.Errorf("options can not be nil")
+	}
+
+	var result entity.Workspace
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(workspaceIDParamName, workspaceID).
+		SetBody(options).
+		SetResult(&result).
+		Put(workspacePath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return &result, nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWorkspaceClasses(t *testing.T) {
+	fs := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(fs.authHTTPClient.restyClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	orgID := "o1"
+	expected := []entity.WorkspaceClass{
+		{ID: "2x8", CPU: 2, MemoryGB: 8, PricePerHour: 0.1},
+		{ID: "4x16", CPU: 4, MemoryGB: 16, PricePerHour: 0.2},
+	}
+	res, err := httpmock.NewJsonResponder(200, expected)
+	if !assert.Nil(t, err) {
+		return
+	}
+	url := fmt.Sprintf("%s/%s", fs.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(workspaceClassOrgPathPattern, orgID))
+	httpmock.RegisterResponder("GET", url, res)
+
+	classes, err := fs.GetWorkspaceClasses(orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, expected, classes)
+}
+
+func TestUpdateWorkspace(t *testing.T) {
+	fs := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(fs.authHTTPClient.restyClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	workspaceID := "abcd1234"
+	url := fmt.Sprintf("%s/%s", fs.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(workspacePathPattern, workspaceID))
+	httpmock.RegisterResponder("PUT", url, func(req *http.Request) (*http.Response, error) {
+		options := UpdateWorkspaceOptions{}
+		err := json.NewDecoder(req.Body).Decode(&options)
+		if err != nil {
+			return httpmock.NewStringResponse(400, ""), nil
+		}
+		return httpmock.NewJsonResponse(200, entity.Workspace{ID: workspaceID, WorkspaceClassID: options.WorkspaceClassID})
+	})
+
+	workspace, err := fs.UpdateWorkspace(workspaceID, &UpdateWorkspaceOptions{WorkspaceClassID: "4x16"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "4x16", workspace.WorkspaceClassID)
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -78,6 +80,22 @@ func (f FileStore) FileExists(filepath string) (bool, error) {
 	return fileExists, nil
 }
 
+func (f FileStore) GetFileMode(filepath string) (os.FileMode, error) {
+	info, err := f.fs.Stat(filepath)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	return info.Mode(), nil
+}
+
+func (f FileStore) SetFileMode(filepath string, mode os.FileMode) error {
+	err := f.fs.Chmod(filepath, mode)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func (f FileStore) GetDotGitConfigFile(path string) (string, error) {
 	dotGitConfigFile := filepath.Join(path, ".git", "config")
 
@@ -375,6 +393,63 @@ func (f FileStore) GetOSUser() string {
 	}
 }
 
+func (f FileStore) getSSHAllStatusPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetSSHAllStatusPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetSSHAllStatusAddress is where the running sshall serves the state of its
+// tunnels, it is empty when sshall is not running
+func (f FileStore) GetSSHAllStatusAddress() (string, error) {
+	path, err := f.getSSHAllStatusPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (f FileStore) WriteSSHAllStatusAddress(address string) error {
+	path, err := f.getSSHAllStatusPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, path, []byte(address), 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) DeleteSSHAllStatusAddress() error {
+	path, err := f.getSSHAllStatusPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func (f FileStore) GetServerSockFile() string {
 	return "/var/run/brev.sock"
 }
@@ -394,3 +469,102 @@ func (f FileStore) GetFileAsString(path string) (string, error) {
 	}
 	return res, nil
 }
+
+// GetForwardProfiles reads ~/.brev/forwards.yaml, it is empty when the user
+// has not made one
+func (f FileStore) GetForwardProfiles() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetForwardProfilesPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+func (f FileStore) getApplyStatePath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetApplyStatePath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetApplyState reads ~/.brev/apply_state.json, what brev apply last applied
+// from this machine, it is empty before the first apply
+func (f FileStore) GetApplyState() (string, error) {
+	path, err := f.getApplyStatePath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+func (f FileStore) SaveApplyState(state string) error {
+	path, err := f.getApplyStatePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, path, []byte(state), 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+const applyStateKeyBytes = 32
+
+// GetApplyStateKey is the random key of this machine the secret hashes in
+// ~/.brev/apply_state.json are made with, so a copy of that file can not be
+// used to guess the secret values. It is created on first use.
+func (f FileStore) GetApplyStateKey() ([]byte, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetApplyStateKeyPath(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if err == nil {
+		key, decodeErr := hex.DecodeString(strings.TrimSpace(string(data)))
+		if decodeErr == nil && len(key) == applyStateKeyBytes {
+			return key, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	// a missing or damaged key only means secrets are sent again once
+	key := make([]byte, applyStateKeyBytes)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, path, []byte(hex.EncodeToString(key)+"\n"), 0o600)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return key, nil
+}
@@ -157,3 +157,60 @@ func TestFileStore_GetOrCreateFile(t *testing.T) {
 		})
 	}
 }
+
+func TestSSHAllStatusAddress(t *testing.T) {
+	fs := MakeMockFileStore()
+	address, err := fs.GetSSHAllStatusAddress()
+	assert.Nil(t, err)
+	assert.Empty(t, address)
+
+	err = fs.WriteSSHAllStatusAddress("127.0.0.1:43211")
+	assert.Nil(t, err)
+	address, err = fs.GetSSHAllStatusAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:43211", address)
+
+	err = fs.DeleteSSHAllStatusAddress()
+	assert.Nil(t, err)
+	err = fs.DeleteSSHAllStatusAddress()
+	assert.Nil(t, err)
+	address, err = fs.GetSSHAllStatusAddress()
+	assert.Nil(t, err)
+	assert.Empty(t, address)
+}
+
+func TestGetApplyStateKey(t *testing.T) {
+	fs := MakeMockFileStore()
+	key, err := fs.GetApplyStateKey()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, key, applyStateKeyBytes)
+	again, err := fs.GetApplyStateKey()
+	assert.Nil(t, err)
+	assert.Equal(t, key, again)
+
+	home, err := fs.UserHomeDir()
+	if !assert.Nil(t, err) {
+		return
+	}
+	info, err := fs.fs.Stat(home + "/.brev/apply_state.key")
+	if assert.Nil(t, err) {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	fs := MakeMockFileStore()
+	knownHosts, err := fs.GetKnownHosts()
+	assert.Nil(t, err)
+	assert.Empty(t, knownHosts)
+
+	err = fs.AppendKnownHost("ws-1 ssh-ed25519 AAAA1")
+	assert.Nil(t, err)
+	err = fs.AppendKnownHost("ws-2 ssh-ed25519 AAAA2")
+	assert.Nil(t, err)
+	knownHosts, err = fs.GetKnownHosts()
+	assert.Nil(t, err)
+	assert.Equal(t, "ws-1 ssh-ed25519 AAAA1\nws-2 ssh-ed25519 AAAA2\n", knownHosts)
+}
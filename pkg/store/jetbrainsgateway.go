@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
-	"github.com/spf13/afero"
 )
 
 const (
@@ -82,7 +81,7 @@ func (f FileStore) WriteJetBrainsConfig(config string) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = afero.WriteFile(f.fs, path, []byte(config), 0o644)
+	err = f.writeLockedConfig(path, config)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -0,0 +1,253 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/afero"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+func (f FileStore) GetPreviousPrivateKeyPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetPreviousSSHPrivateKeyPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+func (f FileStore) getSSHKeyRotationPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetSSHKeyRotationPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetPrivateKey is empty when there is no private key yet
+func (f FileStore) GetPrivateKey() (string, error) {
+	path, err := f.GetPrivateKeyPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+// GetPreviousPrivateKey is empty when no rotation is pending
+func (f FileStore) GetPreviousPrivateKey() (string, error) {
+	path, err := f.GetPreviousPrivateKeyPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+// GetSSHKeyRotation is nil when the key was never rotated
+func (f FileStore) GetSSHKeyRotation() (*entity.SSHKeyRotation, error) {
+	path, err := f.getSSHKeyRotationPath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	var rotation entity.SSHKeyRotation
+	err = json.Unmarshal(data, &rotation)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &rotation, nil
+}
+
+func (f FileStore) writeSSHKeyRotation(rotation *entity.SSHKeyRotation) error {
+	path, err := f.getSSHKeyRotationPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if rotation == nil {
+		err = f.fs.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(rotation, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, path, data, 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) writePrivateKey(pem string) error {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.WriteSSHPrivateKey(f.fs, pem, home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) deletePreviousPrivateKey() error {
+	path, err := f.GetPreviousPrivateKeyPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// RotatePrivateKey replaces the private key with pem, rotation is the public
+// key of pem and the workspaces that still need the previous key. The current
+// key is kept as the previous key while any workspace does.
+func (f FileStore) RotatePrivateKey(pem string, rotation *entity.SSHKeyRotation) (err error) {
+	unlock, err := f.lockSSHConfig()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
+
+	if rotation == nil || len(rotation.PendingWorkspaceIDs) == 0 {
+		err = f.writeSSHKeyRotation(nil)
+		if err == nil {
+			err = f.deletePreviousPrivateKey()
+		}
+		if err == nil {
+			err = f.writePrivateKey(pem)
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+
+	current, err := f.GetPrivateKey()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if current != "" {
+		previousPath, err := f.GetPreviousPrivateKeyPath()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = files.WriteFileAtomic(f.fs, previousPath, []byte(current), 0o600)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	err = f.writeSSHKeyRotation(rotation)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.writePrivateKey(pem)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// PruneSSHKeyRotation drops the workspaces that are no longer running from
+// the last rotation, they get the new key from the server when they start
+// again. The rotation and the previous key are removed once no workspace
+// needs the previous key.
+func (f FileStore) PruneSSHKeyRotation(runningWorkspaceIDs []string) (err error) {
+	rotation, err := f.GetSSHKeyRotation()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if rotation == nil {
+		return nil
+	}
+
+	unlock, err := f.lockSSHConfig()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
+
+	// read again now that nothing else can rotate the key
+	rotation, err = f.GetSSHKeyRotation()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if rotation == nil {
+		return nil
+	}
+	running := map[string]bool{}
+	for _, id := range runningWorkspaceIDs {
+		running[id] = true
+	}
+	pending := []string{}
+	for _, id := range rotation.PendingWorkspaceIDs {
+		if running[id] {
+			pending = append(pending, id)
+		}
+	}
+	if len(pending) == 0 {
+		err = f.deletePreviousPrivateKey()
+		if err == nil {
+			err = f.writeSSHKeyRotation(nil)
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(pending) == len(rotation.PendingWorkspaceIDs) {
+		return nil
+	}
+	rotation.PendingWorkspaceIDs = pending
+	err = f.writeSSHKeyRotation(rotation)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestPruneSSHKeyRotation(t *testing.T) {
+	fs := MakeMockFileStore()
+	err := fs.WritePrivateKey("old key")
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = fs.RotatePrivateKey("new key", &entity.SSHKeyRotation{PendingWorkspaceIDs: []string{"a", "b"}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	previousPath, err := fs.GetPreviousPrivateKeyPath()
+	assert.Nil(t, err)
+
+	err = fs.PruneSSHKeyRotation([]string{"a", "c"})
+	assert.Nil(t, err)
+	rotation, err := fs.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, rotation.PendingWorkspaceIDs)
+	exists, err := afero.Exists(fs.fs, previousPath)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	err = fs.PruneSSHKeyRotation([]string{"c"})
+	assert.Nil(t, err)
+	rotation, err = fs.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Nil(t, rotation)
+	exists, err = afero.Exists(fs.fs, previousPath)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	// the key the server distributes is written again
+	err = fs.WritePrivateKey("server key")
+	assert.Nil(t, err)
+	key, err := fs.GetPrivateKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "server key", key)
+}
+
+func TestRotatePrivateKeyWithoutPendingWorkspaces(t *testing.T) {
+	fs := MakeMockFileStore()
+	assert.Nil(t, fs.WritePrivateKey("old key"))
+
+	err := fs.RotatePrivateKey("new key", &entity.SSHKeyRotation{})
+	assert.Nil(t, err)
+	rotation, err := fs.GetSSHKeyRotation()
+	assert.Nil(t, err)
+	assert.Nil(t, rotation)
+	previous, err := fs.GetPreviousPrivateKey()
+	assert.Nil(t, err)
+	assert.Empty(t, previous)
+	key, err := fs.GetPrivateKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "new key", key)
+}
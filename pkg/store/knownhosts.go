@@ -0,0 +1,60 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+func (f FileStore) getKnownHostsPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetKnownHostsPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetKnownHosts is the host keys of the workspaces brev shell connected to
+func (f FileStore) GetKnownHosts() (string, error) {
+	path, err := f.getKnownHostsPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+func (f FileStore) AppendKnownHost(line string) error {
+	path, err := f.getKnownHostsPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	file, err := f.fs.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer file.Close() //nolint:errcheck,gosec // write is checked
+	_, err = file.WriteString(line + "\n")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
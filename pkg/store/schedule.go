@@ -0,0 +1,69 @@
+package store
+
+import (
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+// Schedule starts and stops a workspace on cron expressions, it is only
+// stored locally and acted on by the run-tasks daemon
+type Schedule struct {
+	WorkspaceID   string `json:"workspaceId"`
+	WorkspaceName string `json:"workspaceName"`
+	Start         string `json:"start,omitempty"`
+	Stop          string `json:"stop,omitempty"`
+	Timezone      string `json:"timezone,omitempty"`
+}
+
+type schedulesFile struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+func (f FileStore) GetSchedules() ([]Schedule, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetSchedulesPath(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	exists, err := afero.Exists(f.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return []Schedule{}, nil
+	}
+	var sf schedulesFile
+	err = files.ReadJSON(f.fs, path, &sf)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if sf.Schedules == nil {
+		return []Schedule{}, nil
+	}
+	return sf.Schedules, nil
+}
+
+func (f FileStore) SetSchedules(schedules []Schedule) error {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.BuildBrevHome(f.fs, home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetSchedulesPath(home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.OverwriteJSON(f.fs, path, schedulesFile{Schedules: schedules})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
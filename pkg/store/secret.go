@@ -1,6 +1,10 @@
 package store
 
-import breverrors "github.com/brevdev/brev-cli/pkg/errors"
+import (
+	"fmt"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
 
 type CreateSecretRequest struct {
 	Name          string        `json:"name"`
@@ -10,6 +14,12 @@ type CreateSecretRequest struct {
 	Dest          SecretReqDest `json:"dest"`
 }
 
+// Secret is a secret as the api returns it, the value is never returned
+type Secret struct {
+	ID string `json:"id"`
+	CreateSecretRequest
+}
+
 type HierarchyType string
 
 const (
@@ -67,3 +77,81 @@ func (s AuthHTTPStore) CreateSecret(req CreateSecretRequest) (*CreateSecretReque
 
 	return &result, nil
 }
+
+const secretIDParamName = "secretID"
+
+var (
+	secretPathPattern = fmt.Sprintf("%s/%%s", secretsPath)
+	secretPath        = fmt.Sprintf(secretPathPattern, fmt.Sprintf("{%s}", secretIDParamName))
+)
+
+// GetSecrets is the secrets of an org or a user
+func (s AuthHTTPStore) GetSecrets(hierarchyType HierarchyType, hierarchyID string) ([]Secret, error) {
+	var result []Secret
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetQueryParams(map[string]string{
+			"hierarchyType": string(hierarchyType),
+			"hierarchyID":   hierarchyID,
+		}).
+		SetResult(&result).
+		Get(secretsPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return result, nil
+}
+
+func (s AuthHTTPStore) UpdateSecret(secretID string, req CreateSecretRequest) (*Secret, error) {
+	var result Secret
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(secretIDParamName, secretID).
+		SetResult(&result).
+		SetBody(req).
+		Put(secretPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return &result, nil
+}
+
+func (s AuthHTTPStore) DeleteSecret(secretID string) error {
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(secretIDParamName, secretID).
+		Delete(secretPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return NewHTTPResponseError(res)
+	}
+	return nil
+}
+
+var (
+	workspaceSecretsConfigPathPattern = fmt.Sprintf("%s/secrets/config", workspacePathPattern)
+	workspaceSecretsConfigPath        = fmt.Sprintf(workspaceSecretsConfigPathPattern, fmt.Sprintf("{%s}", workspaceIDParamName))
+)
+
+// GetWorkspaceSecretsConfig is the vault agent config of a workspace, the
+// one workspacemanagerv2 mounts at /etc/config/config.hcl
+func (s AuthHTTPStore) GetWorkspaceSecretsConfig(workspaceID string) (string, error) {
+	res, err := s.authHTTPClient.restyClient.R().
+		SetPathParam(workspaceIDParamName, workspaceID).
+		Get(workspaceSecretsConfigPath)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return "", NewHTTPResponseError(res)
+	}
+	return string(res.Body()), nil
+}
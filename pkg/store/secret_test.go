@@ -0,0 +1,95 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSecrets(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	expected := []Secret{{
+		ID: "s-1",
+		CreateSecretRequest: CreateSecretRequest{
+			Name:          "SERVER_URL",
+			HierarchyType: Org,
+			HierarchyID:   "o-1",
+			Dest:          SecretReqDest{Type: EnvVariable, Config: DestConfig{Name: "SERVER_URL"}},
+		},
+	}}
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, secretsPath)
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "org", req.URL.Query().Get("hierarchyType"))
+		assert.Equal(t, "o-1", req.URL.Query().Get("hierarchyID"))
+		return httpmock.NewJsonResponse(200, expected)
+	})
+
+	secrets, err := s.GetSecrets(Org, "o-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, secrets)
+}
+
+func TestUpdateSecret(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	req := CreateSecretRequest{
+		Name:          "SERVER_URL",
+		HierarchyType: User,
+		HierarchyID:   "u-1",
+		Src:           SecretReqSrc{Type: KeyValue, Config: SrcConfig{Value: "https://brev.sh"}},
+		Dest:          SecretReqDest{Type: EnvVariable, Config: DestConfig{Name: "SERVER_URL"}},
+	}
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(secretPathPattern, "s-1"))
+	httpmock.RegisterResponder("PUT", url, func(r *http.Request) (*http.Response, error) {
+		var body CreateSecretRequest
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			return nil, err
+		}
+		assert.Equal(t, req, body)
+		return httpmock.NewJsonResponse(200, Secret{ID: "s-1", CreateSecretRequest: body})
+	})
+
+	secret, err := s.UpdateSecret("s-1", req)
+	assert.Nil(t, err)
+	if assert.NotNil(t, secret) {
+		assert.Equal(t, "s-1", secret.ID)
+	}
+}
+
+func TestDeleteSecret(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(secretPathPattern, "s-1"))
+	httpmock.RegisterResponder("DELETE", url, httpmock.NewStringResponder(200, "{}"))
+	err := s.DeleteSecret("s-1")
+	assert.Nil(t, err)
+
+	httpmock.RegisterResponder("DELETE", url, httpmock.NewStringResponder(404, "{}"))
+	err = s.DeleteSecret("s-1")
+	assert.NotNil(t, err)
+}
+
+func TestGetWorkspaceSecretsConfig(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	expected := `template {
+  destination = "/home/brev/.brev/secrets.env"
+}
+`
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(workspaceSecretsConfigPathPattern, "ws-1"))
+	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(200, expected))
+
+	config, err := s.GetWorkspaceSecretsConfig("ws-1")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, config)
+}
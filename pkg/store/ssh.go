@@ -4,13 +4,14 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
-	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"strings"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/spf13/afero"
 )
 
@@ -64,83 +65,192 @@ func (f FileStore) GetBrevSSHConfigPath() (string, error) {
 	return path, nil
 }
 
-func (f FileStore) WriteUserSSHConfig(config string) error {
+func (f FileStore) GetSSHOverridesPath() (string, error) {
 	home, err := f.UserHomeDir()
 	if err != nil {
-		return breverrors.WrapAndTrace(err)
+		return "", breverrors.WrapAndTrace(err)
 	}
-	csp, err := files.GetUserSSHConfigPath(home)
+	path, err := files.GetSSHOverridesPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetSSHOverrides reads ~/.brev/ssh_overrides.yaml, it is empty when the
+// user has not made one
+func (f FileStore) GetSSHOverrides() (string, error) {
+	path, err := f.GetSSHOverridesPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+// lockSSHConfig is held by every writer of generated ssh config, the daemon
+// rewrites these files every few seconds and would otherwise race with a
+// brev refresh run at the same time
+func (f FileStore) lockSSHConfig() (func() error, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	lockPath, err := files.GetSSHConfigLockPath(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	unlock, err := files.LockFile(f.fs, lockPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return unlock, nil
+}
+
+// writeLockedConfig atomically writes a generated config, unchanged content
+// is not rewritten
+func (f FileStore) writeLockedConfig(path string, config string) (err error) {
+	unlock, err := f.lockSSHConfig()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = afero.WriteFile(f.fs, csp, []byte(config), 0o644)
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
+	_, err = files.WriteFileAtomic(f.fs, path, []byte(config), 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func (f FileStore) WriteBrevSSHConfig(config string) error {
+// WriteUserSSHConfig copies ~/.ssh/config to ~/.brev/config.bak before
+// replacing it, so the config from before the last change can be recovered
+func (f FileStore) WriteUserSSHConfig(config string) (err error) {
 	home, err := f.UserHomeDir()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	bsp, err := files.GetBrevSSHConfigPath(home)
+	csp, err := files.GetUserSSHConfigPath(home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	unlock, err := f.lockSSHConfig()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = f.fs.MkdirAll(filepath.Dir(bsp), 0o755)
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
+
+	unchanged, err := files.HasContent(f.fs, csp, []byte(config))
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	err = afero.WriteFile(f.fs, bsp, []byte(config), 0o644)
+	if unchanged {
+		return nil
+	}
+	exists, err := afero.Exists(f.fs, csp)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if exists {
+		err = f.backupUserSSHConfig(home, csp)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	_, err = files.WriteFileAtomic(f.fs, csp, []byte(config), 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func (f FileStore) CreateNewSSHConfigBackup() error {
+func (f FileStore) WriteBrevSSHConfig(config string) error {
 	home, err := f.UserHomeDir()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	backupFilePath, err := files.GetNewBackupSSHConfigFilePath(home)
+	bsp, err := files.GetBrevSSHConfigPath(home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.writeLockedConfig(bsp, config)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	return nil
+}
 
+func (f FileStore) CreateNewSSHConfigBackup() error {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 	csp, err := files.GetUserSSHConfigPath(home)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	file, err := f.fs.Open(csp)
+	err = f.backupUserSSHConfig(home, csp)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	return nil
+}
 
-	buf := new(strings.Builder)
-	_, err = io.Copy(buf, file)
+// backupUserSSHConfig replaces the previous backup rather than adding one per
+// write
+func (f FileStore) backupUserSSHConfig(home string, csp string) error {
+	backupFilePath, err := files.GetSSHConfigBackupPath(home)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-
-	err = afero.WriteFile(f.fs, *backupFilePath, []byte(buf.String()), 0o644)
+	data, err := afero.ReadFile(f.fs, csp)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.fs.MkdirAll(filepath.Dir(*backupFilePath), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, *backupFilePath, data, 0o644)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	fmt.Printf("Editing ssh config, backed up at path %s\n", *backupFilePath)
+	terminal.New().Eprintf("Editing ssh config, backed up at path %s\n", *backupFilePath)
 	return nil
 }
 
-func (f FileStore) WritePrivateKey(pem string) error {
-	home, err := f.UserHomeDir()
+// WritePrivateKey atomically replaces the private key
+func (f FileStore) WritePrivateKey(pem string) (err error) {
+	unlock, err := f.lockSSHConfig()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
 
-	err = files.WriteSSHPrivateKey(f.fs, pem, home)
+	err = f.writePrivateKey(pem)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -206,3 +206,39 @@ func TestVerifyPrivateKey(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteUserSSHConfigSkipsUnchanged(t *testing.T) {
+	fs := MakeMockFileStore()
+	err := fs.WriteUserSSHConfig("Include ~/.brev/ssh_config\n")
+	if !assert.Nil(t, err) {
+		return
+	}
+	home, err := fs.UserHomeDir()
+	if !assert.Nil(t, err) {
+		return
+	}
+	backupPath, err := files.GetSSHConfigBackupPath(home)
+	if !assert.Nil(t, err) {
+		return
+	}
+	// the first write has nothing to back up, an unchanged write does not back up either
+	err = fs.WriteUserSSHConfig("Include ~/.brev/ssh_config\n")
+	if !assert.Nil(t, err) {
+		return
+	}
+	exists, err := afero.Exists(fs.fs, *backupPath)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	err = fs.WriteUserSSHConfig("Include ~/.brev/ssh_config\nHost a\n")
+	assert.Nil(t, err)
+	err = fs.WriteUserSSHConfig("Include ~/.brev/ssh_config\nHost b\n")
+	assert.Nil(t, err)
+	// one backup, of the config from before the last change
+	backups, err := afero.Glob(fs.fs, home+"/.brev/config.bak*")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{*backupPath}, backups)
+	data, err := afero.ReadFile(fs.fs, *backupPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "Include ~/.brev/ssh_config\nHost a\n", string(data))
+}
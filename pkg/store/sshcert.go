@@ -0,0 +1,237 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+const (
+	sshCertificateKeyFileName = "brev-session.pem"
+	// sshCertificatesCurrentFileName names the directory in certs holding
+	// the key and certificates in use, each renewal writes a new directory
+	// and then swaps this file so the key and its certificates change together
+	sshCertificatesCurrentFileName = "current"
+)
+
+func (f FileStore) getSSHCertificatesRootPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetSSHCertificatesPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// getCurrentSSHCertificates is the directory of the current key and
+// certificates, empty when none were written yet
+func (f FileStore) getCurrentSSHCertificates(root string) (string, error) {
+	data, err := afero.ReadFile(f.fs, filepath.Join(root, sshCertificatesCurrentFileName))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	current := strings.TrimSpace(string(data))
+	if !isSafeFileName(current) {
+		return "", fmt.Errorf("invalid %s file in %s", sshCertificatesCurrentFileName, root)
+	}
+	return current, nil
+}
+
+func (f FileStore) getSSHCertificatesPath() (string, error) {
+	root, err := f.getSSHCertificatesRootPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	current, err := f.getCurrentSSHCertificates(root)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(root, current), nil
+}
+
+// GetSSHCertificateKeyPath is the private key the ssh certificates are issued for
+func (f FileStore) GetSSHCertificateKeyPath() (string, error) {
+	dir, err := f.getSSHCertificatesPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(dir, sshCertificateKeyFileName), nil
+}
+
+// GetSSHCertificateKey is empty when no certificate was issued yet
+func (f FileStore) GetSSHCertificateKey() (string, error) {
+	path, err := f.GetSSHCertificateKeyPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+// GetSSHCertificatePath is the certificate signed by the CA of a workspace group
+func (f FileStore) GetSSHCertificatePath(workspaceGroupID string) (string, error) {
+	if !isSafeFileName(workspaceGroupID) {
+		return "", fmt.Errorf("invalid workspace group id %q", workspaceGroupID)
+	}
+	dir, err := f.getSSHCertificatesPath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(dir, workspaceGroupID+"-cert.pub"), nil
+}
+
+// isSafeFileName is whether name can be used as a file name in certs
+func isSafeFileName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".")
+}
+
+// GetSSHCertificate is empty when no certificate was issued for the group yet
+func (f FileStore) GetSSHCertificate(workspaceGroupID string) (string, error) {
+	path, err := f.GetSSHCertificatePath(workspaceGroupID)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+// WriteSSHCertificates replaces the certificate key and the certificates of
+// each workspace group issued for it. They are written to a new directory
+// that then becomes the current one, so readers never see a key with the
+// certificates of another. The previous directory is kept for ssh configs
+// that still point to it.
+func (f FileStore) WriteSSHCertificates(privateKey string, certificates map[string]string) (err error) {
+	unlock, err := f.lockSSHConfig()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = breverrors.WrapAndTrace(unlockErr)
+		}
+	}()
+
+	root, err := f.getSSHCertificatesRootPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	previous, err := f.getCurrentSSHCertificates(root)
+	if err != nil {
+		// a broken current file is replaced below
+		previous = ""
+	}
+	next := uuid.New().String()
+	err = f.fs.MkdirAll(filepath.Join(root, next), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = files.WriteFileAtomic(f.fs, filepath.Join(root, next, sshCertificateKeyFileName), []byte(privateKey), 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for workspaceGroupID, certificate := range certificates {
+		if !isSafeFileName(workspaceGroupID) {
+			return fmt.Errorf("invalid workspace group id %q", workspaceGroupID)
+		}
+		_, err = files.WriteFileAtomic(f.fs, filepath.Join(root, next, workspaceGroupID+"-cert.pub"), []byte(certificate), 0o644)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	_, err = files.WriteFileAtomic(f.fs, filepath.Join(root, sshCertificatesCurrentFileName), []byte(next+"\n"), 0o644)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	err = f.removeOldSSHCertificates(root, next, previous)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// removeOldSSHCertificates removes every directory but the current and the
+// previous one, and the files from before there were directories once they
+// are no longer the previous certificates
+func (f FileStore) removeOldSSHCertificates(root string, current string, previous string) error {
+	entries, err := afero.ReadDir(f.fs, root)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if name == sshCertificatesCurrentFileName || name == current || name == previous || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if !e.IsDir() && previous == "" {
+			continue
+		}
+		err = f.fs.RemoveAll(filepath.Join(root, name))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+var sshCertificatesPath = fmt.Sprintf("%s/ssh_certificates", userKeysPath)
+
+type SignSSHCertificateRequest struct {
+	WorkspaceGroupID string `json:"workspaceGroupId"`
+	PublicKey        string `json:"publicKey"`
+	ValidForSeconds  int    `json:"validForSeconds"`
+}
+
+type SignSSHCertificateResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+// SignSSHCertificate has the CA of a workspace group issue a user certificate
+// for publicKey, returned in authorized_keys format. It is only called for
+// groups that publish an ssh CA in the user keys.
+func (s AuthHTTPStore) SignSSHCertificate(workspaceGroupID string, publicKey string, validFor time.Duration) (string, error) {
+	var result SignSSHCertificateResponse
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(SignSSHCertificateRequest{
+			WorkspaceGroupID: workspaceGroupID,
+			PublicKey:        publicKey,
+			ValidForSeconds:  int(validFor.Seconds()),
+		}).
+		SetResult(&result).
+		Post(sshCertificatesPath)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return "", NewHTTPResponseError(res)
+	}
+	return result.Certificate, nil
+}
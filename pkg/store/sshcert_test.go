@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignSSHCertificate(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, sshCertificatesPath)
+	httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+		var body SignSSHCertificateRequest
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			return nil, err
+		}
+		assert.Equal(t, SignSSHCertificateRequest{WorkspaceGroupID: "wg-1", PublicKey: "ssh-rsa AAAA", ValidForSeconds: 3600}, body)
+		return httpmock.NewJsonResponse(200, SignSSHCertificateResponse{Certificate: "ssh-rsa-cert-v01@openssh.com AAAA"})
+	})
+
+	cert, err := s.SignSSHCertificate("wg-1", "ssh-rsa AAAA", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-rsa-cert-v01@openssh.com AAAA", cert)
+}
+
+func TestWriteSSHCertificates(t *testing.T) {
+	fs := MakeMockFileStore()
+	cert, err := fs.GetSSHCertificate("wg-1")
+	assert.Nil(t, err)
+	assert.Empty(t, cert)
+
+	err = fs.WriteSSHCertificates("key", map[string]string{"wg-1": "cert1", "wg-2": "cert2"})
+	assert.Nil(t, err)
+
+	cert, err = fs.GetSSHCertificate("wg-2")
+	assert.Nil(t, err)
+	assert.Equal(t, "cert2", cert)
+	keyPath, err := fs.GetSSHCertificateKeyPath()
+	assert.Nil(t, err)
+	info, err := fs.fs.Stat(keyPath)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "-rw-------", info.Mode().String())
+	}
+	key, err := afero.ReadFile(fs.fs, keyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "key", string(key))
+
+	_, err = fs.GetSSHCertificatePath("../wg-1")
+	assert.NotNil(t, err)
+
+	// a renewal switches the key and the certificates together and keeps the
+	// previous ones for ssh configs that still point to them
+	err = fs.WriteSSHCertificates("key2", map[string]string{"wg-1": "cert3"})
+	assert.Nil(t, err)
+	key, err = afero.ReadFile(fs.fs, keyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "key", string(key))
+	newKeyPath, err := fs.GetSSHCertificateKeyPath()
+	assert.Nil(t, err)
+	assert.NotEqual(t, keyPath, newKeyPath)
+	cert, err = fs.GetSSHCertificate("wg-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "cert3", cert)
+	cert, err = fs.GetSSHCertificate("wg-2")
+	assert.Nil(t, err)
+	assert.Empty(t, cert)
+
+	err = fs.WriteSSHCertificates("key3", map[string]string{"wg-1": "cert4"})
+	assert.Nil(t, err)
+	_, err = fs.fs.Stat(keyPath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = fs.fs.Stat(newKeyPath)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/afero"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+// PersonalSettings are local preferences kept in ~/.brev/personal_settings.json
+type PersonalSettings struct {
+	// SSHConfigTargets are the extra tools, beyond ~/.ssh/config and
+	// JetBrains Gateway, that workspace hosts are written to
+	SSHConfigTargets []string `json:"sshConfigTargets,omitempty"`
+}
+
+func (f FileStore) GetPersonalSettings() (*PersonalSettings, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetPersonalSettingsCachePath(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	exists, err := afero.Exists(f.fs, path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return &PersonalSettings{}, nil
+	}
+	var settings PersonalSettings
+	err = files.ReadJSON(f.fs, path, &settings)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &settings, nil
+}
+
+func (f FileStore) SetPersonalSettings(settings *PersonalSettings) error {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.BuildBrevHome(f.fs, home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetPersonalSettingsCachePath(home)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = files.OverwriteJSON(f.fs, path, settings)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (f FileStore) GetVSCodeSettingsPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return filepath.Join(home, ".config", "Code", "User", "settings.json"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Code", "User", "settings.json"), nil
+	default:
+		return "", fmt.Errorf("%s not supported at this time", runtime.GOOS)
+	}
+}
+
+func (f FileStore) GetVSCodeHostsPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetVSCodeHostsPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+func (f FileStore) GetZedSettingsPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(home, ".config", "zed", "settings.json"), nil
+}
+
+func (f FileStore) GetTrampConfigPath() (string, error) {
+	home, err := f.UserHomeDir()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	path, err := files.GetTrampConfigPath(home)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return path, nil
+}
+
+// GetSSHTargetConfig reads the config of an ssh config target, a file that
+// does not exist yet is empty
+func (f FileStore) GetSSHTargetConfig(path string) (string, error) {
+	data, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return string(data), nil
+}
+
+func (f FileStore) WriteSSHTargetConfig(path string, config string) error {
+	err := f.writeLockedConfig(path, config)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// GetSSHConfigTargets are the extra ssh config targets enabled in the
+// personal settings
+func (f FileStore) GetSSHConfigTargets() ([]string, error) {
+	settings, err := f.GetPersonalSettings()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return settings.SSHConfigTargets, nil
+}
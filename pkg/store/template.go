@@ -0,0 +1,30 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+var (
+	workspaceTemplateOrgPathPattern = "api/organizations/%s/workspace_templates"
+	workspaceTemplateOrgPath        = fmt.Sprintf(workspaceTemplateOrgPathPattern, fmt.Sprintf("{%s}", orgIDParamName))
+)
+
+// GetWorkspaceTemplates returns the public templates and the ones private to the org
+func (s AuthHTTPStore) GetWorkspaceTemplates(organizationID string) ([]entity.WorkspaceTemplate, error) {
+	var result []entity.WorkspaceTemplate
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetPathParam(orgIDParamName, organizationID).
+		SetResult(&result).
+		Get(workspaceTemplateOrgPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWorkspaceTemplates(t *testing.T) {
+	fs := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(fs.authHTTPClient.restyClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	orgID := "o1"
+	expected := []entity.WorkspaceTemplate{{
+		ID:          "4nbb4lg2s",
+		Name:        "ubuntu",
+		RegistryURI: "registry.hub.docker.com",
+		Image:       "brevdev/ubuntu-proxy:0.3.2",
+		Public:      true,
+		Port:        22778,
+	}}
+	res, err := httpmock.NewJsonResponder(200, expected)
+	if !assert.Nil(t, err) {
+		return
+	}
+	url := fmt.Sprintf("%s/%s", fs.authHTTPClient.restyClient.BaseURL, fmt.Sprintf(workspaceTemplateOrgPathPattern, orgID))
+	httpmock.RegisterResponder("GET", url, res)
+
+	templates, err := fs.GetWorkspaceTemplates(orgID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, expected, templates)
+}
@@ -63,6 +63,25 @@ func (s AuthHTTPStore) GetCurrentUserKeys() (*entity.UserKeys, error) {
 	return &result, nil
 }
 
+// UpdateCurrentUserKeys replaces the keypair of the user, workspaces get the
+// new public key when they start and GetCurrentUserKeys serves the new
+// private key
+func (s AuthHTTPStore) UpdateCurrentUserKeys(keys *entity.UpdateUserKeys) (*entity.UserKeys, error) {
+	var result entity.UserKeys
+	res, err := s.authHTTPClient.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(keys).
+		SetResult(&result).
+		Put(userKeysPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+	return &result, nil
+}
+
 var usersPath = "api/users"
 
 type UserCreateResponse struct {
@@ -72,6 +72,19 @@ func TestGetCurrentUserKeys(t *testing.T) {
 	}
 }
 
+func TestUpdateCurrentUserKeys(t *testing.T) {
+	s := MakeMockAuthHTTPStore()
+	httpmock.ActivateNonDefault(s.authHTTPClient.restyClient.GetClient())
+
+	expected := &entity.UserKeys{PrivateKey: "priv", PublicKey: "pub"}
+	url := fmt.Sprintf("%s/%s", s.authHTTPClient.restyClient.BaseURL, userKeysPath)
+	httpmock.RegisterResponder("PUT", url, httpmock.NewJsonResponderOrPanic(200, expected))
+
+	keys, err := s.UpdateCurrentUserKeys(&entity.UpdateUserKeys{PrivateKey: "priv", PublicKey: "pub"})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, keys)
+}
+
 func TestCreateUser(t *testing.T) {
 	s := MakeMockNoHTTPStore()
 	httpmock.ActivateNonDefault(s.noAuthHTTPClient.restyClient.GetClient())
@@ -82,6 +82,11 @@ func (c *CreateWorkspacesOptions) WithStartupScript(startupScript string) *Creat
 	return c
 }
 
+func (c *CreateWorkspacesOptions) WithWorkspaceTemplateID(workspaceTemplateID string) *CreateWorkspacesOptions {
+	c.WorkspaceTemplateID = workspaceTemplateID
+	return c
+}
+
 func (c *CreateWorkspacesOptions) WithWorkspaceClassID(workspaceClassID string) *CreateWorkspacesOptions {
 	c.WorkspaceClassID = workspaceClassID
 	return c
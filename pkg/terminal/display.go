@@ -92,6 +92,8 @@ type PromptContent struct {
 	Label      string
 	Default    string
 	AllowEmpty bool
+	// Mask replaces the characters typed, for secrets
+	Mask rune
 }
 
 func PromptGetInput(pc PromptContent) string {
@@ -118,6 +120,7 @@ func PromptGetInput(pc PromptContent) string {
 		Validate:  validate,
 		Default:   pc.Default,
 		AllowEdit: true,
+		Mask:      pc.Mask,
 	}
 
 	result, err := prompt.Run()
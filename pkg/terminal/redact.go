@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	redacted = "[redacted]"
+	// minRedactLength keeps short values from mangling unrelated output
+	minRedactLength = 4
+)
+
+// redactor is shared by every Terminal, errors are printed by a Terminal
+// of their own
+type redactor struct {
+	mu     sync.RWMutex
+	values []string
+}
+
+var defaultRedactor = &redactor{}
+
+func (r *redactor) add(value string) {
+	candidates := []string{value}
+	// a line of a multi line value, like a key, can end up in output alone
+	if strings.Contains(value, "\n") {
+		candidates = append(candidates, strings.Split(value, "\n")...)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range candidates {
+		c = strings.TrimSpace(c)
+		if len(c) < minRedactLength || contains(r.values, c) {
+			continue
+		}
+		r.values = append(r.values, c)
+	}
+	// longest first so a value containing another is replaced whole
+	sort.SliceStable(r.values, func(i, j int) bool {
+		return len(r.values[i]) > len(r.values[j])
+	})
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	return s
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSecret makes every Terminal print [redacted] in place of value
+func (t *Terminal) AddSecret(values ...string) {
+	for _, v := range values {
+		t.getRedactor().add(v)
+	}
+}
+
+// Redact replaces the secrets added with AddSecret in s
+func (t *Terminal) Redact(s string) string {
+	return t.getRedactor().redact(s)
+}
+
+// RedactError is err, or an error with a redacted message when its message
+// holds a secret
+func (t *Terminal) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	redactedMessage := t.Redact(message)
+	if redactedMessage == message {
+		return err
+	}
+	return errors.New(redactedMessage)
+}
+
+func (t *Terminal) getRedactor() *redactor {
+	if t.redactor == nil {
+		return defaultRedactor
+	}
+	return t.redactor
+}
@@ -0,0 +1,24 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	out := &bytes.Buffer{}
+	term := &Terminal{out: out, verbose: out, err: out, redactor: &redactor{}}
+	term.AddSecret("hunter2", "abc", "-----BEGIN KEY-----\nc2VjcmV0a2V5\n-----END KEY-----")
+
+	term.Vprintf("password is %s\n", "hunter2")
+	term.Eprint("got abc and c2VjcmV0a2V5")
+	assert.Equal(t, "password is [redacted]\ngot abc and [redacted]\n", out.String())
+
+	err := errors.New("bad hunter2")
+	assert.Equal(t, "bad [redacted]", term.RedactError(err).Error())
+	other := errors.New("nothing secret")
+	assert.Equal(t, other, term.RedactError(other))
+}
@@ -35,6 +35,8 @@ type Terminal struct {
 	Bar ProgressBar
 
 	Spinner *spinner.Spinner
+
+	redactor *redactor
 }
 
 func New() (t *Terminal) {
@@ -47,6 +49,8 @@ func New() (t *Terminal) {
 		Red:     color.New(color.FgRed).SprintfFunc(),
 		Blue:    color.New(color.FgBlue).SprintfFunc(),
 		White:   color.New(color.FgWhite, color.Bold).SprintfFunc(),
+
+		redactor: defaultRedactor,
 	}
 }
 
@@ -59,27 +63,27 @@ func (t *Terminal) SetVerbose(verbose bool) {
 }
 
 func (t *Terminal) Print(a string) {
-	fmt.Fprintln(t.out, a)
+	fmt.Fprintln(t.out, t.Redact(a))
 }
 
 func (t *Terminal) Printf(format string, a ...interface{}) {
-	fmt.Fprintf(t.out, format, a...)
+	fmt.Fprint(t.out, t.Redact(fmt.Sprintf(format, a...)))
 }
 
 func (t *Terminal) Vprint(a string) {
-	fmt.Fprintln(t.verbose, a)
+	fmt.Fprintln(t.verbose, t.Redact(a))
 }
 
 func (t *Terminal) Vprintf(format string, a ...interface{}) {
-	fmt.Fprintf(t.verbose, format, a...)
+	fmt.Fprint(t.verbose, t.Redact(fmt.Sprintf(format, a...)))
 }
 
 func (t *Terminal) Eprint(a string) {
-	fmt.Fprintln(t.err, a)
+	fmt.Fprintln(t.err, t.Redact(a))
 }
 
 func (t *Terminal) Eprintf(format string, a ...interface{}) {
-	fmt.Fprintf(t.err, format, a...)
+	fmt.Fprint(t.err, t.Redact(fmt.Sprintf(format, a...)))
 }
 
 func (t *Terminal) Errprint(err error, a string) {
@@ -0,0 +1,488 @@
+package workspacemanagerv2
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const DefaultVolumesBasePath = "/tmp/brev/volumes" // TODO proper path that will be saved
+
+// SnapshotVolume is a volume backed by a local directory, its contents are
+// what Snapshot saves and Restore puts back
+type SnapshotVolume interface {
+	Volume
+	GetLocalPath() string
+}
+
+type LocalVolume struct {
+	LocalPath   string
+	MountToPath string
+}
+
+var _ SnapshotVolume = LocalVolume{}
+
+// NewProjectVolume is the volume holding /home/brev/workspace, only the
+// workspace id is needed to find it on disk
+func NewProjectVolume(workspaceID string) LocalVolume {
+	return LocalVolume{
+		LocalPath:   filepath.Join(DefaultVolumesBasePath, workspaceID, "home/brev/workspace"),
+		MountToPath: "/home/brev/workspace",
+	}
+}
+
+func (l LocalVolume) GetIdentifier() string {
+	return l.LocalPath
+}
+
+func (l LocalVolume) GetMountToPath() string {
+	return l.MountToPath
+}
+
+func (l LocalVolume) GetLocalPath() string {
+	return l.LocalPath
+}
+
+func (l LocalVolume) Setup(_ context.Context) error {
+	err := os.MkdirAll(l.LocalPath, os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Teardown keeps the data so a Rebuild doesn't lose the project
+func (l LocalVolume) Teardown(_ context.Context) error {
+	return nil
+}
+
+// HasLocalVolumes is true when this machine holds the workspace's project volume
+func HasLocalVolumes(workspaceID string) bool {
+	_, err := os.Stat(NewProjectVolume(workspaceID).GetLocalPath())
+	return err == nil
+}
+
+type VolumeSnapshot struct {
+	MountToPath string `json:"mountToPath"`
+	Digest      string `json:"digest"`
+	Size        int64  `json:"size"`
+}
+
+type Snapshot struct {
+	Name        string           `json:"name"`
+	WorkspaceID string           `json:"workspaceId"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	Volumes     []VolumeSnapshot `json:"volumes"`
+}
+
+func (s Snapshot) Size() int64 {
+	var size int64
+	for _, v := range s.Volumes {
+		size += v.Size
+	}
+	return size
+}
+
+var snapshotNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+func ValidateSnapshotName(name string) error {
+	if !snapshotNameRegex.MatchString(name) {
+		return breverrors.NewValidationError(fmt.Sprintf("invalid snapshot name %q, use letters, numbers, '.', '_' and '-'", name))
+	}
+	return nil
+}
+
+// SnapshotStore keeps volume tarballs content addressed under blobs/sha256
+// so unchanged volumes are only stored once, snapshots are small json
+// manifests under snapshots/<workspace id>/<name>.json
+type SnapshotStore struct {
+	Root string
+}
+
+func NewSnapshotStore(root string) *SnapshotStore {
+	return &SnapshotStore{Root: root}
+}
+
+func (s SnapshotStore) blobPath(digest string) string {
+	return filepath.Join(s.Root, "blobs", "sha256", digest)
+}
+
+func (s SnapshotStore) snapshotPath(workspaceID string, name string) string {
+	return filepath.Join(s.Root, "snapshots", workspaceID, name+".json")
+}
+
+// PutDirectory tars and gzips dir into the blob store and returns its digest
+func (s SnapshotStore) PutDirectory(dir string) (string, int64, error) {
+	blobDir := filepath.Join(s.Root, "blobs", "sha256")
+	err := os.MkdirAll(blobDir, 0o700)
+	if err != nil {
+		return "", 0, breverrors.WrapAndTrace(err)
+	}
+	tmp, err := ioutil.TempFile(blobDir, ".tmp-")
+	if err != nil {
+		return "", 0, breverrors.WrapAndTrace(err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // already renamed on success
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	err = writeTarGz(io.MultiWriter(tmp, hash, counter), dir)
+	if err != nil {
+		_ = tmp.Close()
+		return "", 0, breverrors.WrapAndTrace(err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return "", 0, breverrors.WrapAndTrace(err)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if _, err := os.Stat(s.blobPath(digest)); err == nil {
+		return digest, counter.n, nil
+	}
+	err = os.Rename(tmp.Name(), s.blobPath(digest))
+	if err != nil {
+		return "", 0, breverrors.WrapAndTrace(err)
+	}
+	return digest, counter.n, nil
+}
+
+// ExtractTo replaces the contents of dir with the blob. It extracts into a
+// sibling directory first so dir is left untouched if the blob is bad.
+func (s SnapshotStore) ExtractTo(digest string, dir string) error {
+	f, err := os.Open(s.blobPath(digest))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+
+	dir = filepath.Clean(dir)
+	parent := filepath.Dir(dir)
+	err = os.MkdirAll(parent, os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp, err := ioutil.TempDir(parent, "."+filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer os.RemoveAll(tmp) //nolint:errcheck // already renamed on success
+	mode := os.FileMode(0o755)
+	if info, statErr := os.Stat(dir); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	err = os.Chmod(tmp, mode)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = extractTarGz(f, tmp)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = replaceDir(tmp, dir)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// replaceDir moves src to dst, putting the old dst back if the move fails
+func replaceDir(src string, dst string) error {
+	old := src + ".old"
+	_, err := os.Lstat(dst)
+	switch {
+	case os.IsNotExist(err):
+		old = ""
+	case err != nil:
+		return breverrors.WrapAndTrace(err)
+	default:
+		err = os.Rename(dst, old)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	err = os.Rename(src, dst)
+	if err != nil {
+		if old != "" {
+			_ = os.Rename(old, dst)
+		}
+		return breverrors.WrapAndTrace(err)
+	}
+	if old != "" {
+		err = os.RemoveAll(old)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+func (s SnapshotStore) SaveSnapshot(snapshot Snapshot) error {
+	path := s.snapshotPath(snapshot.WorkspaceID, snapshot.Name)
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", " ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = ioutil.WriteFile(path, data, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s SnapshotStore) GetSnapshot(workspaceID string, name string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(s.snapshotPath(workspaceID, name))
+	if os.IsNotExist(err) {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no snapshot named %s", name))
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	snapshot := Snapshot{}
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots returns the newest first, an empty workspaceID lists all workspaces
+func (s SnapshotStore) ListSnapshots(workspaceID string) ([]Snapshot, error) {
+	pattern := filepath.Join(s.Root, "snapshots", "*", "*.json")
+	if workspaceID != "" {
+		pattern = filepath.Join(s.Root, "snapshots", workspaceID, "*.json")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	snapshots := []Snapshot{}
+	for _, p := range paths {
+		snapshot, err := s.GetSnapshot(filepath.Base(filepath.Dir(p)), strings.TrimSuffix(filepath.Base(p), ".json"))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Prune deletes all but the newest keep snapshots of a workspace and then
+// removes blobs no snapshot refers to
+func (s SnapshotStore) Prune(workspaceID string, keep int) ([]Snapshot, error) {
+	snapshots, err := s.ListSnapshots(workspaceID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	pruned := []Snapshot{}
+	for i, snapshot := range snapshots {
+		if i < keep {
+			continue
+		}
+		err = os.Remove(s.snapshotPath(snapshot.WorkspaceID, snapshot.Name))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		pruned = append(pruned, snapshot)
+	}
+	_, err = s.GarbageCollect()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return pruned, nil
+}
+
+// GarbageCollect removes blobs that are not referenced by any snapshot
+func (s SnapshotStore) GarbageCollect() (int, error) {
+	snapshots, err := s.ListSnapshots("")
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	referenced := map[string]bool{}
+	for _, snapshot := range snapshots {
+		for _, v := range snapshot.Volumes {
+			referenced[v.Digest] = true
+		}
+	}
+	blobs, err := filepath.Glob(filepath.Join(s.Root, "blobs", "sha256", "*"))
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	removed := 0
+	for _, b := range blobs {
+		// skip referenced blobs and temp files of snapshots in progress
+		if referenced[filepath.Base(b)] || strings.HasPrefix(filepath.Base(b), ".") {
+			continue
+		}
+		err = os.Remove(b)
+		if err != nil {
+			return removed, breverrors.WrapAndTrace(err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// writeTarGz leaves the gzip header empty so identical trees hash the same
+func writeTarGz(w io.Writer, dir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if rel == "." {
+			return nil
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		header.Name = filepath.ToSlash(rel)
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path) //nolint:gosec // walking a directory we own
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		defer f.Close() //nolint:errcheck // read only
+		_, err = io.Copy(tw, f)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = tw.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = gw.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func extractTarGz(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name)) //nolint:gosec // checked below
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in snapshot: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.FileMode(header.Mode))
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, target)
+		case tar.TypeLink:
+			err = writeHardlink(dir, target, header.Linkname)
+		case tar.TypeReg:
+			err = writeFile(target, tr, os.FileMode(header.Mode))
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeLink {
+			_ = os.Chtimes(target, header.ModTime, header.ModTime)
+		}
+	}
+}
+
+// writeHardlink links target to linkname, which is relative to the archive root
+func writeHardlink(dir string, target string, linkname string) error {
+	source := filepath.Join(dir, filepath.FromSlash(linkname)) //nolint:gosec // checked below
+	if !strings.HasPrefix(source, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid link in snapshot: %s", linkname)
+	}
+	err := os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Link(source, target)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode) //nolint:gosec // path checked by caller
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = io.Copy(f, r) //nolint:gosec // snapshots are created by us
+	if err != nil {
+		_ = f.Close()
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package workspacemanagerv2
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockContainerManager struct {
+	status  ContainerStatus
+	stopped int
+	started int
+}
+
+func (m *mockContainerManager) GetContainer(_ context.Context, containerID string) (*Container, error) {
+	return &Container{ID: containerID, Status: m.status}, nil
+}
+
+func (m *mockContainerManager) StopContainer(_ context.Context, _ string) error {
+	m.stopped++
+	m.status = ContainerStopped
+	return nil
+}
+
+func (m *mockContainerManager) DeleteContainer(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockContainerManager) CreateContainer(_ context.Context, _ CreateContainerOptions, _ string) (string, error) {
+	return "", nil
+}
+
+func (m *mockContainerManager) StartContainer(_ context.Context, _ string) error {
+	m.started++
+	m.status = ContainerRunning
+	return nil
+}
+
+func (m *mockContainerManager) DeleteVolume(_ context.Context, _ string) error {
+	return nil
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(path, []byte(content), 0o600)
+	assert.Nil(t, err)
+}
+
+func Test_SnapshotStoreDedupesIdenticalVolumes(t *testing.T) {
+	root := t.TempDir()
+	s := NewSnapshotStore(filepath.Join(root, "snapshots"))
+	dir := filepath.Join(root, "vol")
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main")
+	writeTestFile(t, filepath.Join(dir, "sub", "README.md"), "hi")
+
+	digest1, size, err := s.PutDirectory(dir)
+	assert.Nil(t, err)
+	assert.Greater(t, size, int64(0))
+	digest2, _, err := s.PutDirectory(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, digest1, digest2)
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main // changed")
+	digest3, _, err := s.PutDirectory(dir)
+	assert.Nil(t, err)
+	assert.NotEqual(t, digest1, digest3)
+
+	out := filepath.Join(root, "out")
+	writeTestFile(t, filepath.Join(out, "stale"), "remove me")
+	err = s.ExtractTo(digest1, out)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(out, "main.go"))
+	assert.Nil(t, err)
+	assert.Equal(t, "package main", string(data))
+	data, err = ioutil.ReadFile(filepath.Join(out, "sub", "README.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", string(data))
+	_, err = os.Stat(filepath.Join(out, "stale"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_SnapshotStorePrune(t *testing.T) {
+	root := t.TempDir()
+	s := NewSnapshotStore(root)
+	dir := filepath.Join(t.TempDir(), "vol")
+	now := time.Now()
+	for i, name := range []string{"one", "two", "three"} {
+		writeTestFile(t, filepath.Join(dir, "file"), name)
+		digest, size, err := s.PutDirectory(dir)
+		assert.Nil(t, err)
+		err = s.SaveSnapshot(Snapshot{
+			Name:        name,
+			WorkspaceID: "abcd1234",
+			CreatedAt:   now.Add(time.Duration(i) * time.Minute),
+			Volumes:     []VolumeSnapshot{{MountToPath: "/home/brev/workspace", Digest: digest, Size: size}},
+		})
+		assert.Nil(t, err)
+	}
+
+	snapshots, err := s.ListSnapshots("abcd1234")
+	assert.Nil(t, err)
+	assert.Len(t, snapshots, 3)
+	assert.Equal(t, "three", snapshots[0].Name)
+
+	pruned, err := s.Prune("abcd1234", 1)
+	assert.Nil(t, err)
+	assert.Len(t, pruned, 2)
+
+	snapshots, err = s.ListSnapshots("")
+	assert.Nil(t, err)
+	if assert.Len(t, snapshots, 1) {
+		assert.Equal(t, "three", snapshots[0].Name)
+	}
+	blobs, err := filepath.Glob(filepath.Join(root, "blobs", "sha256", "*"))
+	assert.Nil(t, err)
+	assert.Len(t, blobs, 1)
+
+	_, err = s.GetSnapshot("abcd1234", "one")
+	assert.NotNil(t, err)
+}
+
+func Test_ExtractRejectsPathTraversal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("evil")
+	err := tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o600, Size: int64(len(content))})
+	assert.Nil(t, err)
+	_, err = tw.Write(content)
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+
+	dir := filepath.Join(t.TempDir(), "vol")
+	assert.Nil(t, os.MkdirAll(dir, os.ModePerm))
+	err = extractTarGz(buf, dir)
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_ExtractToKeepsDirOnError(t *testing.T) {
+	root := t.TempDir()
+	s := NewSnapshotStore(filepath.Join(root, "snapshots"))
+	writeTestFile(t, s.blobPath("bad"), "not a tarball")
+
+	out := filepath.Join(root, "out")
+	writeTestFile(t, filepath.Join(out, "keep"), "still here")
+	err := s.ExtractTo("bad", out)
+	assert.NotNil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(out, "keep"))
+	assert.Nil(t, err)
+	assert.Equal(t, "still here", string(data))
+	entries, err := ioutil.ReadDir(root)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func Test_ExtractHardlink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	content := []byte("shared")
+	err := tw.WriteHeader(&tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0o600, Size: int64(len(content))})
+	assert.Nil(t, err)
+	_, err = tw.Write(content)
+	assert.Nil(t, err)
+	err = tw.WriteHeader(&tar.Header{Name: "sub/b", Typeflag: tar.TypeLink, Linkname: "a"})
+	assert.Nil(t, err)
+	err = tw.WriteHeader(&tar.Header{Name: "c", Typeflag: tar.TypeLink, Linkname: "../escape"})
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+
+	dir := filepath.Join(t.TempDir(), "vol")
+	assert.Nil(t, os.MkdirAll(dir, os.ModePerm))
+	err = extractTarGz(buf, dir)
+	assert.NotNil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "sub", "b"))
+	assert.Nil(t, err)
+	assert.Equal(t, "shared", string(data))
+	_, err = os.Stat(filepath.Join(dir, "c"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_ValidateSnapshotName(t *testing.T) {
+	assert.Nil(t, ValidateSnapshotName("pre-reset-20261018-120000"))
+	assert.NotNil(t, ValidateSnapshotName(""))
+	assert.NotNil(t, ValidateSnapshotName("../etc"))
+	assert.NotNil(t, ValidateSnapshotName("a/b"))
+}
+
+func Test_ContainerWorkspaceSnapshotRestore(t *testing.T) {
+	root := t.TempDir()
+	vol := LocalVolume{LocalPath: filepath.Join(root, "vol"), MountToPath: "/home/brev/workspace"}
+	writeTestFile(t, filepath.Join(vol.LocalPath, "notes.txt"), "before")
+
+	cm := &mockContainerManager{status: ContainerRunning}
+	cw := NewContainerWorkspace(cm, "abcd1234", "image", []Volume{vol}).
+		WithSnapshotStore(NewSnapshotStore(filepath.Join(root, "store")))
+
+	snapshot, err := cw.Snapshot(context.Background(), "first")
+	assert.Nil(t, err)
+	if !assert.NotNil(t, snapshot) {
+		return
+	}
+	assert.Len(t, snapshot.Volumes, 1)
+	assert.Equal(t, 0, cm.stopped)
+
+	writeTestFile(t, filepath.Join(vol.LocalPath, "notes.txt"), "after")
+	writeTestFile(t, filepath.Join(vol.LocalPath, "new.txt"), "new")
+
+	err = cw.Restore(context.Background(), "first")
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(vol.LocalPath, "notes.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "before", string(data))
+	_, err = os.Stat(filepath.Join(vol.LocalPath, "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, 1, cm.stopped)
+	assert.Equal(t, 1, cm.started)
+
+	err = cw.Restore(context.Background(), "missing")
+	assert.NotNil(t, err)
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -95,8 +97,7 @@ func (w WorkspaceManager) MakeContainerWorkspace(workspaceID string) (*Container
 		return nil, breverrors.WrapAndTrace(err)
 	}
 
-	basePath := "/tmp/brev/volumes" // TODO proper path that will be saved
-	workspaceVolumesPath := filepath.Join(basePath, workspace.ID)
+	workspaceVolumesPath := filepath.Join(DefaultVolumesBasePath, workspace.ID)
 
 	localMeta := filepath.Join(workspaceVolumesPath, "etc/meta")
 	metaVolumes := NewStaticFiles("/etc/meta", map[string]io.Reader{
@@ -111,11 +112,7 @@ func (w WorkspaceManager) MakeContainerWorkspace(workspaceID string) (*Container
 	}).
 		WithPathPrefix(secretsLocalConfig)
 
-	workspaceVolLocalPath := filepath.Join(workspaceVolumesPath, "home/brev/workspace")
-	workspaceVol := SimpleVolume{
-		Identifier:  workspaceVolLocalPath,
-		MountToPath: "/home/brev/workspace",
-	}
+	workspaceVol := NewProjectVolume(workspace.ID)
 
 	k8sTokenVol := SimpleVolume{
 		Identifier:  "/var/run/secrets",
@@ -182,12 +179,103 @@ type ContainerWorkspace struct {
 	Identifier       string
 	Image            string
 	Volumes          []Volume
+	SnapshotStore    *SnapshotStore
 }
 
 func NewContainerWorkspace(cm ContainerManager, identifier string, image string, volumes []Volume) *ContainerWorkspace {
 	return &ContainerWorkspace{ContainerManager: cm, Identifier: identifier, Image: image, Volumes: volumes}
 }
 
+func (c *ContainerWorkspace) WithSnapshotStore(snapshotStore *SnapshotStore) *ContainerWorkspace {
+	c.SnapshotStore = snapshotStore
+	return c
+}
+
+// Snapshot saves every SnapshotVolume of the workspace under name, the
+// container keeps running so the snapshot is only crash consistent
+func (c ContainerWorkspace) Snapshot(_ context.Context, name string) (*Snapshot, error) {
+	if c.SnapshotStore == nil {
+		return nil, fmt.Errorf("no snapshot store configured")
+	}
+	err := ValidateSnapshotName(name)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	snapshot := Snapshot{Name: name, WorkspaceID: c.Identifier, CreatedAt: time.Now()}
+	for _, v := range c.snapshotVolumes() {
+		digest, size, err := c.SnapshotStore.PutDirectory(v.GetLocalPath())
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		snapshot.Volumes = append(snapshot.Volumes, VolumeSnapshot{MountToPath: v.GetMountToPath(), Digest: digest, Size: size})
+	}
+	if len(snapshot.Volumes) == 0 {
+		return nil, fmt.Errorf("workspace %s has no volumes to snapshot", c.Identifier)
+	}
+	err = c.SnapshotStore.SaveSnapshot(snapshot)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &snapshot, nil
+}
+
+// Restore stops the container if it is running, puts the volumes back the
+// way they were when the snapshot was taken and starts it again
+func (c ContainerWorkspace) Restore(ctx context.Context, name string) error {
+	if c.SnapshotStore == nil {
+		return fmt.Errorf("no snapshot store configured")
+	}
+	snapshot, err := c.SnapshotStore.GetSnapshot(c.Identifier, name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	volumes := map[string]SnapshotVolume{}
+	for _, v := range c.snapshotVolumes() {
+		volumes[v.GetMountToPath()] = v
+	}
+
+	container, err := c.ContainerManager.GetContainer(ctx, c.Identifier)
+	if err != nil && !strings.Contains(err.Error(), "No such container") {
+		return breverrors.WrapAndTrace(err)
+	}
+	wasRunning := container != nil && container.Status == ContainerRunning
+	if wasRunning {
+		err = c.Stop(ctx)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	for _, vs := range snapshot.Volumes {
+		v, ok := volumes[vs.MountToPath]
+		if !ok {
+			return fmt.Errorf("snapshot %s has a volume for %s which the workspace does not mount", name, vs.MountToPath)
+		}
+		err = c.SnapshotStore.ExtractTo(vs.Digest, v.GetLocalPath())
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+
+	if wasRunning {
+		err = c.StartFromStopped(ctx)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+func (c ContainerWorkspace) snapshotVolumes() []SnapshotVolume {
+	volumes := []SnapshotVolume{}
+	for _, v := range c.Volumes {
+		if sv, ok := v.(SnapshotVolume); ok {
+			volumes = append(volumes, sv)
+		}
+	}
+	return volumes
+}
+
 func (c ContainerWorkspace) Start(ctx context.Context) error {
 	container, err := c.ContainerManager.GetContainer(ctx, c.Identifier)
 	if err != nil && !strings.Contains(err.Error(), "No such container") {
@@ -0,0 +1,73 @@
+//go:build !windows
+// +build !windows
+
+package workspacesecrets
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// chownInHome gives the user a regular file below their home. The user can
+// change anything there while this runs as root, so every directory is opened
+// relative to the one before it without following symlinks, and the file is
+// checked and chowned through the same descriptor instead of by path.
+func chownInHome(path string, u *user.User) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	rel, err := filepath.Rel(u.HomeDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		return fmt.Errorf("%s is not in %s", path, u.HomeDir)
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+
+	dirfd, err := unix.Open(u.HomeDir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return breverrors.WrapAndTrace(&os.PathError{Op: "open", Path: u.HomeDir, Err: err})
+	}
+	dir := u.HomeDir
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		fd, err := unix.Openat(dirfd, part, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		_ = unix.Close(dirfd)
+		if err != nil {
+			return breverrors.WrapAndTrace(&os.PathError{Op: "open", Path: dir, Err: err})
+		}
+		dirfd = fd
+	}
+	// non blocking so a fifo put in its place does not hang the task
+	fd, err := unix.Openat(dirfd, parts[len(parts)-1], unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	_ = unix.Close(dirfd)
+	if err != nil {
+		return breverrors.WrapAndTrace(&os.PathError{Op: "open", Path: path, Err: err})
+	}
+	defer unix.Close(fd) //nolint:errcheck // read only
+
+	var st unix.Stat_t
+	err = unix.Fstat(fd, &st)
+	if err != nil {
+		return breverrors.WrapAndTrace(&os.PathError{Op: "stat", Path: path, Err: err})
+	}
+	// a hard link could be to a file of root
+	if st.Mode&unix.S_IFMT != unix.S_IFREG || st.Nlink > 1 {
+		return fmt.Errorf("%s is not a regular file with one link", path)
+	}
+	err = unix.Fchown(fd, uid, gid)
+	if err != nil {
+		return breverrors.WrapAndTrace(&os.PathError{Op: "chown", Path: path, Err: err})
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+// +build !windows
+
+package workspacesecrets
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChownInHome(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(outside, "key"), []byte("root"), 0o600))
+	assert.Nil(t, os.Mkdir(filepath.Join(home, ".brev"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(home, ".brev", "secrets.env"), []byte("A=1"), 0o600))
+	// a directory swapped for a symlink after the path was checked
+	assert.Nil(t, os.Symlink(outside, filepath.Join(home, ".aws")))
+	assert.Nil(t, os.Symlink(filepath.Join(outside, "key"), filepath.Join(home, "key")))
+	u := &user.User{Uid: strconv.Itoa(os.Getuid()), Gid: strconv.Itoa(os.Getgid()), HomeDir: home}
+
+	assert.Nil(t, chownInHome(filepath.Join(home, ".brev", "secrets.env"), u))
+	assert.NotNil(t, chownInHome(filepath.Join(home, ".aws", "key"), u))
+	assert.NotNil(t, chownInHome(filepath.Join(home, "key"), u))
+	assert.NotNil(t, chownInHome(filepath.Join(outside, "key"), u))
+}
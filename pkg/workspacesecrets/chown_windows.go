@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package workspacesecrets
+
+import (
+	"os/user"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+)
+
+// chownInHome is only needed in linux workspaces
+func chownInHome(path string, u *user.User) error {
+	err := setupworkspace.ChownFilePathToUser(path, u)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package workspacesecrets
+
+import (
+	"os"
+	"syscall"
+)
+
+func linkCount(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink) //nolint:unconvert // uint16 on darwin
+	}
+	return 1
+}
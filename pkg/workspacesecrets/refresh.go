@@ -0,0 +1,397 @@
+// Package workspacesecrets keeps the secrets in a running workspace up to
+// date, so changes made with brev secret reach it without a reset. The vault
+// agent in the workspace renders the secrets from its config, this keeps that
+// config current and follows up on what the agent renders.
+package workspacesecrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+	"github.com/hashicorp/hcl"
+	"github.com/spf13/afero"
+)
+
+const (
+	// DefaultConfigPath is the vault agent config, the agent re-renders its
+	// templates when it changes
+	DefaultConfigPath = "/etc/config/config.hcl"
+	// GenerationFileName changes each time the rendered secrets do
+	GenerationFileName = "secrets.generation"
+	// HookFileName is a script shells can source to reload the env files
+	// before their next prompt after a refresh
+	HookFileName = "secrets-hook.sh"
+	// HooksDirName holds executables run as the workspace user after a
+	// refresh, with BREV_SECRETS_ENV_FILES set to the env files
+	HooksDirName = "hooks/secrets-refreshed"
+
+	envFilesVar = "BREV_SECRETS_ENV_FILES"
+	hookTimeout = 30 * time.Second
+)
+
+const shellHook = `# source from ~/.bashrc or ~/.zshrc to pick up refreshed brev secrets
+__brev_secrets_reload() {
+  [ -f "$HOME/.brev/secrets.generation" ] || return 0
+  __brev_secrets_generation="$(cat "$HOME/.brev/secrets.generation")"
+  if [ "$__brev_secrets_generation" != "${BREV_SECRETS_GENERATION:-}" ]; then
+    for __brev_secrets_file in %s; do
+      [ -r "$__brev_secrets_file" ] && . "$__brev_secrets_file"
+    done
+    export BREV_SECRETS_GENERATION="$__brev_secrets_generation"
+  fi
+}
+if [ -n "${ZSH_VERSION:-}" ]; then
+  precmd_functions+=(__brev_secrets_reload)
+else
+  PROMPT_COMMAND="__brev_secrets_reload${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+type RefreshStore interface {
+	GetCurrentWorkspaceID() (string, error)
+	GetWorkspaceSecretsConfig(workspaceID string) (string, error)
+}
+
+// RefreshTask runs as root in the workspace. It rewrites the vault agent
+// config when it changes, gives the workspace user the rendered secrets in
+// their home and tells shells and hooks when the secrets changed.
+type RefreshTask struct {
+	Store      RefreshStore
+	User       *user.User
+	ConfigPath string
+
+	fs        afero.Fs
+	chown     func(path string, user *user.User) error
+	chownFile func(path string, user *user.User) error
+	runHook   func(path string, env []string) error
+}
+
+var _ tasks.Task = RefreshTask{}
+
+func NewRefreshTask(store RefreshStore, user *user.User) RefreshTask {
+	t := RefreshTask{
+		Store:      store,
+		User:       user,
+		ConfigPath: DefaultConfigPath,
+		fs:         afero.NewOsFs(),
+		chown:      lchownToUser,
+		chownFile:  chownInHome,
+	}
+	t.runHook = t.runHookAsUser
+	return t
+}
+
+func (r RefreshTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+func (r RefreshTask) Configure() error {
+	return nil
+}
+
+func (r RefreshTask) Run() error {
+	workspaceID, err := r.Store.GetCurrentWorkspaceID()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if workspaceID == "" {
+		return nil
+	}
+	config, err := r.Store.GetWorkspaceSecretsConfig(workspaceID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = r.writeConfig(config)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// the agent renders in the background, what it rendered since the last
+	// run is picked up here and the rest on the next one
+	generation, changed, err := r.Apply(config)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if changed {
+		log.Printf("secrets refreshed, generation %s", generation)
+		r.runHooks(config)
+	}
+	return nil
+}
+
+// writeConfig replaces the agent config as root. On a read only mount the
+// config is a volume kept current by the cluster, so there is nothing to do.
+func (r RefreshTask) writeConfig(config string) error {
+	_, err := files.WriteFileAtomic(r.fs, r.ConfigPath, []byte(config), 0o600)
+	if errors.Is(err, syscall.EROFS) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// TemplateDestinations is the files the templates of a vault agent config
+// render to
+func TemplateDestinations(config string) ([]string, error) {
+	var parsed struct {
+		Template []struct {
+			Destination string `hcl:"destination"`
+		} `hcl:"template"`
+	}
+	err := hcl.Decode(&parsed, config)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	destinations := []string{}
+	for _, t := range parsed.Template {
+		if t.Destination != "" {
+			destinations = append(destinations, filepath.Clean(t.Destination))
+		}
+	}
+	sort.Strings(destinations)
+	return destinations, nil
+}
+
+// Apply gives the user the rendered secrets in their home and is whether they
+// changed since the last time, the generation file is only rewritten then so
+// shells reload once per change
+func (r RefreshTask) Apply(config string) (string, bool, error) {
+	destinations, err := TemplateDestinations(config)
+	if err != nil {
+		return "", false, breverrors.WrapAndTrace(err)
+	}
+
+	sum := sha256.New()
+	for _, path := range destinations {
+		contents, err := afero.ReadFile(r.fs, path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", false, breverrors.WrapAndTrace(err)
+		}
+		_, _ = fmt.Fprintf(sum, "%s\x00%s\x00", path, contents)
+		err = r.giveToUser(path)
+		if err != nil {
+			return "", false, breverrors.WrapAndTrace(err, fmt.Sprintf("secret file %s", path))
+		}
+	}
+
+	dotBrev := r.dotBrevPath()
+	hook := fmt.Sprintf(shellHook, shellWords(envFiles(destinations)))
+	_, err = r.writeUserFile(filepath.Join(dotBrev, HookFileName), []byte(hook), 0o644)
+	if err != nil {
+		return "", false, breverrors.WrapAndTrace(err)
+	}
+	generation := hex.EncodeToString(sum.Sum(nil))[:16]
+	changed, err := r.writeUserFile(filepath.Join(dotBrev, GenerationFileName), []byte(generation+"\n"), 0o644)
+	if err != nil {
+		return "", false, breverrors.WrapAndTrace(err)
+	}
+	return generation, changed, nil
+}
+
+// envFiles are the destinations shells source
+func envFiles(destinations []string) []string {
+	env := []string{}
+	for _, d := range destinations {
+		if strings.HasSuffix(d, ".env") {
+			env = append(env, d)
+		}
+	}
+	return env
+}
+
+func shellWords(words []string) string {
+	quoted := []string{}
+	for _, w := range words {
+		quoted = append(quoted, "'"+strings.ReplaceAll(w, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (r RefreshTask) dotBrevPath() string {
+	return filepath.Join(r.User.HomeDir, ".brev")
+}
+
+// inHome is whether path is below the home of the user, only files there are
+// given to the user, a secret rendered anywhere else stays as the agent made it
+func (r RefreshTask) inHome(path string) bool {
+	rel, err := filepath.Rel(r.User.HomeDir, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel)
+}
+
+// giveToUser chowns a rendered file in the home of the user to them. The
+// checks here give a clear error, chownFile makes them again on the file it
+// chowns since the user can swap the path in between.
+func (r RefreshTask) giveToUser(path string) error {
+	if !r.inHome(path) {
+		return nil
+	}
+	err := r.checkNoSymlinks(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	info, err := r.fs.Stat(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	// a hard link could be to a file of root
+	if !info.Mode().IsRegular() || linkCount(info) > 1 {
+		return fmt.Errorf("%s is not a regular file with one link", path)
+	}
+	err = r.chownFile(path, r.User)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// lchownToUser chowns what brev made in the home of the user, a symlink the
+// user put in its place is chowned itself instead of what it points to
+func lchownToUser(path string, u *user.User) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Lchown(path, uid, gid)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// writeUserFile writes a file of brev in the home of the user as root, with
+// the user owning the result
+func (r RefreshTask) writeUserFile(path string, data []byte, perm os.FileMode) (bool, error) {
+	if !r.inHome(path) {
+		return false, fmt.Errorf("%s is not in %s", path, r.User.HomeDir)
+	}
+	err := r.checkNoSymlinks(path)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	err = r.mkdirAll(filepath.Dir(path))
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	changed, err := files.WriteFileAtomicAs(r.fs, path, data, perm, func(tmpPath string) error {
+		return r.chown(tmpPath, r.User)
+	})
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return changed, nil
+}
+
+// checkNoSymlinks checks the parts of a path in the home of the user, so a
+// symlink the user made is refused instead of followed as root
+func (r RefreshTask) checkNoSymlinks(path string) error {
+	lstater, ok := r.fs.(afero.Lstater)
+	if !ok {
+		return nil
+	}
+	for p := path; r.inHome(p); p = filepath.Dir(p) {
+		info, _, err := lstater.LstatIfPossible(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink, secrets are not written through one", p)
+		}
+	}
+	return nil
+}
+
+// mkdirAll creates the missing directories of dir in the home of the user
+// owned by the user, directories that exist are left as they are
+func (r RefreshTask) mkdirAll(dir string) error {
+	missing := []string{}
+	for p := dir; r.inHome(p); p = filepath.Dir(p) {
+		exists, err := afero.DirExists(r.fs, p)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if exists {
+			break
+		}
+		missing = append(missing, p)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := r.fs.Mkdir(missing[i], 0o755)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = r.chown(missing[i], r.User)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+// runHooks runs the executables in HooksDirName, a failing hook is logged
+func (r RefreshTask) runHooks(config string) {
+	hooksDir := filepath.Join(r.dotBrevPath(), HooksDirName)
+	entries, err := afero.ReadDir(r.fs, hooksDir)
+	if err != nil {
+		return
+	}
+	destinations, err := TemplateDestinations(config)
+	if err != nil {
+		return
+	}
+	env := []string{envFilesVar + "=" + strings.Join(envFiles(destinations), string(filepath.ListSeparator))}
+	for _, e := range entries {
+		if !e.Mode().IsRegular() || e.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		path := filepath.Join(hooksDir, e.Name())
+		err := r.runHook(path, env)
+		if err != nil {
+			log.Printf("secrets hook %s failed: %v", path, err)
+		}
+	}
+}
+
+func (r RefreshTask) runHookAsUser(path string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path) //nolint:gosec // hooks are the user's own
+	cmd.Dir = r.User.HomeDir
+	err := setupworkspace.CmdAsUser(cmd, r.User)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return breverrors.WrapAndTrace(err, string(out))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package workspacesecrets
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `vault {
+  address = "https://vault.brev.sh"
+}
+
+template {
+  source      = "/etc/config/secrets.env.tmpl"
+  destination = "/home/brev/.brev/secrets.env"
+}
+
+template {
+  contents    = "{{ with secret \"kv/aws\" }}{{ .Data.key }}{{ end }}"
+  destination = "/home/brev/.aws/key"
+}
+
+template {
+  contents    = "x"
+  destination = "/etc/sudoers.d/brev"
+}
+`
+
+type mockRefreshStore struct {
+	config string
+}
+
+func (m *mockRefreshStore) GetCurrentWorkspaceID() (string, error) {
+	return "ws-1", nil
+}
+
+func (m *mockRefreshStore) GetWorkspaceSecretsConfig(_ string) (string, error) {
+	return m.config, nil
+}
+
+func makeTestTask(fs afero.Fs, home string, s RefreshStore) (*RefreshTask, *[]string, *[]string) {
+	chowned := []string{}
+	hooks := []string{}
+	t := &RefreshTask{
+		Store:      s,
+		User:       &user.User{Username: "brev", Uid: "1000", Gid: "1000", HomeDir: home},
+		ConfigPath: "/etc/config/config.hcl",
+		fs:         fs,
+		chown: func(path string, _ *user.User) error {
+			chowned = append(chowned, path)
+			return nil
+		},
+		chownFile: func(path string, _ *user.User) error {
+			chowned = append(chowned, path)
+			return nil
+		},
+		runHook: func(path string, env []string) error {
+			hooks = append(hooks, path)
+			return nil
+		},
+	}
+	return t, &chowned, &hooks
+}
+
+func TestTemplateDestinations(t *testing.T) {
+	destinations, err := TemplateDestinations(testConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/etc/sudoers.d/brev", "/home/brev/.aws/key", "/home/brev/.brev/secrets.env"}, destinations)
+	assert.Equal(t, []string{"/home/brev/.brev/secrets.env"}, envFiles(destinations))
+}
+
+func TestRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := &mockRefreshStore{config: testConfig}
+	task, chowned, hooks := makeTestTask(fs, "/home/brev", s)
+	assert.Nil(t, fs.MkdirAll("/home/brev/.brev/hooks/secrets-refreshed", 0o755))
+	assert.Nil(t, afero.WriteFile(fs, "/home/brev/.brev/hooks/secrets-refreshed/reload", []byte("#!/bin/sh"), 0o755))
+	assert.Nil(t, afero.WriteFile(fs, "/home/brev/.brev/hooks/secrets-refreshed/README", []byte("notes"), 0o644))
+	// what the agent rendered
+	assert.Nil(t, afero.WriteFile(fs, "/home/brev/.brev/secrets.env", []byte("export SERVER_URL=https://brev.sh\n"), 0o600))
+	assert.Nil(t, afero.WriteFile(fs, "/etc/sudoers.d/brev", []byte("x"), 0o440))
+
+	err := task.Run()
+	assert.Nil(t, err)
+	config, err := afero.ReadFile(fs, "/etc/config/config.hcl")
+	assert.Nil(t, err)
+	assert.Equal(t, testConfig, string(config))
+	assert.Contains(t, *chowned, "/home/brev/.brev/secrets.env")
+	assert.NotContains(t, *chowned, "/etc/sudoers.d/brev")
+	assert.NotContains(t, *chowned, "/home/brev/.brev")
+	hook, err := afero.ReadFile(fs, "/home/brev/.brev/secrets-hook.sh")
+	assert.Nil(t, err)
+	assert.Contains(t, string(hook), "for __brev_secrets_file in '/home/brev/.brev/secrets.env'; do")
+	assert.Equal(t, []string{"/home/brev/.brev/hooks/secrets-refreshed/reload"}, *hooks)
+	generation, err := afero.ReadFile(fs, "/home/brev/.brev/secrets.generation")
+	assert.Nil(t, err)
+
+	// nothing rendered since, no hook runs
+	err = task.Run()
+	assert.Nil(t, err)
+	assert.Len(t, *hooks, 1)
+
+	// the agent rendered a new file
+	assert.Nil(t, fs.MkdirAll("/home/brev/.aws", 0o700))
+	assert.Nil(t, afero.WriteFile(fs, "/home/brev/.aws/key", []byte("key1"), 0o600))
+	err = task.Run()
+	assert.Nil(t, err)
+	assert.Contains(t, *chowned, "/home/brev/.aws/key")
+	assert.NotContains(t, *chowned, "/home/brev/.aws")
+	assert.Len(t, *hooks, 2)
+	newGeneration, err := afero.ReadFile(fs, "/home/brev/.brev/secrets.generation")
+	assert.Nil(t, err)
+	assert.NotEqual(t, generation, newGeneration)
+}
+
+func TestApplyRefusesSymlinks(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(outside, "key"), []byte("root"), 0o600))
+	assert.Nil(t, os.Symlink(outside, filepath.Join(home, ".aws")))
+	task, chowned, _ := makeTestTask(afero.NewOsFs(), home, &mockRefreshStore{})
+
+	_, _, err := task.Apply(`template {
+  destination = "` + filepath.Join(home, ".aws", "key") + `"
+}`)
+	assert.NotNil(t, err)
+	assert.Empty(t, *chowned)
+}
+
+func TestApplyRefusesHardLinks(t *testing.T) {
+	home := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "shadow")
+	assert.Nil(t, os.WriteFile(outside, []byte("root"), 0o600))
+	assert.Nil(t, os.Link(outside, filepath.Join(home, "key")))
+	task, chowned, _ := makeTestTask(afero.NewOsFs(), home, &mockRefreshStore{})
+
+	_, _, err := task.Apply(`template {
+  destination = "` + filepath.Join(home, "key") + `"
+}`)
+	assert.NotNil(t, err)
+	assert.Empty(t, *chowned)
+}